@@ -2,36 +2,80 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
+	"time"
 
+	"github.com/art-es/queue-service/internal/app/domain"
+	"github.com/art-es/queue-service/internal/app/services/consumer"
+	binaryio "github.com/art-es/queue-service/internal/app/services/consumer/binaryio"
+	"github.com/art-es/queue-service/internal/app/services/health"
 	"github.com/art-es/queue-service/internal/app/services/queue"
 	"github.com/art-es/queue-service/internal/app/services/task"
 	"github.com/art-es/queue-service/internal/cache/inmemory"
 	"github.com/art-es/queue-service/internal/infra/clock"
+	"github.com/art-es/queue-service/internal/infra/idgen"
 	"github.com/art-es/queue-service/internal/infra/initial"
 	"github.com/art-es/queue-service/internal/infra/log"
 	"github.com/art-es/queue-service/internal/infra/log/logimpl"
+	"github.com/art-es/queue-service/internal/infra/metrics/metricsimpl"
+	"github.com/art-es/queue-service/internal/infra/notify"
+	"github.com/art-es/queue-service/internal/infra/random"
 	"github.com/art-es/queue-service/internal/repository/psql"
+	psqlidempotency "github.com/art-es/queue-service/internal/repository/psql/idempotency"
+	psqlmigration "github.com/art-es/queue-service/internal/repository/psql/migration"
+	psqlqueue "github.com/art-es/queue-service/internal/repository/psql/queue"
 	psqltask "github.com/art-es/queue-service/internal/repository/psql/task"
 	httpadapter "github.com/art-es/queue-service/internal/transport/http/adapter"
 	httpendpoints "github.com/art-es/queue-service/internal/transport/http/endpoints"
+	netconsumer "github.com/art-es/queue-service/internal/transport/net/consumer"
 )
 
+const (
+	retentionInterval = time.Minute
+
+	idempotencyKeyStorePsql   = "psql"
+	idempotencyKeyStoreMemory = "memory"
+
+	defaultIdempotencyKeyRetentionSeconds = 60 * 60 * 24
+	defaultIdempotencyKeyCacheSize        = 100000
+	defaultShutdownTimeoutSeconds         = 30
+)
+
+// idempotencyKeyStore is implemented by both the psql repository and the in-memory cache.
+type idempotencyKeyStore interface {
+	GetQueuePush(ctx context.Context, queueName, key string) (*domain.PushRecord, bool, error)
+	SetQueuePush(ctx context.Context, queueName, key string, record *domain.PushRecord) error
+	HasTaskAck(ctx context.Context, key string) (bool, error)
+	SetTaskAck(ctx context.Context, key string) error
+	HasTaskNack(ctx context.Context, key string) (bool, error)
+	SetTaskNack(ctx context.Context, key string) error
+	DeleteExpired(ctx context.Context) (int64, error)
+	Transactional() bool
+}
+
 var (
 	appCtx, appCtxCancel = context.WithCancel(context.Background())
 
-	logger     log.Logger
-	baseLogger log.Logger
-	httpServer *http.Server
+	logger          log.Logger
+	baseLogger      log.Logger
+	httpServer      *http.Server
+	consumerServer  *netconsumer.Server
+	consumerService *consumer.Service
+	healthService   *health.Service
+	shutdownTimeout time.Duration
 
 	// Connections (should be closed)
-	serviceListener net.Listener
-	psqlConn        psql.Conn
+	serviceListener  net.Listener
+	consumerListener net.Listener
+	psqlConn         psql.Conn
+	psqlListener     *psql.Listener
 )
 
 func main() {
@@ -45,58 +89,130 @@ func main() {
 			Write()
 
 		teardown()
+		os.Exit(1)
 	}
 
-	go func() {
-		signalChan := make(chan os.Signal, 1)
-		signal.Notify(signalChan, syscall.SIGINT, syscall.SIGTERM)
+	logger.Log(log.LevelInfo).
+		With("message", "service started").
+		With("addr", serviceListener.Addr().String()).
+		With("consumer_addr", consumerListener.Addr().String()).
+		Write()
 
-		<-signalChan
-		teardown()
+	go consumerServer.Serve(consumerListener)
+
+	go func() {
+		if err := httpServer.Serve(serviceListener); !errors.Is(err, http.ErrServerClosed) {
+			logger.Log(log.LevelError).
+				With("message", "http serve error").
+				With("error", err.Error()).
+				Write()
+		}
 	}()
 
+	signalChan := make(chan os.Signal, 1)
+	signal.Notify(signalChan, syscall.SIGINT, syscall.SIGTERM)
+	<-signalChan
+
 	logger.Log(log.LevelInfo).
-		With("message", "service started").
-		With("addr", serviceListener.Addr().String()).
+		With("message", "service shutting down").
 		Write()
 
-	_ = httpServer.Serve(serviceListener)
+	teardown()
 }
 
 func setup() error {
 	var serviceAddr string
+	var consumerAddr string
 	var psqlSource string
+	idempotencyKeyStoreKind := idempotencyKeyStorePsql
+	idempotencyKeyRetentionSeconds := defaultIdempotencyKeyRetentionSeconds
+	idempotencyKeyCacheSize := defaultIdempotencyKeyCacheSize
+	shutdownTimeoutSeconds := defaultShutdownTimeoutSeconds
 
 	err := initial.ParseEnv(
 		initial.Env{Name: "SERVICE_ADDR", Target: &serviceAddr, Required: true},
+		initial.Env{Name: "CONSUMER_ADDR", Target: &consumerAddr, Required: true},
 		initial.Env{Name: "PSQL_SOURCE", Target: &psqlSource, Required: true},
+		initial.Env{Name: "IDEMPOTENCY_KEY_STORE", Target: &idempotencyKeyStoreKind},
+		initial.Env{Name: "IDEMPOTENCY_KEY_RETENTION_SECONDS", Target: &idempotencyKeyRetentionSeconds},
+		initial.Env{Name: "IDEMPOTENCY_KEY_CACHE_SIZE", Target: &idempotencyKeyCacheSize},
+		initial.Env{Name: "SHUTDOWN_TIMEOUT_SECONDS", Target: &shutdownTimeoutSeconds},
 	)
 	if err != nil {
 		return err
 	}
 
+	// Nothing could be kept by a cache with no room for a key
+	if idempotencyKeyCacheSize <= 0 {
+		return fmt.Errorf("env %q must be positive: %d", "IDEMPOTENCY_KEY_CACHE_SIZE", idempotencyKeyCacheSize)
+	}
+
+	shutdownTimeout = time.Duration(shutdownTimeoutSeconds) * time.Second
+
 	serviceListener, err = net.Listen("tcp", serviceAddr)
 	if err != nil {
 		return fmt.Errorf("listen service addr: %w", err)
 	}
 
-	psqlConn, err = psql.Connect(psqlSource, baseLogger)
+	consumerListener, err = net.Listen("tcp", consumerAddr)
+	if err != nil {
+		return fmt.Errorf("listen consumer addr: %w", err)
+	}
+
+	metricsRegistry := metricsimpl.NewRegistry()
+
+	psqlConn, err = psql.Connect(psqlSource, metricsRegistry, baseLogger)
 	if err != nil {
 		return fmt.Errorf("psql connect: %w", err)
 	}
 
 	psqlExecGetter := psql.NewExecGetter(psqlConn)
+	queueRepository := psqlqueue.NewRepository(psqlExecGetter)
 	taskRepository := psqltask.NewRepository(psqlExecGetter)
+	migrationRepository := psqlmigration.NewRepository(psqlExecGetter)
 	clockObj := clock.NewClock()
-	idempotencyKeyCache := inmemory.NewIdempotencyKeyCache()
+	idGenerator := idgen.NewGenerator()
+	randomObj := random.NewRandom()
+	notifyHub := notify.NewHub()
 
-	queueService := queue.NewService(clockObj, idempotencyKeyCache, taskRepository, baseLogger)
-	taskService := task.NewService(clockObj, idempotencyKeyCache, taskRepository, baseLogger)
+	var idempotencyKeys idempotencyKeyStore
+	idempotencyKeyRetention := time.Duration(idempotencyKeyRetentionSeconds) * time.Second
+	switch idempotencyKeyStoreKind {
+	case idempotencyKeyStorePsql:
+		idempotencyKeys = psqlidempotency.NewRepository(psqlExecGetter, idempotencyKeyRetention)
+	case idempotencyKeyStoreMemory:
+		idempotencyKeys = inmemory.NewIdempotencyKeyCache(clockObj, idempotencyKeyRetention, idempotencyKeyCacheSize)
+	default:
+		return fmt.Errorf("unknown idempotency key store: %s", idempotencyKeyStoreKind)
+	}
+
+	psqlListener, err = psql.NewListener(psqlSource, notifyHub, baseLogger)
+	if err != nil {
+		return fmt.Errorf("psql listen: %w", err)
+	}
 
-	httpRouter := httpadapter.NewMuxRouter()
+	queueService := queue.NewService(clockObj, idGenerator, idempotencyKeys, notifyHub, queueRepository, taskRepository, metricsRegistry, baseLogger)
+	taskService := task.NewService(clockObj, randomObj, idempotencyKeys, queueRepository, taskRepository, metricsRegistry, baseLogger)
+	healthService = health.NewService(psqlConn, migrationRepository, psqlmigration.ExpectedVersion)
+	consumerService = consumer.NewService(appCtx, binaryio.New(), queueService, taskService, baseLogger)
+
+	httpRouter := httpadapter.NewMuxRouter(metricsRegistry)
+	httpendpoints.RegisterHealthz(httpRouter)
+	httpendpoints.RegisterMetrics(httpRouter, metricsRegistry)
+	httpendpoints.RegisterReadyz(httpRouter, healthService, baseLogger)
+	httpendpoints.RegisterV1QueuesDead(httpRouter, queueService, baseLogger)
+	httpendpoints.RegisterV1QueuesDeadPurge(httpRouter, queueService, baseLogger)
+	httpendpoints.RegisterV1QueuesDeadRedrive(httpRouter, queueService, baseLogger)
+	httpendpoints.RegisterV1QueuesDelete(httpRouter, queueService, baseLogger)
+	httpendpoints.RegisterV1QueuesGet(httpRouter, queueService, baseLogger)
+	httpendpoints.RegisterV1QueuesList(httpRouter, queueService, baseLogger)
 	httpendpoints.RegisterV1QueuesPop(httpRouter, queueService, baseLogger)
 	httpendpoints.RegisterV1QueuesPush(httpRouter, queueService, baseLogger)
+	httpendpoints.RegisterV1QueuesPushBatch(httpRouter, queueService, baseLogger)
+	httpendpoints.RegisterV1QueuesPut(httpRouter, queueService, baseLogger)
+	httpendpoints.RegisterV1QueuesStats(httpRouter, queueService, baseLogger)
 	httpendpoints.RegisterV1TasksAck(httpRouter, taskService, baseLogger)
+	httpendpoints.RegisterV1TasksExtend(httpRouter, taskService, baseLogger)
 	httpendpoints.RegisterV1TasksNack(httpRouter, taskService, baseLogger)
 	httpServer = &http.Server{
 		Handler: httpRouter.Mux,
@@ -104,14 +220,46 @@ func setup() error {
 			return appCtx
 		},
 	}
+	consumerServer = netconsumer.NewServer(consumerService, metricsRegistry, baseLogger)
+
+	go psqlListener.Run(appCtx)
+	go queueService.RunRetention(appCtx, retentionInterval)
 
 	return nil
 }
 
+// teardown stops accepting work, waits for the in-flight requests and consumers up to the shutdown
+// timeout and only then closes the connections.
 func teardown() {
-	appCtxCancel()
+	if healthService != nil {
+		healthService.Shutdown()
+	}
+
+	if consumerListener != nil {
+		if err := consumerListener.Close(); err != nil {
+			logger.Log(log.LevelError).
+				With("message", "consumer listener close error").
+				With("error", err.Error()).
+				Write()
+		}
+	}
 
-	if serviceListener != nil {
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer shutdownCancel()
+
+	var wg sync.WaitGroup
+
+	if httpServer != nil {
+		// Closes the service listener and waits for the in-flight requests
+		wg.Go(func() {
+			if err := httpServer.Shutdown(shutdownCtx); err != nil {
+				logger.Log(log.LevelError).
+					With("message", "http server shutdown error").
+					With("error", err.Error()).
+					Write()
+			}
+		})
+	} else if serviceListener != nil {
 		if err := serviceListener.Close(); err != nil {
 			logger.Log(log.LevelError).
 				With("message", "service listener close error").
@@ -120,6 +268,26 @@ func teardown() {
 		}
 	}
 
+	if consumerService != nil {
+		wg.Go(func() {
+			consumerService.Shutdown(shutdownCtx)
+		})
+	}
+
+	wg.Wait()
+
+	// Cancels the requests left after the timeout and the background jobs
+	appCtxCancel()
+
+	if psqlListener != nil {
+		if err := psqlListener.Close(); err != nil {
+			logger.Log(log.LevelError).
+				With("message", "psql listener close error").
+				With("error", err.Error()).
+				Write()
+		}
+	}
+
 	if psqlConn != nil {
 		if err := psqlConn.Close(); err != nil {
 			logger.Log(log.LevelError).
@@ -0,0 +1,2 @@
+ALTER TABLE tasks
+    ADD COLUMN lease_token UUID DEFAULT NULL;
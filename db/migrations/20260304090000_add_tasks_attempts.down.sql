@@ -0,0 +1,12 @@
+DELETE FROM tasks
+WHERE status = 'dead';
+
+ALTER TABLE tasks
+    DROP CONSTRAINT status_check;
+
+ALTER TABLE tasks
+    ADD CONSTRAINT status_check
+    CHECK (status IN ('pending', 'processing', 'failed'));
+
+ALTER TABLE tasks
+    DROP COLUMN attempts;
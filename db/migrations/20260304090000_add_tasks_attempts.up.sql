@@ -0,0 +1,9 @@
+ALTER TABLE tasks
+    ADD COLUMN attempts INT NOT NULL DEFAULT 0;
+
+ALTER TABLE tasks
+    DROP CONSTRAINT status_check;
+
+ALTER TABLE tasks
+    ADD CONSTRAINT status_check
+    CHECK (status IN ('pending', 'processing', 'failed', 'dead'));
@@ -0,0 +1,7 @@
+DROP INDEX idx_tasks_pop;
+
+CREATE INDEX idx_tasks_pop
+    ON tasks (queue_name, status, locked_until, created_at);
+
+ALTER TABLE tasks
+    DROP COLUMN priority;
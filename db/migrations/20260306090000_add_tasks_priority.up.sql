@@ -0,0 +1,10 @@
+ALTER TABLE tasks
+    ADD COLUMN priority INT NOT NULL DEFAULT 0;
+
+DROP INDEX idx_tasks_pop;
+
+-- Pop walks the index in delivery order, dead tasks are left out by the predicate.
+-- The visibility of the rest is checked on the heap rows, which are read anyway to lock them.
+CREATE INDEX idx_tasks_pop
+    ON tasks (queue_name, priority DESC, created_at ASC)
+    WHERE status <> 'dead';
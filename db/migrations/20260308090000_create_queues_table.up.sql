@@ -0,0 +1,17 @@
+CREATE TABLE queues (
+    name                    TEXT PRIMARY KEY,
+    visibility_timeout      INT NOT NULL,
+    max_visibility_timeout  INT NOT NULL,
+    backoff_policy          TEXT NOT NULL,
+    backoff_initial         INT NOT NULL,
+    backoff_max             INT NOT NULL DEFAULT 0,
+    max_attempts            INT NOT NULL DEFAULT 0,
+    max_payload_size        INT NOT NULL,
+    retention               INT NOT NULL DEFAULT 0,
+    created_at              TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at              TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+ALTER TABLE queues
+    ADD CONSTRAINT backoff_policy_check
+    CHECK (backoff_policy IN ('fixed', 'exponential'));
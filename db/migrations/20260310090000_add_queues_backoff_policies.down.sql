@@ -0,0 +1,10 @@
+UPDATE queues
+SET backoff_policy = 'exponential'
+WHERE backoff_policy NOT IN ('fixed', 'exponential');
+
+ALTER TABLE queues
+    DROP CONSTRAINT backoff_policy_check;
+
+ALTER TABLE queues
+    ADD CONSTRAINT backoff_policy_check
+    CHECK (backoff_policy IN ('fixed', 'exponential'));
@@ -0,0 +1,6 @@
+ALTER TABLE queues
+    DROP CONSTRAINT backoff_policy_check;
+
+ALTER TABLE queues
+    ADD CONSTRAINT backoff_policy_check
+    CHECK (backoff_policy IN ('fixed', 'linear', 'exponential', 'decorrelated_jitter'));
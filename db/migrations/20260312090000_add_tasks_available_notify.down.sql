@@ -0,0 +1,5 @@
+DROP TRIGGER tasks_available_update ON tasks;
+
+DROP TRIGGER tasks_available_insert ON tasks;
+
+DROP FUNCTION notify_task_available();
@@ -0,0 +1,21 @@
+CREATE FUNCTION notify_task_available() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('task_available', json_build_object(
+        'queue', NEW.queue_name,
+        'available_at_ms', (extract(epoch FROM coalesce(NEW.locked_until, now())) * 1000)::bigint
+    )::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER tasks_available_insert
+    AFTER INSERT ON tasks
+    FOR EACH ROW
+    WHEN (NEW.status = 'pending')
+    EXECUTE FUNCTION notify_task_available();
+
+CREATE TRIGGER tasks_available_update
+    AFTER UPDATE OF status ON tasks
+    FOR EACH ROW
+    WHEN (NEW.status IN ('pending', 'failed') AND OLD.status IS DISTINCT FROM NEW.status)
+    EXECUTE FUNCTION notify_task_available();
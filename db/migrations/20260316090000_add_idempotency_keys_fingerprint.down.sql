@@ -0,0 +1,12 @@
+DELETE FROM idempotency_keys
+WHERE queue_name <> '';
+
+ALTER TABLE idempotency_keys
+    DROP CONSTRAINT idempotency_keys_pkey;
+
+ALTER TABLE idempotency_keys
+    ADD PRIMARY KEY (scope, key);
+
+ALTER TABLE idempotency_keys
+    DROP COLUMN fingerprint,
+    DROP COLUMN queue_name;
@@ -0,0 +1,9 @@
+ALTER TABLE idempotency_keys
+    ADD COLUMN queue_name TEXT NOT NULL DEFAULT '',
+    ADD COLUMN fingerprint TEXT DEFAULT NULL;
+
+ALTER TABLE idempotency_keys
+    DROP CONSTRAINT idempotency_keys_pkey;
+
+ALTER TABLE idempotency_keys
+    ADD PRIMARY KEY (scope, queue_name, key);
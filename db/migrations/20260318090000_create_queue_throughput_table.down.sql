@@ -0,0 +1,15 @@
+DROP INDEX idx_tasks_stats;
+
+DROP TRIGGER tasks_count_failed ON tasks;
+
+DROP TRIGGER tasks_count_acked ON tasks;
+
+DROP TRIGGER tasks_count_pushed ON tasks;
+
+DROP FUNCTION count_tasks_failed();
+
+DROP FUNCTION count_tasks_acked();
+
+DROP FUNCTION count_tasks_pushed();
+
+DROP TABLE queue_throughput;
@@ -0,0 +1,73 @@
+-- Per-minute task counters. Rows are sharded by the backend PID,
+-- so concurrent transactions of the same queue rarely wait for each other's row lock.
+CREATE TABLE queue_throughput (
+    queue_name  TEXT NOT NULL,
+    minute      TIMESTAMPTZ NOT NULL,
+    shard       INT NOT NULL,
+    pushed      BIGINT NOT NULL DEFAULT 0,
+    acked       BIGINT NOT NULL DEFAULT 0,
+    failed      BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (queue_name, minute, shard)
+);
+
+CREATE FUNCTION count_tasks_pushed() RETURNS trigger AS $$
+BEGIN
+    INSERT INTO queue_throughput (queue_name, minute, shard, pushed)
+    SELECT queue_name, date_trunc('minute', now()), pg_backend_pid() % 16, count(*)
+    FROM new_tasks
+    GROUP BY queue_name
+    ON CONFLICT (queue_name, minute, shard) DO UPDATE
+    SET pushed = queue_throughput.pushed + EXCLUDED.pushed;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Acked tasks are deleted while their processing lock is held.
+CREATE FUNCTION count_tasks_acked() RETURNS trigger AS $$
+BEGIN
+    INSERT INTO queue_throughput (queue_name, minute, shard, acked)
+    SELECT queue_name, date_trunc('minute', now()), pg_backend_pid() % 16, count(*)
+    FROM old_tasks
+    WHERE status = 'processing' AND locked_until > now()
+    GROUP BY queue_name
+    ON CONFLICT (queue_name, minute, shard) DO UPDATE
+    SET acked = queue_throughput.acked + EXCLUDED.acked;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Failed tasks are nacked or dead-lettered after a processing lock expiry.
+CREATE FUNCTION count_tasks_failed() RETURNS trigger AS $$
+BEGIN
+    INSERT INTO queue_throughput (queue_name, minute, shard, failed)
+    SELECT n.queue_name, date_trunc('minute', now()), pg_backend_pid() % 16, count(*)
+    FROM new_tasks n
+    JOIN old_tasks o ON o.id = n.id
+    WHERE o.status = 'processing' AND n.status IN ('failed', 'dead')
+    GROUP BY n.queue_name
+    ON CONFLICT (queue_name, minute, shard) DO UPDATE
+    SET failed = queue_throughput.failed + EXCLUDED.failed;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER tasks_count_pushed
+    AFTER INSERT ON tasks
+    REFERENCING NEW TABLE AS new_tasks
+    FOR EACH STATEMENT
+    EXECUTE FUNCTION count_tasks_pushed();
+
+CREATE TRIGGER tasks_count_acked
+    AFTER DELETE ON tasks
+    REFERENCING OLD TABLE AS old_tasks
+    FOR EACH STATEMENT
+    EXECUTE FUNCTION count_tasks_acked();
+
+CREATE TRIGGER tasks_count_failed
+    AFTER UPDATE ON tasks
+    REFERENCING OLD TABLE AS old_tasks NEW TABLE AS new_tasks
+    FOR EACH STATEMENT
+    EXECUTE FUNCTION count_tasks_failed();
+
+CREATE INDEX idx_tasks_stats
+    ON tasks (queue_name, status);
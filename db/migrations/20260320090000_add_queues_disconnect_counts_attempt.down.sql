@@ -0,0 +1,2 @@
+ALTER TABLE queues
+    DROP COLUMN disconnect_counts_attempt;
@@ -0,0 +1,2 @@
+ALTER TABLE queues
+    ADD COLUMN disconnect_counts_attempt BOOLEAN NOT NULL DEFAULT false;
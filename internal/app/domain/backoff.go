@@ -0,0 +1,128 @@
+package domain
+
+import (
+	"time"
+)
+
+// BackoffMaxDelay caps the delay of every policy, also with no Max set.
+const BackoffMaxDelay = 7 * 24 * time.Hour
+
+const (
+	BackoffPolicyFixed              = "fixed"               // Same delay after every failure
+	BackoffPolicyLinear             = "linear"              // Delay grows by the initial delay after every failure
+	BackoffPolicyExponential        = "exponential"         // Delay doubles after every failure
+	BackoffPolicyDecorrelatedJitter = "decorrelated_jitter" // Random delay between the initial and 3x the previous one
+)
+
+// Random is a source of random numbers, it is used by jittered backoff strategies.
+type Random interface {
+	// Int64N returns a random number in [0, n).
+	Int64N(n int64) int64
+}
+
+// BackoffStrategy computes the lock duration of a failed task, given the previous one.
+type BackoffStrategy interface {
+	Delay(last *time.Duration, random Random) time.Duration
+}
+
+// Backoff defines lock durations of failed tasks.
+type Backoff struct {
+	Policy  string
+	Initial time.Duration // Delay after the first failure
+	Max     time.Duration // Zero means BackoffMaxDelay
+}
+
+func IsBackoffPolicy(policy string) bool {
+	switch policy {
+	case BackoffPolicyFixed, BackoffPolicyLinear, BackoffPolicyExponential, BackoffPolicyDecorrelatedJitter:
+		return true
+	default:
+		return false
+	}
+}
+
+// Strategy returns the strategy of the policy. Unknown policies fall back to the fixed one.
+func (b Backoff) Strategy() BackoffStrategy {
+	switch b.Policy {
+	case BackoffPolicyLinear:
+		return &linearBackoff{initial: b.Initial, max: b.Max}
+	case BackoffPolicyExponential:
+		return &exponentialBackoff{initial: b.Initial, max: b.Max}
+	case BackoffPolicyDecorrelatedJitter:
+		return &decorrelatedJitterBackoff{initial: b.Initial, max: b.Max}
+	default:
+		return &fixedBackoff{initial: b.Initial, max: b.Max}
+	}
+}
+
+// Delay returns the lock duration after a failure, given the previous one.
+func (b Backoff) Delay(last *time.Duration, random Random) time.Duration {
+	return b.Strategy().Delay(last, random)
+}
+
+type fixedBackoff struct {
+	initial time.Duration
+	max     time.Duration
+}
+
+func (b *fixedBackoff) Delay(_ *time.Duration, _ Random) time.Duration {
+	return capDelay(b.initial, b.max)
+}
+
+type linearBackoff struct {
+	initial time.Duration
+	max     time.Duration
+}
+
+func (b *linearBackoff) Delay(last *time.Duration, _ Random) time.Duration {
+	if last == nil {
+		return capDelay(b.initial, b.max)
+	}
+	return capDelay(capDelay(*last, b.max)+b.initial, b.max)
+}
+
+type exponentialBackoff struct {
+	initial time.Duration
+	max     time.Duration
+}
+
+func (b *exponentialBackoff) Delay(last *time.Duration, _ Random) time.Duration {
+	if last == nil {
+		return capDelay(b.initial, b.max)
+	}
+	// The previous delay is capped first, so doubling it can not overflow
+	return capDelay(capDelay(*last, b.max)*2, b.max)
+}
+
+// decorrelatedJitterBackoff spreads retries of tasks failed at the same time,
+// see https://aws.amazon.com/blogs/architecture/exponential-backoff-and-jitter/.
+type decorrelatedJitterBackoff struct {
+	initial time.Duration
+	max     time.Duration
+}
+
+func (b *decorrelatedJitterBackoff) Delay(last *time.Duration, random Random) time.Duration {
+	if last == nil {
+		return capDelay(b.initial, b.max)
+	}
+
+	upper := capDelay(*last, b.max) * 3
+	if upper <= b.initial {
+		return capDelay(b.initial, b.max)
+	}
+
+	delay := b.initial + time.Duration(random.Int64N(int64(upper-b.initial)))
+	return capDelay(delay, b.max)
+}
+
+// capDelay limits the delay by max, or by BackoffMaxDelay if max is not set or greater.
+func capDelay(delay, max time.Duration) time.Duration {
+	if max <= 0 || max > BackoffMaxDelay {
+		max = BackoffMaxDelay
+	}
+
+	if delay > max {
+		return max
+	}
+	return delay
+}
@@ -0,0 +1,136 @@
+package domain
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/art-es/queue-service/internal/infra/ops"
+	"github.com/stretchr/testify/assert"
+)
+
+// maxRandom always returns the highest possible value.
+type maxRandom struct{}
+
+func (maxRandom) Int64N(n int64) int64 {
+	return n - 1
+}
+
+// minRandom always returns the lowest possible value.
+type minRandom struct{}
+
+func (minRandom) Int64N(int64) int64 {
+	return 0
+}
+
+func TestBackoff_Delay(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		backoff  Backoff
+		last     *time.Duration
+		expDelay time.Duration
+	}{
+		{
+			name:     "fixed first failure",
+			backoff:  Backoff{Policy: BackoffPolicyFixed, Initial: time.Minute},
+			last:     nil,
+			expDelay: time.Minute,
+		},
+		{
+			name:     "fixed next failure",
+			backoff:  Backoff{Policy: BackoffPolicyFixed, Initial: time.Minute},
+			last:     ops.Pointer(time.Minute),
+			expDelay: time.Minute,
+		},
+		{
+			name:     "linear first failure",
+			backoff:  Backoff{Policy: BackoffPolicyLinear, Initial: time.Minute},
+			last:     nil,
+			expDelay: time.Minute,
+		},
+		{
+			name:     "linear next failure",
+			backoff:  Backoff{Policy: BackoffPolicyLinear, Initial: time.Minute},
+			last:     ops.Pointer(3 * time.Minute),
+			expDelay: 4 * time.Minute,
+		},
+		{
+			name:     "linear capped",
+			backoff:  Backoff{Policy: BackoffPolicyLinear, Initial: time.Minute, Max: 3 * time.Minute},
+			last:     ops.Pointer(3 * time.Minute),
+			expDelay: 3 * time.Minute,
+		},
+		{
+			name:     "exponential first failure",
+			backoff:  Backoff{Policy: BackoffPolicyExponential, Initial: time.Minute},
+			last:     nil,
+			expDelay: time.Minute,
+		},
+		{
+			name:     "exponential next failure",
+			backoff:  Backoff{Policy: BackoffPolicyExponential, Initial: time.Minute},
+			last:     ops.Pointer(4 * time.Minute),
+			expDelay: 8 * time.Minute,
+		},
+		{
+			name:     "exponential capped",
+			backoff:  Backoff{Policy: BackoffPolicyExponential, Initial: time.Minute, Max: 5 * time.Minute},
+			last:     ops.Pointer(4 * time.Minute),
+			expDelay: 5 * time.Minute,
+		},
+		{
+			name:     "decorrelated jitter first failure",
+			backoff:  Backoff{Policy: BackoffPolicyDecorrelatedJitter, Initial: time.Minute},
+			last:     nil,
+			expDelay: time.Minute,
+		},
+		{
+			name:     "decorrelated jitter next failure",
+			backoff:  Backoff{Policy: BackoffPolicyDecorrelatedJitter, Initial: time.Minute},
+			last:     ops.Pointer(2 * time.Minute),
+			expDelay: 6*time.Minute - 1,
+		},
+		{
+			name:     "decorrelated jitter capped",
+			backoff:  Backoff{Policy: BackoffPolicyDecorrelatedJitter, Initial: time.Minute, Max: 5 * time.Minute},
+			last:     ops.Pointer(2 * time.Minute),
+			expDelay: 5 * time.Minute,
+		},
+		{
+			name:     "linear with no max does not overflow",
+			backoff:  Backoff{Policy: BackoffPolicyLinear, Initial: time.Minute},
+			last:     ops.Pointer(time.Duration(math.MaxInt64)),
+			expDelay: BackoffMaxDelay,
+		},
+		{
+			name:     "exponential with no max does not overflow",
+			backoff:  Backoff{Policy: BackoffPolicyExponential, Initial: time.Minute},
+			last:     ops.Pointer(time.Duration(math.MaxInt64/2 + 1)),
+			expDelay: BackoffMaxDelay,
+		},
+		{
+			name:     "decorrelated jitter with no max does not overflow",
+			backoff:  Backoff{Policy: BackoffPolicyDecorrelatedJitter, Initial: time.Minute},
+			last:     ops.Pointer(time.Duration(math.MaxInt64/3 + 1)),
+			expDelay: BackoffMaxDelay,
+		},
+		{
+			name:     "unknown policy",
+			backoff:  Backoff{Policy: "unknown", Initial: time.Minute},
+			last:     ops.Pointer(4 * time.Minute),
+			expDelay: time.Minute,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expDelay, tc.backoff.Delay(tc.last, maxRandom{}))
+		})
+	}
+}
+
+func TestBackoff_Delay_DecorrelatedJitterRange(t *testing.T) {
+	backoff := Backoff{Policy: BackoffPolicyDecorrelatedJitter, Initial: time.Minute}
+	last := ops.Pointer(2 * time.Minute)
+
+	assert.Equal(t, time.Minute, backoff.Delay(last, minRandom{}))
+	assert.Equal(t, 6*time.Minute-1, backoff.Delay(last, maxRandom{}))
+}
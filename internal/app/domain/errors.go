@@ -0,0 +1,9 @@
+package domain
+
+import "errors"
+
+var (
+	ErrLeaseLost            = errors.New("lease lost")
+	ErrPayloadTooLarge      = errors.New("payload too large")
+	ErrIdempotencyKeyReused = errors.New("idempotency key reused with a different request")
+)
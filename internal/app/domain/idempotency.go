@@ -0,0 +1,29 @@
+package domain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// PushRecord is the result of a push stored for its idempotency key.
+type PushRecord struct {
+	Fingerprint string
+	Task        *Task
+}
+
+// PushFingerprint identifies a push request by its queue name and payload hash.
+func PushFingerprint(queueName, payload string) string {
+	h := sha256.New()
+	h.Write([]byte(queueName))
+	h.Write([]byte{0})
+	h.Write([]byte(payload))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Check returns ErrIdempotencyKeyReused if the record was stored for a different request.
+func (r *PushRecord) Check(fingerprint string) error {
+	if r.Fingerprint != fingerprint {
+		return ErrIdempotencyKeyReused
+	}
+	return nil
+}
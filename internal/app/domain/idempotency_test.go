@@ -0,0 +1,23 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPushFingerprint(t *testing.T) {
+	fingerprint := PushFingerprint("queue", "payload")
+
+	assert.Equal(t, fingerprint, PushFingerprint("queue", "payload"))
+	assert.NotEqual(t, fingerprint, PushFingerprint("otherQueue", "payload"))
+	assert.NotEqual(t, fingerprint, PushFingerprint("queue", "otherPayload"))
+	assert.NotEqual(t, PushFingerprint("ab", "c"), PushFingerprint("a", "bc"))
+}
+
+func TestPushRecord_Check(t *testing.T) {
+	record := &PushRecord{Fingerprint: PushFingerprint("queue", "payload")}
+
+	assert.NoError(t, record.Check(PushFingerprint("queue", "payload")))
+	assert.ErrorIs(t, record.Check(PushFingerprint("queue", "otherPayload")), ErrIdempotencyKeyReused)
+}
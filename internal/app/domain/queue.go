@@ -0,0 +1,50 @@
+package domain
+
+import (
+	"time"
+)
+
+const (
+	queueDefaultVisibilityTimeout    = 5 * time.Minute
+	queueDefaultMaxVisibilityTimeout = 12 * time.Hour
+	queueDefaultBackoffInitial       = 1 * time.Minute
+	queueDefaultBackoffMax           = 1 * time.Hour
+	queueDefaultMaxAttempts          = 0 // Unlimited, as before tasks could be dead
+	queueDefaultMaxPayloadSize       = 1024 * 4
+)
+
+// Queue holds per-queue settings. Queues without stored settings use the defaults of NewQueue.
+type Queue struct {
+	Name                    string
+	VisibilityTimeout       time.Duration // Processing lock duration after delivery
+	MaxVisibilityTimeout    time.Duration // Max processing lock duration on extension
+	Backoff                 Backoff
+	MaxAttempts             int           // Zero means unlimited attempts
+	MaxPayloadSize          int           // In bytes
+	Retention               time.Duration // Zero means tasks are kept forever
+	DisconnectCountsAttempt bool          // Unacked tasks of a disconnected consumer keep the delivery attempt
+	CreatedAt               time.Time
+	UpdatedAt               time.Time
+}
+
+func NewQueue(name string) *Queue {
+	return &Queue{
+		Name:                 name,
+		VisibilityTimeout:    queueDefaultVisibilityTimeout,
+		MaxVisibilityTimeout: queueDefaultMaxVisibilityTimeout,
+		Backoff: Backoff{
+			Policy:  BackoffPolicyExponential,
+			Initial: queueDefaultBackoffInitial,
+			Max:     queueDefaultBackoffMax,
+		},
+		MaxAttempts:    queueDefaultMaxAttempts,
+		MaxPayloadSize: queueDefaultMaxPayloadSize,
+	}
+}
+
+func (q *Queue) CheckPayload(payload string) error {
+	if len(payload) > q.MaxPayloadSize {
+		return ErrPayloadTooLarge
+	}
+	return nil
+}
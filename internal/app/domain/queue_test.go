@@ -0,0 +1,30 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueue_CheckPayload(t *testing.T) {
+	queue := NewQueue("testQueueName")
+	queue.MaxPayloadSize = 4
+
+	assert.NoError(t, queue.CheckPayload("1234"))
+	assert.ErrorIs(t, queue.CheckPayload("12345"), ErrPayloadTooLarge)
+}
+
+func TestQueue_Backoff(t *testing.T) {
+	queue := NewQueue("testQueueName")
+
+	var last *time.Duration
+	for range 100 {
+		delay := queue.Backoff.Delay(last, nil)
+		assert.Positive(t, delay)
+		assert.LessOrEqual(t, delay, queueDefaultBackoffMax)
+		last = &delay
+	}
+
+	assert.Equal(t, queueDefaultBackoffMax, *last)
+}
@@ -0,0 +1,23 @@
+package domain
+
+import "time"
+
+// QueueStats are the task counts of a queue by status.
+type QueueStats struct {
+	QueueName          string
+	Pending            int64
+	Processing         int64
+	Failed             int64
+	Dead               int64
+	OldestPendingAge   *time.Duration // Age of the oldest pending task, nil if there are none
+	NextFailedUnlockAt *time.Time     // Nearest time a failed task becomes available again, nil if there are none
+	Throughput         []*QueueThroughput
+}
+
+// QueueThroughput counts the tasks of a queue that went through each stage during the period up to now.
+type QueueThroughput struct {
+	Period time.Duration
+	Pushed int64
+	Acked  int64
+	Failed int64 // Nacked or dead after a processing lock expiry
+}
@@ -10,11 +10,7 @@ const (
 	TaskStatusPending    = "pending"    // Ready for action
 	TaskStatusProcessing = "processing" // Taken into action
 	TaskStatusFailed     = "failed"     // Action failed
-)
-
-const (
-	taskProcessingTimeout = 5 * time.Minute
-	taskFirstFailTimeout  = 1 * time.Minute
+	TaskStatusDead       = "dead"       // Max attempts reached, never delivered again
 )
 
 type Task struct {
@@ -23,30 +19,76 @@ type Task struct {
 	Payload          string
 	Status           string
 	CreatedAt        time.Time
-	LockedUntil      *time.Time
+	LockedUntil      *time.Time // Invisible for delivery until this time, if set
 	LastFailDuration *time.Duration
+	LeaseToken       string // Token of the current delivery, empty if the task is not processing
+	Attempts         int    // Number of deliveries
+	Priority         int    // Higher priority tasks are delivered first
 }
 
-func NewTask(queueName, payload string) *Task {
+// NewTask creates a pending task. A non-nil visibleAt delays the first delivery until that time.
+func NewTask(queueName, payload string, priority int, visibleAt *time.Time) *Task {
 	return &Task{
-		QueueName: queueName,
-		Payload:   payload,
-		Status:    TaskStatusPending,
+		QueueName:   queueName,
+		Payload:     payload,
+		Status:      TaskStatusPending,
+		LockedUntil: visibleAt,
+		Priority:    priority,
 	}
 }
 
-func (t *Task) ToProcessing(now time.Time) {
+func (t *Task) ToProcessing(now time.Time, leaseToken string, queue *Queue) {
 	t.Status = TaskStatusProcessing
-	t.LockedUntil = ops.Pointer(now.Add(taskProcessingTimeout))
+	t.LockedUntil = ops.Pointer(now.Add(queue.VisibilityTimeout))
+	t.LeaseToken = leaseToken
+	t.Attempts++
+}
+
+// ExtendLock pushes the processing lock forward by d,
+// but never further than the queue's max visibility timeout from now.
+func (t *Task) ExtendLock(now time.Time, d time.Duration, queue *Queue) {
+	lockedUntil := now
+	if t.LockedUntil != nil && t.LockedUntil.After(now) {
+		lockedUntil = *t.LockedUntil
+	}
+
+	lockedUntil = lockedUntil.Add(d)
+	if maxLockedUntil := now.Add(queue.MaxVisibilityTimeout); lockedUntil.After(maxLockedUntil) {
+		lockedUntil = maxLockedUntil
+	}
+
+	t.LockedUntil = ops.Pointer(lockedUntil)
+}
+
+func (t *Task) CheckLease(leaseToken string) error {
+	if t.Status != TaskStatusProcessing || t.LeaseToken != leaseToken {
+		return ErrLeaseLost
+	}
+	return nil
 }
 
-func (t *Task) ToFailed(now time.Time) {
-	lockDuration := taskFirstFailTimeout
-	if t.LastFailDuration != nil {
-		lockDuration = *t.LastFailDuration * 2
+// IsExhausted reports whether the task has been delivered maxAttempts times.
+// Zero maxAttempts means unlimited attempts.
+func (t *Task) IsExhausted(maxAttempts int) bool {
+	return maxAttempts > 0 && t.Attempts >= maxAttempts
+}
+
+// ToFailed moves the task to the failed status locked for delay or,
+// if the attempts are exhausted, to the dead status.
+func (t *Task) ToFailed(now time.Time, delay time.Duration, queue *Queue) {
+	if t.IsExhausted(queue.MaxAttempts) {
+		t.ToDead()
+		return
 	}
 
 	t.Status = TaskStatusFailed
-	t.LockedUntil = ops.Pointer(now.Add(lockDuration))
-	t.LastFailDuration = ops.Pointer(lockDuration)
+	t.LockedUntil = ops.Pointer(now.Add(delay))
+	t.LastFailDuration = ops.Pointer(delay)
+	t.LeaseToken = ""
+}
+
+func (t *Task) ToDead() {
+	t.Status = TaskStatusDead
+	t.LockedUntil = nil
+	t.LeaseToken = ""
 }
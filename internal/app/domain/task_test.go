@@ -13,15 +13,34 @@ func TestNewTask(t *testing.T) {
 	queueName := "testQueueName"
 	payload := "testPayload"
 
-	task := NewTask(queueName, payload)
+	t.Run("visible immediately", func(t *testing.T) {
+		task := NewTask(queueName, payload, 0, nil)
 
-	expTask := &Task{
-		QueueName: queueName,
-		Payload:   payload,
-		Status:    TaskStatusPending,
-	}
+		expTask := &Task{
+			QueueName: queueName,
+			Payload:   payload,
+			Status:    TaskStatusPending,
+		}
 
-	assert.Equal(t, expTask, task)
+		assert.Equal(t, expTask, task)
+	})
+
+	t.Run("visible later", func(t *testing.T) {
+		visibleAt, err := time.Parse(time.DateTime, "2006-01-02 15:04:05")
+		require.NoError(t, err)
+
+		task := NewTask(queueName, payload, 5, &visibleAt)
+
+		expTask := &Task{
+			QueueName:   queueName,
+			Payload:     payload,
+			Status:      TaskStatusPending,
+			LockedUntil: &visibleAt,
+			Priority:    5,
+		}
+
+		assert.Equal(t, expTask, task)
+	})
 }
 
 func TestTask_ToProcessing(t *testing.T) {
@@ -33,7 +52,7 @@ func TestTask_ToProcessing(t *testing.T) {
 		LockedUntil: nil,
 	}
 
-	task.ToProcessing(now)
+	task.ToProcessing(now, "testLeaseToken", NewQueue("testQueueName"))
 
 	expLockedUntil, err := time.Parse(time.DateTime, "2006-01-02 15:09:05")
 	require.NoError(t, err)
@@ -41,6 +60,8 @@ func TestTask_ToProcessing(t *testing.T) {
 	expTask := &Task{
 		Status:      TaskStatusProcessing,
 		LockedUntil: &expLockedUntil,
+		LeaseToken:  "testLeaseToken",
+		Attempts:    1,
 	}
 
 	assert.Equal(t, expTask, task)
@@ -50,13 +71,13 @@ func TestTask_ToFailed(t *testing.T) {
 	now, err := time.Parse(time.DateTime, "2006-01-02 15:04:05")
 	require.NoError(t, err)
 
-	t.Run("LastFailDuration is nil", func(t *testing.T) {
+	t.Run("first failure", func(t *testing.T) {
 		task := &Task{
 			Status:      TaskStatusPending,
 			LockedUntil: nil,
 		}
 
-		task.ToFailed(now)
+		task.ToFailed(now, time.Minute, NewQueue("testQueueName"))
 
 		expLockedUntil, err := time.Parse(time.DateTime, "2006-01-02 15:05:05")
 		require.NoError(t, err)
@@ -64,20 +85,21 @@ func TestTask_ToFailed(t *testing.T) {
 		expTask := &Task{
 			Status:           TaskStatusFailed,
 			LockedUntil:      &expLockedUntil,
-			LastFailDuration: ops.Pointer(taskFirstFailTimeout),
+			LastFailDuration: ops.Pointer(time.Minute),
 		}
 
 		assert.Equal(t, expTask, task)
 	})
 
-	t.Run("LastFailDuration is not nil", func(t *testing.T) {
+	t.Run("next failure", func(t *testing.T) {
 		task := &Task{
-			Status:           TaskStatusPending,
+			Status:           TaskStatusProcessing,
 			LockedUntil:      nil,
 			LastFailDuration: ops.Pointer(3 * time.Minute),
+			LeaseToken:       "testLeaseToken",
 		}
 
-		task.ToFailed(now)
+		task.ToFailed(now, 6*time.Minute, NewQueue("testQueueName"))
 
 		expLockedUntil, err := time.Parse(time.DateTime, "2006-01-02 15:10:05")
 		require.NoError(t, err)
@@ -91,3 +113,108 @@ func TestTask_ToFailed(t *testing.T) {
 		assert.Equal(t, expTask, task)
 	})
 }
+
+func TestTask_ToFailed_AttemptsExhausted(t *testing.T) {
+	now, err := time.Parse(time.DateTime, "2006-01-02 15:04:05")
+	require.NoError(t, err)
+
+	queue := NewQueue("testQueueName")
+	queue.MaxAttempts = 3
+
+	t.Run("attempts are not exhausted", func(t *testing.T) {
+		task := &Task{
+			Status:     TaskStatusProcessing,
+			LeaseToken: "testLeaseToken",
+			Attempts:   2,
+		}
+
+		task.ToFailed(now, time.Minute, queue)
+
+		assert.Equal(t, TaskStatusFailed, task.Status)
+		assert.Equal(t, 2, task.Attempts)
+	})
+
+	t.Run("attempts are exhausted", func(t *testing.T) {
+		lockedUntil, err := time.Parse(time.DateTime, "2006-01-02 15:09:05")
+		require.NoError(t, err)
+
+		task := &Task{
+			Status:           TaskStatusProcessing,
+			LockedUntil:      &lockedUntil,
+			LastFailDuration: ops.Pointer(2 * time.Minute),
+			LeaseToken:       "testLeaseToken",
+			Attempts:         3,
+		}
+
+		task.ToFailed(now, time.Minute, queue)
+
+		expTask := &Task{
+			Status:           TaskStatusDead,
+			LastFailDuration: ops.Pointer(2 * time.Minute),
+			Attempts:         3,
+		}
+
+		assert.Equal(t, expTask, task)
+	})
+
+	t.Run("default queue retries forever", func(t *testing.T) {
+		task := &Task{
+			Status:     TaskStatusProcessing,
+			LeaseToken: "testLeaseToken",
+			Attempts:   1000,
+		}
+
+		task.ToFailed(now, time.Minute, NewQueue("testQueueName"))
+
+		assert.Equal(t, TaskStatusFailed, task.Status)
+	})
+}
+
+func TestTask_CheckLease(t *testing.T) {
+	t.Run("lease token matches", func(t *testing.T) {
+		task := &Task{Status: TaskStatusProcessing, LeaseToken: "testLeaseToken"}
+		assert.NoError(t, task.CheckLease("testLeaseToken"))
+	})
+
+	t.Run("lease token does not match", func(t *testing.T) {
+		task := &Task{Status: TaskStatusProcessing, LeaseToken: "testLeaseToken"}
+		assert.ErrorIs(t, task.CheckLease("staleLeaseToken"), ErrLeaseLost)
+	})
+
+	t.Run("task is not processing", func(t *testing.T) {
+		task := &Task{Status: TaskStatusFailed}
+		assert.ErrorIs(t, task.CheckLease(""), ErrLeaseLost)
+	})
+}
+
+func TestTask_ExtendLock(t *testing.T) {
+	now, err := time.Parse(time.DateTime, "2006-01-02 15:04:05")
+	require.NoError(t, err)
+
+	t.Run("extend active lock", func(t *testing.T) {
+		lockedUntil, err := time.Parse(time.DateTime, "2006-01-02 15:06:05")
+		require.NoError(t, err)
+
+		task := &Task{Status: TaskStatusProcessing, LockedUntil: &lockedUntil}
+		task.ExtendLock(now, 10*time.Minute, NewQueue("testQueueName"))
+
+		expLockedUntil, err := time.Parse(time.DateTime, "2006-01-02 15:16:05")
+		require.NoError(t, err)
+
+		assert.Equal(t, &expLockedUntil, task.LockedUntil)
+	})
+
+	t.Run("extend up to max processing timeout", func(t *testing.T) {
+		lockedUntil, err := time.Parse(time.DateTime, "2006-01-02 15:06:05")
+		require.NoError(t, err)
+
+		task := &Task{Status: TaskStatusProcessing, LockedUntil: &lockedUntil}
+		queue := NewQueue("testQueueName")
+		queue.MaxVisibilityTimeout = time.Hour
+
+		task.ExtendLock(now, 24*time.Hour, queue)
+
+		expLockedUntil := now.Add(time.Hour)
+		assert.Equal(t, &expLockedUntil, task.LockedUntil)
+	})
+}
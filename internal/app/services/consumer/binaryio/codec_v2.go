@@ -0,0 +1,112 @@
+package binary
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/art-es/queue-service/internal/app/services/consumer/dto"
+)
+
+type codecV2 struct {
+	r        io.Reader
+	w        io.Writer
+	features uint32 // Negotiated in the handshake
+}
+
+func newCodecV2(r io.Reader, w io.Writer, features uint32) *codecV2 {
+	return &codecV2{r: r, w: w, features: features}
+}
+
+// Read returns nil without an error for a message of an unsupported type, its frame is skipped.
+func (c *codecV2) Read() (*dto.Message, error) {
+	msgType, requestID, body, err := readFrame(c.r)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &decoder{buf: body}
+
+	var msgData any
+	switch msgType {
+	case inputTypeQueueSubscribe:
+		data := dto.MessageDataQueueSubscribe{
+			QueueName: d.string(),
+			Credits:   dto.UnlimitedCredits,
+		}
+		if c.features&FeatureCredits != 0 {
+			data.Credits = int(d.uint32())
+		}
+		msgData = data
+	case inputTypeTaskAck, inputTypeTaskNack:
+		msgData = dto.MessageDataTaskLease{
+			TaskID:     d.uuid(),
+			LeaseToken: d.uuid(),
+		}
+	case inputTypeTaskExtend:
+		msgData = dto.MessageDataTaskExtend{
+			TaskID:     d.uuid(),
+			LeaseToken: d.uuid(),
+			Duration:   time.Duration(d.int64()),
+		}
+	case inputTypeQueuePop:
+		msgData = dto.MessageDataQueuePop{
+			QueueName: d.string(),
+			Max:       int(d.uint16()),
+		}
+	case inputTypeSubscriptionCredits:
+		msgData = dto.MessageDataSubscriptionCredits{
+			SubscriptionID: d.uint32(),
+			Credits:        int(d.uint32()),
+		}
+	case inputTypeQueueUnsubscribe:
+		msgData = dto.MessageDataSubscriptionID(d.uint32())
+	default:
+		return nil, nil
+	}
+
+	if d.err != nil {
+		return nil, fmt.Errorf("decode message: %w", d.err)
+	}
+
+	return &dto.Message{
+		Type:      dto.MessageType(msgType),
+		RequestID: requestID,
+		Data:      msgData,
+	}, nil
+}
+
+func (c *codecV2) Write(msg *dto.Message) error {
+	e := &encoder{}
+
+	switch data := msg.Data.(type) {
+	case dto.MessageDataTask:
+		// A delivery is tagged with its subscription
+		e.uint32(data.SubscriptionID)
+		e.string(data.QueueName)
+		e.task(data)
+	case dto.MessageDataSubscriptionID:
+		e.uint32(uint32(data))
+	case dto.MessageDataTasks:
+		e.uint16(uint16(len(data)))
+		for _, task := range data {
+			e.task(task)
+		}
+	case dto.MessageDataError:
+		e.uint16(uint16(data.Code))
+		e.string(data.Message)
+	}
+
+	if e.err != nil {
+		return fmt.Errorf("encode message: %w", e.err)
+	}
+
+	return writeFrame(c.w, uint8(msg.Type), msg.RequestID, e.buf)
+}
+
+func (e *encoder) task(task dto.MessageDataTask) {
+	e.uuid(task.ID)
+	e.string(task.Payload)
+	e.int64(task.CreatedAt.UnixNano())
+	e.uuid(task.LeaseToken)
+}
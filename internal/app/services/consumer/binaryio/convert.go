@@ -1,14 +1,19 @@
 package binary
 
-import "time"
+import (
+	"fmt"
+	"time"
 
-func convertStringToUUIDBytes(str string) [sizeUUID]byte {
-	slice := []byte(str)
-	array := [sizeUUID]byte{}
-	for i := 0; i < len(slice) && i < len(array); i++ {
-		array[i] = slice[i]
+	"github.com/google/uuid"
+)
+
+// convertStringToUUIDBytes returns the raw bytes of the UUID, so the client can send it back as is.
+func convertStringToUUIDBytes(str string) ([sizeUUID]byte, error) {
+	id, err := uuid.Parse(str)
+	if err != nil {
+		return [sizeUUID]byte{}, fmt.Errorf("parse uuid %q: %w", str, err)
 	}
-	return array
+	return id, nil
 }
 
 func convertTimeToDateTimeBytes(t time.Time) [sizeDateTime]byte {
@@ -30,14 +35,7 @@ func convertStringToLongBytes(str string) [sizeLongText]byte {
 }
 
 func convertUUIDBytesToString(array [sizeUUID]byte) string {
-	slice := make([]byte, 0, sizeUUID)
-	for _, b := range array {
-		if b == byte(0) {
-			break
-		}
-		slice = append(slice, b)
-	}
-	return string(slice)
+	return uuid.UUID(array).String()
 }
 
 func convertShortBytesToString(array [sizeShortText]byte) string {
@@ -0,0 +1,145 @@
+package binary
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/google/uuid"
+)
+
+const (
+	frameHeaderSize = 1 + 8    // Message type and request ID
+	maxFrameSize    = 16 << 20 // Guards against allocating whatever a broken client sends
+)
+
+var errFrameTooShort = errors.New("frame is too short")
+
+// readFrame reads a v2 frame: the size of the rest, the message type, the request ID and the body.
+func readFrame(r io.Reader) (msgType uint8, requestID uint64, body []byte, err error) {
+	var size uint32
+	if err = binary.Read(r, binary.BigEndian, &size); err != nil {
+		return 0, 0, nil, err
+	}
+
+	if size < frameHeaderSize || size > maxFrameSize {
+		return 0, 0, nil, fmt.Errorf("invalid frame size %d", size)
+	}
+
+	buf := make([]byte, size)
+	if _, err = io.ReadFull(r, buf); err != nil {
+		return 0, 0, nil, fmt.Errorf("read frame: %w", err)
+	}
+
+	return buf[0], binary.BigEndian.Uint64(buf[1:frameHeaderSize]), buf[frameHeaderSize:], nil
+}
+
+// writeFrame writes the frame with a single write, so frames of concurrent writers never interleave.
+func writeFrame(w io.Writer, msgType uint8, requestID uint64, body []byte) error {
+	buf := make([]byte, 0, 4+frameHeaderSize+len(body))
+	buf = binary.BigEndian.AppendUint32(buf, uint32(frameHeaderSize+len(body)))
+	buf = append(buf, msgType)
+	buf = binary.BigEndian.AppendUint64(buf, requestID)
+	buf = append(buf, body...)
+
+	_, err := w.Write(buf)
+	return err
+}
+
+// decoder reads the body fields. The first error is kept and the following reads return zero values.
+// Bytes left after the known fields are ignored, so later versions may append fields.
+type decoder struct {
+	buf []byte
+	err error
+}
+
+func (d *decoder) next(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+
+	if len(d.buf) < n {
+		d.err = errFrameTooShort
+		return nil
+	}
+
+	out := d.buf[:n]
+	d.buf = d.buf[n:]
+	return out
+}
+
+func (d *decoder) uint16() uint16 {
+	if b := d.next(2); b != nil {
+		return binary.BigEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (d *decoder) uint32() uint32 {
+	if b := d.next(4); b != nil {
+		return binary.BigEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (d *decoder) int64() int64 {
+	if b := d.next(8); b != nil {
+		return int64(binary.BigEndian.Uint64(b))
+	}
+	return 0
+}
+
+// string reads a string prefixed with its uint32 size.
+func (d *decoder) string() string {
+	size := d.uint32()
+	if b := d.next(int(size)); b != nil {
+		return string(b)
+	}
+	return ""
+}
+
+func (d *decoder) uuid() string {
+	if b := d.next(16); b != nil {
+		return uuid.UUID(b).String()
+	}
+	return ""
+}
+
+// encoder appends the body fields. The first error is kept and the following writes are skipped.
+type encoder struct {
+	buf []byte
+	err error
+}
+
+func (e *encoder) uint16(v uint16) {
+	e.buf = binary.BigEndian.AppendUint16(e.buf, v)
+}
+
+func (e *encoder) uint32(v uint32) {
+	e.buf = binary.BigEndian.AppendUint32(e.buf, v)
+}
+
+func (e *encoder) int64(v int64) {
+	e.buf = binary.BigEndian.AppendUint64(e.buf, uint64(v))
+}
+
+// string writes the string prefixed with its uint32 size.
+func (e *encoder) string(v string) {
+	e.uint32(uint32(len(v)))
+	e.buf = append(e.buf, v...)
+}
+
+func (e *encoder) uuid(v string) {
+	if e.err != nil {
+		return
+	}
+
+	id, err := uuid.Parse(v)
+	if err != nil {
+		e.err = fmt.Errorf("parse uuid %q: %w", v, err)
+		return
+	}
+
+	e.buf = append(e.buf, id[:]...)
+}
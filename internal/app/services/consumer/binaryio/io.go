@@ -1,8 +1,42 @@
 package binary
 
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/art-es/queue-service/internal/app/services/consumer/dto"
+)
+
+const (
+	ProtocolV1 = 1 // Fixed size fields, no handshake
+	ProtocolV2 = 2 // Length-prefixed frames with request IDs
+
+	maxProtocolVersion = ProtocolV2
+
+	// FeatureCredits limits the unacked tasks of a subscription: the v2 subscribe message
+	// carries the credits and the credits message adjusts them.
+	FeatureCredits uint32 = 1 << 0
+
+	// supportedFeatures is the bit set of optional features, the handshake carries them
+	// so they can be added without a new protocol version.
+	supportedFeatures = FeatureCredits
+)
+
+var handshakeMagic = [4]byte{'Q', 'S', 'C', 'P'}
+
+// handshake is both the client hello and the server reply.
+type handshake struct {
+	Magic    [4]byte
+	Version  uint8
+	Features uint32
+}
+
 type IO struct {
-	*reader
-	*writer
+	reader *reader
+	writer *writer
 }
 
 func New() *IO {
@@ -11,3 +45,73 @@ func New() *IO {
 		writer: newWriter(),
 	}
 }
+
+// Handshake negotiates the protocol version of the connection. A v2 client starts with a hello
+// carrying the highest version and the features it supports, the server replies with the agreed
+// version and features. A client starting with a message right away is a v1 client,
+// as no v1 message type matches the first byte of the hello.
+func (b *IO) Handshake(rw io.ReadWriter) (dto.Codec, error) {
+	r := bufio.NewReader(rw)
+
+	first, err := r.Peek(1)
+	if err != nil {
+		return nil, err
+	}
+
+	if first[0] != handshakeMagic[0] {
+		return b.newCodecV1(r, rw), nil
+	}
+
+	var hello handshake
+	if err = binary.Read(r, binary.BigEndian, &hello); err != nil {
+		return nil, fmt.Errorf("read hello: %w", err)
+	}
+
+	if hello.Magic != handshakeMagic {
+		return nil, errors.New("invalid hello magic")
+	}
+
+	if hello.Version < ProtocolV1 {
+		return nil, fmt.Errorf("unsupported protocol version %d", hello.Version)
+	}
+
+	reply := handshake{
+		Magic:    handshakeMagic,
+		Version:  min(hello.Version, maxProtocolVersion),
+		Features: hello.Features & supportedFeatures,
+	}
+
+	if err = binary.Write(rw, binary.BigEndian, reply); err != nil {
+		return nil, fmt.Errorf("write hello reply: %w", err)
+	}
+
+	if reply.Version == ProtocolV1 {
+		return b.newCodecV1(r, rw), nil
+	}
+
+	return newCodecV2(r, rw, reply.Features), nil
+}
+
+type codecV1 struct {
+	r      io.Reader
+	w      io.Writer
+	reader *reader
+	writer *writer
+}
+
+func (b *IO) newCodecV1(r io.Reader, w io.Writer) *codecV1 {
+	return &codecV1{
+		r:      r,
+		w:      w,
+		reader: b.reader,
+		writer: b.writer,
+	}
+}
+
+func (c *codecV1) Read() (*dto.Message, error) {
+	return c.reader.Read(c.r)
+}
+
+func (c *codecV1) Write(m *dto.Message) error {
+	return c.writer.Write(c.w, m)
+}
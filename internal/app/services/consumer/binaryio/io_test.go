@@ -0,0 +1,378 @@
+package binary
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/art-es/queue-service/internal/app/services/consumer/dto"
+)
+
+const (
+	testTaskID     = "0b7e5a52-3f0c-4f5e-9d6a-1c2b3d4e5f60"
+	testLeaseToken = "9a8b7c6d-5e4f-4a3b-8c2d-1e0f9a8b7c6d"
+)
+
+// conn is a connection with the client input already written and the server output collected.
+type conn struct {
+	in  *bytes.Buffer
+	out *bytes.Buffer
+}
+
+func (c *conn) Read(p []byte) (int, error)  { return c.in.Read(p) }
+func (c *conn) Write(p []byte) (int, error) { return c.out.Write(p) }
+
+func newConn(t *testing.T, in ...any) *conn {
+	c := &conn{in: &bytes.Buffer{}, out: &bytes.Buffer{}}
+	for _, v := range in {
+		if b, ok := v.([]byte); ok {
+			c.in.Write(b)
+			continue
+		}
+		require.NoError(t, binary.Write(c.in, binary.BigEndian, v))
+	}
+	return c
+}
+
+func hello(version uint8) handshake {
+	return handshake{Magic: handshakeMagic, Version: version, Features: FeatureCredits | 1<<31}
+}
+
+func frame(t *testing.T, msgType uint8, requestID uint64, fill func(e *encoder)) []byte {
+	e := &encoder{}
+	fill(e)
+	require.NoError(t, e.err)
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, writeFrame(buf, msgType, requestID, e.buf))
+	return buf.Bytes()
+}
+
+func TestIO_Handshake(t *testing.T) {
+	t.Run("negotiate v2", func(t *testing.T) {
+		c := newConn(t, hello(3))
+
+		codec, err := New().Handshake(c)
+
+		require.NoError(t, err)
+		assert.IsType(t, &codecV2{}, codec)
+
+		var reply handshake
+		require.NoError(t, binary.Read(c.out, binary.BigEndian, &reply))
+		assert.Equal(t, handshake{Magic: handshakeMagic, Version: ProtocolV2, Features: FeatureCredits}, reply)
+	})
+
+	t.Run("negotiate v1 with hello", func(t *testing.T) {
+		c := newConn(t, hello(1))
+
+		codec, err := New().Handshake(c)
+
+		require.NoError(t, err)
+		assert.IsType(t, &codecV1{}, codec)
+	})
+
+	t.Run("v1 client without hello", func(t *testing.T) {
+		queueName := [sizeShortText]byte{}
+		copy(queueName[:], "queue")
+		c := newConn(t, inputTypeQueueSubscribe, queueName)
+
+		codec, err := New().Handshake(c)
+		require.NoError(t, err)
+		assert.IsType(t, &codecV1{}, codec)
+		assert.Zero(t, c.out.Len(), "v1 clients get no reply")
+
+		msg, err := codec.Read()
+		require.NoError(t, err)
+		assert.Equal(t, &dto.Message{
+			Type: dto.InputTypeQueueSubscribe,
+			Data: dto.MessageDataQueueSubscribe{QueueName: "queue", Credits: dto.UnlimitedCredits},
+		}, msg)
+	})
+
+	t.Run("invalid magic", func(t *testing.T) {
+		c := newConn(t, handshake{Magic: [4]byte{'Q', 'X', 'X', 'X'}, Version: 2})
+
+		_, err := New().Handshake(c)
+
+		assert.EqualError(t, err, "invalid hello magic")
+	})
+}
+
+func TestCodecV2(t *testing.T) {
+	longPayload := strings.Repeat("x", 4096)
+	createdAt := time.Date(2026, 1, 2, 3, 4, 5, 123456789, time.UTC)
+
+	t.Run("read messages", func(t *testing.T) {
+		c := newConn(t,
+			hello(2),
+			frame(t, inputTypeQueueSubscribe, 1, func(e *encoder) {
+				e.string(strings.Repeat("q", 300))
+				e.uint32(10)
+			}),
+			frame(t, inputTypeTaskAck, 2, func(e *encoder) {
+				e.uuid(testTaskID)
+				e.uuid(testLeaseToken)
+			}),
+			frame(t, inputTypeTaskExtend, 3, func(e *encoder) {
+				e.uuid(testTaskID)
+				e.uuid(testLeaseToken)
+				e.int64(int64(90 * time.Second))
+			}),
+			frame(t, 200, 4, func(e *encoder) {
+				e.string("unsupported")
+			}),
+			frame(t, inputTypeQueuePop, 5, func(e *encoder) {
+				e.string("queue")
+				e.uint16(50)
+			}),
+			frame(t, inputTypeSubscriptionCredits, 6, func(e *encoder) {
+				e.uint32(3)
+				e.uint32(0)
+			}),
+			frame(t, inputTypeQueueUnsubscribe, 7, func(e *encoder) {
+				e.uint32(3)
+			}),
+		)
+
+		codec, err := New().Handshake(c)
+		require.NoError(t, err)
+
+		for _, exp := range []*dto.Message{
+			{
+				Type:      dto.InputTypeQueueSubscribe,
+				RequestID: 1,
+				Data:      dto.MessageDataQueueSubscribe{QueueName: strings.Repeat("q", 300), Credits: 10},
+			},
+			{
+				Type:      dto.InputTypeTaskAck,
+				RequestID: 2,
+				Data:      dto.MessageDataTaskLease{TaskID: testTaskID, LeaseToken: testLeaseToken},
+			},
+			{
+				Type:      dto.InputTypeTaskExtend,
+				RequestID: 3,
+				Data: dto.MessageDataTaskExtend{
+					TaskID:     testTaskID,
+					LeaseToken: testLeaseToken,
+					Duration:   90 * time.Second,
+				},
+			},
+			nil,
+			{
+				Type:      dto.InputTypeQueuePop,
+				RequestID: 5,
+				Data:      dto.MessageDataQueuePop{QueueName: "queue", Max: 50},
+			},
+			{
+				Type:      dto.InputTypeSubscriptionCredits,
+				RequestID: 6,
+				Data:      dto.MessageDataSubscriptionCredits{SubscriptionID: 3, Credits: 0},
+			},
+			{
+				Type:      dto.InputTypeQueueUnsubscribe,
+				RequestID: 7,
+				Data:      dto.MessageDataSubscriptionID(3),
+			},
+		} {
+			msg, err := codec.Read()
+			require.NoError(t, err)
+			assert.Equal(t, exp, msg)
+		}
+	})
+
+	t.Run("read subscribe without credits feature", func(t *testing.T) {
+		c := newConn(t,
+			handshake{Magic: handshakeMagic, Version: 2},
+			frame(t, inputTypeQueueSubscribe, 1, func(e *encoder) {
+				e.string("queue")
+			}),
+		)
+
+		codec, err := New().Handshake(c)
+		require.NoError(t, err)
+
+		msg, err := codec.Read()
+		require.NoError(t, err)
+		assert.Equal(t, &dto.Message{
+			Type:      dto.InputTypeQueueSubscribe,
+			RequestID: 1,
+			Data:      dto.MessageDataQueueSubscribe{QueueName: "queue", Credits: dto.UnlimitedCredits},
+		}, msg)
+	})
+
+	t.Run("read truncated message", func(t *testing.T) {
+		c := newConn(t,
+			hello(2),
+			frame(t, inputTypeTaskAck, 1, func(e *encoder) {
+				e.uuid(testTaskID)
+			}),
+		)
+
+		codec, err := New().Handshake(c)
+		require.NoError(t, err)
+
+		_, err = codec.Read()
+		assert.EqualError(t, err, "decode message: frame is too short")
+	})
+
+	t.Run("read too large frame", func(t *testing.T) {
+		c := newConn(t, hello(2), uint32(maxFrameSize+1))
+
+		codec, err := New().Handshake(c)
+		require.NoError(t, err)
+
+		_, err = codec.Read()
+		assert.EqualError(t, err, "invalid frame size 16777217")
+	})
+
+	t.Run("write tasks", func(t *testing.T) {
+		c := newConn(t)
+		codec := newCodecV2(c.in, c.out, FeatureCredits)
+
+		task := dto.MessageDataTask{
+			ID:         testTaskID,
+			Payload:    longPayload,
+			CreatedAt:  createdAt,
+			LeaseToken: testLeaseToken,
+		}
+
+		require.NoError(t, codec.Write(&dto.Message{
+			Type:      dto.OutputTypeQueuePopPass,
+			RequestID: 7,
+			Data:      dto.MessageDataTasks{task, task},
+		}))
+
+		msgType, requestID, body, err := readFrame(c.out)
+		require.NoError(t, err)
+		assert.Equal(t, uint8(dto.OutputTypeQueuePopPass), msgType)
+		assert.Equal(t, uint64(7), requestID)
+
+		d := &decoder{buf: body}
+		assert.Equal(t, uint16(2), d.uint16())
+		for range 2 {
+			assert.Equal(t, testTaskID, d.uuid())
+			assert.Equal(t, longPayload, d.string(), "payload is not truncated")
+			assert.Equal(t, createdAt.UnixNano(), d.int64())
+			assert.Equal(t, testLeaseToken, d.uuid())
+		}
+		assert.NoError(t, d.err)
+		assert.Empty(t, d.buf)
+	})
+
+	t.Run("write delivery", func(t *testing.T) {
+		c := newConn(t)
+		codec := newCodecV2(c.in, c.out, FeatureCredits)
+
+		require.NoError(t, codec.Write(&dto.Message{
+			Type: dto.OutputTypeTaskProcess,
+			Data: dto.MessageDataTask{
+				SubscriptionID: 3,
+				ID:             testTaskID,
+				QueueName:      "queue",
+				Payload:        "payload",
+				CreatedAt:      createdAt,
+				LeaseToken:     testLeaseToken,
+			},
+		}))
+
+		msgType, requestID, body, err := readFrame(c.out)
+		require.NoError(t, err)
+		assert.Equal(t, uint8(dto.OutputTypeTaskProcess), msgType)
+		assert.Zero(t, requestID)
+
+		d := &decoder{buf: body}
+		assert.Equal(t, uint32(3), d.uint32())
+		assert.Equal(t, "queue", d.string())
+		assert.Equal(t, testTaskID, d.uuid())
+		assert.Equal(t, "payload", d.string())
+		assert.Equal(t, createdAt.UnixNano(), d.int64())
+		assert.Equal(t, testLeaseToken, d.uuid())
+		assert.NoError(t, d.err)
+		assert.Empty(t, d.buf)
+	})
+
+	t.Run("write message without data", func(t *testing.T) {
+		c := newConn(t)
+		codec := newCodecV2(c.in, c.out, FeatureCredits)
+
+		require.NoError(t, codec.Write(&dto.Message{Type: dto.OutputTypeTaskAckPass, RequestID: 8}))
+
+		assert.Equal(t, []byte{0, 0, 0, 9, uint8(dto.OutputTypeTaskAckPass), 0, 0, 0, 0, 0, 0, 0, 8}, c.out.Bytes())
+	})
+
+	t.Run("write error", func(t *testing.T) {
+		c := newConn(t)
+		codec := newCodecV2(c.in, c.out, FeatureCredits)
+
+		require.NoError(t, codec.Write(&dto.Message{
+			Type:      dto.OutputTypeTaskAckFail,
+			RequestID: 9,
+			Data:      dto.MessageDataError{Code: dto.ErrorCodeLeaseLost, Message: "lease lost"},
+		}))
+
+		msgType, requestID, body, err := readFrame(c.out)
+		require.NoError(t, err)
+		assert.Equal(t, uint8(dto.OutputTypeTaskAckFail), msgType)
+		assert.Equal(t, uint64(9), requestID)
+
+		d := &decoder{buf: body}
+		assert.Equal(t, uint16(dto.ErrorCodeLeaseLost), d.uint16())
+		assert.Equal(t, "lease lost", d.string())
+		assert.NoError(t, d.err)
+	})
+
+	t.Run("write invalid task id", func(t *testing.T) {
+		c := newConn(t)
+		codec := newCodecV2(c.in, c.out, FeatureCredits)
+
+		err := codec.Write(&dto.Message{
+			Type: dto.OutputTypeTaskProcess,
+			Data: dto.MessageDataTask{ID: "invalid", LeaseToken: testLeaseToken},
+		})
+
+		assert.ErrorContains(t, err, `encode message: parse uuid "invalid"`)
+		assert.Zero(t, c.out.Len())
+	})
+}
+
+func TestCodecV1(t *testing.T) {
+	t.Run("ack delivered task", func(t *testing.T) {
+		out := newConn(t)
+		require.NoError(t, New().newCodecV1(out.in, out.out).Write(&dto.Message{
+			Type: dto.OutputTypeTaskProcess,
+			Data: dto.MessageDataTask{ID: testTaskID, LeaseToken: testLeaseToken},
+		}))
+
+		var msgType uint8
+		var task messageDataTask
+		require.NoError(t, binary.Read(out.out, binary.BigEndian, &msgType))
+		require.NoError(t, binary.Read(out.out, binary.BigEndian, &task))
+
+		// The client sends the delivered ID and lease token back as is
+		in := newConn(t, inputTypeTaskAck, messageDataTaskLease{TaskID: task.ID, LeaseToken: task.LeaseToken})
+		msg, err := New().newCodecV1(in.in, in.out).Read()
+
+		require.NoError(t, err)
+		assert.Equal(t, &dto.Message{
+			Type: dto.InputTypeTaskAck,
+			Data: dto.MessageDataTaskLease{TaskID: testTaskID, LeaseToken: testLeaseToken},
+		}, msg)
+	})
+
+	t.Run("write invalid lease token", func(t *testing.T) {
+		c := newConn(t)
+
+		err := New().newCodecV1(c.in, c.out).Write(&dto.Message{
+			Type: dto.OutputTypeTaskProcess,
+			Data: dto.MessageDataTask{ID: testTaskID, LeaseToken: "invalid"},
+		})
+
+		assert.ErrorContains(t, err, `encode message: parse uuid "invalid"`)
+		assert.Zero(t, c.out.Len())
+	})
+}
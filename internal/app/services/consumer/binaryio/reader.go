@@ -3,14 +3,19 @@ package binary
 import (
 	"encoding/binary"
 	"io"
+	"time"
 
 	"github.com/art-es/queue-service/internal/app/services/consumer/dto"
 )
 
 const (
-	inputTypeQueueSubscribe = uint8(dto.InputTypeQueueSubscribe)
-	inputTypeTaskAck        = uint8(dto.InputTypeTaskAck)
-	inputTypeTaskNack       = uint8(dto.InputTypeTaskNack)
+	inputTypeQueueSubscribe      = uint8(dto.InputTypeQueueSubscribe)
+	inputTypeTaskAck             = uint8(dto.InputTypeTaskAck)
+	inputTypeTaskNack            = uint8(dto.InputTypeTaskNack)
+	inputTypeTaskExtend          = uint8(dto.InputTypeTaskExtend)
+	inputTypeQueuePop            = uint8(dto.InputTypeQueuePop)
+	inputTypeSubscriptionCredits = uint8(dto.InputTypeSubscriptionCredits)
+	inputTypeQueueUnsubscribe    = uint8(dto.InputTypeQueueUnsubscribe)
 )
 
 type reader struct{}
@@ -30,9 +35,13 @@ func (*reader) Read(r io.Reader) (*dto.Message, error) {
 
 	switch msgType {
 	case inputTypeQueueSubscribe:
-		msgData, err = readQueueName(r)
+		msgData, err = readQueueSubscribe(r)
 	case inputTypeTaskAck, inputTypeTaskNack:
-		msgData, err = readTaskID(r)
+		msgData, err = readTaskLease(r)
+	case inputTypeTaskExtend:
+		msgData, err = readTaskExtend(r)
+	case inputTypeQueuePop:
+		msgData, err = readQueuePop(r)
 	default:
 		// unsupported type
 		return nil, nil
@@ -48,22 +57,56 @@ func (*reader) Read(r io.Reader) (*dto.Message, error) {
 	}, nil
 }
 
-func readQueueName(r io.Reader) (dto.MessageDataQueueName, error) {
+// readQueueSubscribe reads a subscription without a credit limit, as v1 has no credits.
+func readQueueSubscribe(r io.Reader) (dto.MessageDataQueueSubscribe, error) {
 	var val [256]byte
 	if err := binary.Read(r, binary.BigEndian, &val); err != nil {
-		return "", err
+		return dto.MessageDataQueueSubscribe{}, err
 	}
 
-	out := dto.MessageDataQueueName(convertBinaryQueueName(val))
+	out := dto.MessageDataQueueSubscribe{
+		QueueName: convertBinaryQueueName(val),
+		Credits:   dto.UnlimitedCredits,
+	}
+	return out, nil
+}
+
+func readTaskLease(r io.Reader) (dto.MessageDataTaskLease, error) {
+	var val messageDataTaskLease
+	if err := binary.Read(r, binary.BigEndian, &val); err != nil {
+		return dto.MessageDataTaskLease{}, err
+	}
+
+	out := dto.MessageDataTaskLease{
+		TaskID:     convertBinaryTaskID(val.TaskID),
+		LeaseToken: convertBinaryLeaseToken(val.LeaseToken),
+	}
 	return out, nil
 }
 
-func readTaskID(r io.Reader) (dto.MessageDataTaskID, error) {
-	var val [16]byte
+func readTaskExtend(r io.Reader) (dto.MessageDataTaskExtend, error) {
+	var val messageDataTaskExtend
 	if err := binary.Read(r, binary.BigEndian, &val); err != nil {
-		return "", err
+		return dto.MessageDataTaskExtend{}, err
 	}
 
-	out := dto.MessageDataTaskID(convertBinaryTaskID(val))
+	out := dto.MessageDataTaskExtend{
+		TaskID:     convertBinaryTaskID(val.TaskID),
+		LeaseToken: convertBinaryLeaseToken(val.LeaseToken),
+		Duration:   time.Duration(val.DurationSeconds) * time.Second,
+	}
+	return out, nil
+}
+
+func readQueuePop(r io.Reader) (dto.MessageDataQueuePop, error) {
+	var val messageDataQueuePop
+	if err := binary.Read(r, binary.BigEndian, &val); err != nil {
+		return dto.MessageDataQueuePop{}, err
+	}
+
+	out := dto.MessageDataQueuePop{
+		QueueName: convertBinaryQueueName(val.QueueName),
+		Max:       int(val.Max),
+	}
 	return out, nil
 }
@@ -12,20 +12,62 @@ const (
 )
 
 var (
-	convertBinaryQueueName = convertShortBytesToString
-	convertBinaryTaskID    = convertUUIDBytesToString
+	convertBinaryQueueName  = convertShortBytesToString
+	convertBinaryTaskID     = convertUUIDBytesToString
+	convertBinaryLeaseToken = convertUUIDBytesToString
 )
 
+type messageDataTaskLease struct {
+	TaskID     [sizeUUID]byte
+	LeaseToken [sizeUUID]byte
+}
+
+type messageDataTaskExtend struct {
+	TaskID          [sizeUUID]byte
+	LeaseToken      [sizeUUID]byte
+	DurationSeconds uint32
+}
+
+type messageDataQueuePop struct {
+	QueueName [sizeShortText]byte
+	Max       uint8
+}
+
 type messageDataTask struct {
-	ID        [sizeUUID]byte
-	Payload   [sizeLongText]byte
-	CreatedAt [sizeDateTime]byte
+	ID         [sizeUUID]byte
+	Payload    [sizeLongText]byte
+	CreatedAt  [sizeDateTime]byte
+	LeaseToken [sizeUUID]byte
 }
 
-func convertToBinaryTask(task dto.MessageDataTask) messageDataTask {
+func convertToBinaryTask(task dto.MessageDataTask) (messageDataTask, error) {
+	id, err := convertStringToUUIDBytes(task.ID)
+	if err != nil {
+		return messageDataTask{}, err
+	}
+
+	leaseToken, err := convertStringToUUIDBytes(task.LeaseToken)
+	if err != nil {
+		return messageDataTask{}, err
+	}
+
 	return messageDataTask{
-		ID:        convertStringToUUIDBytes(task.ID),
-		Payload:   convertStringToLongBytes(task.Payload),
-		CreatedAt: convertTimeToDateTimeBytes(task.CreatedAt),
+		ID:         id,
+		Payload:    convertStringToLongBytes(task.Payload),
+		CreatedAt:  convertTimeToDateTimeBytes(task.CreatedAt),
+		LeaseToken: leaseToken,
+	}, nil
+}
+
+// convertToBinaryTasks returns the tasks count followed by the tasks.
+func convertToBinaryTasks(tasks dto.MessageDataTasks) ([]any, error) {
+	out := make([]messageDataTask, 0, len(tasks))
+	for _, task := range tasks {
+		binaryTask, err := convertToBinaryTask(task)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, binaryTask)
 	}
+	return []any{uint8(len(out)), out}, nil
 }
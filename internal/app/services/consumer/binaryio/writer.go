@@ -16,20 +16,30 @@ func newWriter() *writer {
 
 func (*writer) Write(w io.Writer, msg *dto.Message) error {
 	var (
-		msgType     = uint8(msg.Type)
-		msgData any = nil
+		msgType       = uint8(msg.Type)
+		msgData []any = nil
 	)
 
 	switch msg.Data.(type) {
 	case dto.MessageDataTask:
-		msgData = convertToBinaryTask(msg.Data.(dto.MessageDataTask))
+		task, err := convertToBinaryTask(msg.Data.(dto.MessageDataTask))
+		if err != nil {
+			return fmt.Errorf("encode message: %w", err)
+		}
+		msgData = []any{task}
+	case dto.MessageDataTasks:
+		tasks, err := convertToBinaryTasks(msg.Data.(dto.MessageDataTasks))
+		if err != nil {
+			return fmt.Errorf("encode message: %w", err)
+		}
+		msgData = tasks
 	}
 
 	if err := binary.Write(w, binary.BigEndian, msgType); err != nil {
 		return fmt.Errorf("write message type: %w", err)
 	}
-	if msgData != nil {
-		if err := binary.Write(w, binary.BigEndian, msgData); err != nil {
+	for _, data := range msgData {
+		if err := binary.Write(w, binary.BigEndian, data); err != nil {
 			return fmt.Errorf("write message data: %w", err)
 		}
 	}
@@ -2,10 +2,20 @@ package dto
 
 import "time"
 
+// Codec reads and writes the messages of one connection in the negotiated protocol version.
+type Codec interface {
+	Read() (*Message, error)
+	Write(m *Message) error
+}
+
 const (
 	InputTypeQueueSubscribe MessageType = iota + 1
 	InputTypeTaskAck
 	InputTypeTaskNack
+	InputTypeTaskExtend
+	InputTypeQueuePop
+	InputTypeSubscriptionCredits
+	InputTypeQueueUnsubscribe
 )
 
 const (
@@ -16,21 +26,72 @@ const (
 	OutputTypeTaskNackPass
 	OutputTypeTaskNackFail
 	OutputTypeTaskProcess
+	OutputTypeTaskExtendPass
+	OutputTypeTaskExtendFail
+	OutputTypeQueuePopPass
+	OutputTypeQueuePopFail
+	OutputTypeServerDraining // No more tasks are delivered, the consumer should finish its tasks and disconnect
+	OutputTypeSubscriptionCreditsPass
+	OutputTypeSubscriptionCreditsFail
+	OutputTypeQueueUnsubscribePass
+	OutputTypeQueueUnsubscribeFail
 )
 
+// UnlimitedCredits lets a subscription deliver tasks regardless of how many are unacked.
+const UnlimitedCredits = -1
+
 type Message struct {
-	Type MessageType
-	Data any
+	Type      MessageType
+	RequestID uint64 // Chosen by the client and echoed in the reply, zero in the v1 protocol
+	Data      any
 }
 
 type MessageType uint8
 
+// ErrorCode tells the client why a request has failed.
+type ErrorCode uint16
+
+const (
+	ErrorCodeInternal       ErrorCode = iota + 1
+	ErrorCodeInvalidRequest           // The request is malformed or out of the limits
+	ErrorCodeLeaseLost                // The task is no longer processing with the lease token
+	ErrorCodeShuttingDown             // The server is draining and delivers no more tasks
+)
+
 type (
-	MessageDataQueueName string
-	MessageDataTaskID    string
-	MessageDataTask      struct {
-		ID        string
-		Payload   string
-		CreatedAt time.Time
+	MessageDataQueueSubscribe struct {
+		QueueName string
+		Credits   int // Max unacked tasks delivered by the subscription
+	}
+	MessageDataSubscriptionID      uint32
+	MessageDataSubscriptionCredits struct {
+		SubscriptionID uint32
+		Credits        int
+	}
+	MessageDataQueuePop struct {
+		QueueName string
+		Max       int
+	}
+	MessageDataTaskLease struct {
+		TaskID     string
+		LeaseToken string
+	}
+	MessageDataTaskExtend struct {
+		TaskID     string
+		LeaseToken string
+		Duration   time.Duration
+	}
+	MessageDataTask struct {
+		SubscriptionID uint32 // Zero if the task is popped
+		ID             string
+		QueueName      string
+		Payload        string
+		CreatedAt      time.Time
+		LeaseToken     string
+	}
+	MessageDataTasks []MessageDataTask
+	MessageDataError struct {
+		Code    ErrorCode
+		Message string
 	}
 )
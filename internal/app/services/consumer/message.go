@@ -2,62 +2,181 @@ package consumer
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strconv"
 
 	"github.com/art-es/queue-service/internal/app/domain"
 	"github.com/art-es/queue-service/internal/app/services/consumer/dto"
+	"github.com/art-es/queue-service/internal/app/services/task"
 	"github.com/art-es/queue-service/internal/infra/log"
 )
 
+const (
+	maxPopTasks = 100
+	maxCredits  = 1000
+)
+
+var (
+	errInvalidQueuePop = fmt.Errorf("queue name must not be empty and max must be from 1 to %d", maxPopTasks)
+	errInvalidCredits  = fmt.Errorf("credits must be from 0 to %d", maxCredits)
+	errNotSubscribed   = errors.New("subscription not found")
+	errShuttingDown    = errors.New("server is shutting down")
+)
+
 type messageHandler struct {
+	drainCtx     context.Context
 	queueService queueService
 	taskService  taskService
-	listenTasks  func(ctx context.Context, tasks <-chan *domain.Task, out chan<- *dto.Message)
+	listenTasks  func(ctx context.Context, sess *session, sub *subscription, tasks <-chan *domain.Task)
 	closeConn    func()
 	logger       log.Logger
 }
 
 func newMessageHandler(
+	drainCtx context.Context,
 	queueService queueService,
 	taskService taskService,
 	logger log.Logger,
 ) *messageHandler {
-	return &messageHandler{
+	h := &messageHandler{
+		drainCtx:     drainCtx,
 		queueService: queueService,
 		taskService:  taskService,
-		listenTasks:  listenTasks,
 		logger:       logger,
 	}
+	h.listenTasks = h.forwardTasks
+	return h
+}
+
+// deliveryContext returns a context of the connection that is also done once draining starts,
+// so pops and subscriptions stop taking tasks while acks still work.
+func (h *messageHandler) deliveryContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	stop := context.AfterFunc(h.drainCtx, cancel)
+
+	return ctx, func() {
+		stop()
+		cancel()
+	}
+}
+
+// send sends the message unless the context is done, then the tasks of the message are released
+// and false is returned.
+func (h *messageHandler) send(ctx context.Context, out chan<- *dto.Message, msg *dto.Message) bool {
+	// Checked first, as the select picks at random if the consumer is ready as well
+	if ctx.Err() != nil {
+		h.releaseUndelivered(ctx, msg)
+		return false
+	}
+
+	select {
+	case <-ctx.Done():
+		h.releaseUndelivered(ctx, msg)
+		return false
+	case out <- msg:
+		return true
+	}
+}
+
+// reply sends the reply to the request with its request ID, like send.
+func (h *messageHandler) reply(
+	ctx context.Context,
+	sess *session,
+	in *dto.Message,
+	msgType dto.MessageType,
+	data any,
+) bool {
+	return h.send(ctx, sess.out, &dto.Message{
+		Type:      msgType,
+		RequestID: in.RequestID,
+		Data:      data,
+	})
+}
+
+// replyError sends the fail reply to the request with the code and the message of the error.
+// Unexpected errors are replied as internal ones without details, they are logged by the handlers.
+func (h *messageHandler) replyError(
+	ctx context.Context,
+	sess *session,
+	in *dto.Message,
+	msgType dto.MessageType,
+	err error,
+) {
+	data := dto.MessageDataError{Code: dto.ErrorCodeInternal, Message: "internal error"}
+
+	switch {
+	case errors.Is(err, errInvalidQueuePop),
+		errors.Is(err, errInvalidCredits),
+		errors.Is(err, errNotSubscribed),
+		errors.Is(err, task.ErrInvalidDuration):
+		data = dto.MessageDataError{Code: dto.ErrorCodeInvalidRequest, Message: err.Error()}
+	case errors.Is(err, domain.ErrLeaseLost):
+		data = dto.MessageDataError{Code: dto.ErrorCodeLeaseLost, Message: err.Error()}
+	case errors.Is(err, errShuttingDown):
+		data = dto.MessageDataError{Code: dto.ErrorCodeShuttingDown, Message: err.Error()}
+	}
+
+	h.reply(ctx, sess, in, msgType, data)
+}
+
+// deliveryError returns errShuttingDown instead of the error if draining has started,
+// as deliveries fail then because their context is done.
+func (h *messageHandler) deliveryError(err error) error {
+	if h.drainCtx.Err() != nil {
+		return errShuttingDown
+	}
+	return err
 }
 
-func (h *messageHandler) handle(ctx context.Context, in *dto.Message, out chan<- *dto.Message) {
+func (h *messageHandler) handle(ctx context.Context, sess *session, in *dto.Message) {
 	switch in.Type {
 	case dto.InputTypeQueueSubscribe:
-		h.handleQueueSubscribe(ctx, in, out)
+		h.handleQueueSubscribe(ctx, sess, in)
 	case dto.InputTypeTaskAck:
-		h.handleTaskAck(ctx, in, out)
+		h.handleTaskAck(ctx, sess, in)
 	case dto.InputTypeTaskNack:
-		h.handleTaskNack(ctx, in, out)
+		h.handleTaskNack(ctx, sess, in)
+	case dto.InputTypeTaskExtend:
+		h.handleTaskExtend(ctx, sess, in)
+	case dto.InputTypeQueuePop:
+		h.handleQueuePop(ctx, sess, in)
+	case dto.InputTypeSubscriptionCredits:
+		h.handleSubscriptionCredits(ctx, sess, in)
+	case dto.InputTypeQueueUnsubscribe:
+		h.handleQueueUnsubscribe(ctx, sess, in)
 	}
 }
 
-func (h *messageHandler) handleQueueSubscribe(ctx context.Context, in *dto.Message, out chan<- *dto.Message) {
-	queueName, ok := in.Data.(dto.MessageDataQueueName)
+func (h *messageHandler) handleQueueSubscribe(ctx context.Context, sess *session, in *dto.Message) {
+	data, ok := in.Data.(dto.MessageDataQueueSubscribe)
 	if !ok {
 		return
 	}
 
-	logger := h.logger.With("queue_name", string(queueName))
+	if !validCredits(data.Credits) {
+		h.replyError(ctx, sess, in, dto.OutputTypeQueueSubscribeFail, errInvalidCredits)
+		return
+	}
 
-	tasks, err := h.queueService.Subscribe(ctx, string(queueName))
+	subCtx, cancel := h.deliveryContext(ctx)
+	sub := sess.addSubscription(data.QueueName, data.Credits, cancel)
+
+	logger := h.logger.
+		With("queue_name", data.QueueName).
+		With("subscription_id", strconv.FormatUint(uint64(sub.id), 10))
+
+	tasks, err := h.queueService.Subscribe(subCtx, data.QueueName, sub.acquire)
 	if err != nil {
+		cancel()
+		sess.removeSubscription(sub.id)
+
 		logger.Log(log.LevelError).
 			With("message", "queue subscribe error").
 			With("error", err.Error()).
 			Write()
 
-		out <- &dto.Message{
-			Type: dto.OutputTypeQueueSubscribePass,
-		}
+		h.replyError(ctx, sess, in, dto.OutputTypeQueueSubscribeFail, h.deliveryError(err))
 		return
 	}
 
@@ -65,57 +184,201 @@ func (h *messageHandler) handleQueueSubscribe(ctx context.Context, in *dto.Messa
 		With("message", "subscribed to queue chan").
 		Write()
 
-	out <- &dto.Message{
-		Type: dto.OutputTypeQueueSubscribeFail,
+	// Sent before the tasks, so the consumer knows the subscription ID they are tagged with
+	h.reply(ctx, sess, in, dto.OutputTypeQueueSubscribePass, dto.MessageDataSubscriptionID(sub.id))
+
+	sess.wg.Add(1)
+	go func() {
+		defer sess.wg.Done()
+		defer close(sub.done)
+		defer sess.removeSubscription(sub.id)
+		defer cancel()
+
+		h.listenTasks(subCtx, sess, sub, tasks)
+	}()
+}
+
+// handleQueueUnsubscribe replies once the subscription has stopped delivering tasks
+// and its undelivered tasks are released. Delivered tasks are still to be acked or nacked.
+func (h *messageHandler) handleQueueUnsubscribe(ctx context.Context, sess *session, in *dto.Message) {
+	id, ok := in.Data.(dto.MessageDataSubscriptionID)
+	if !ok {
+		return
+	}
+
+	sub, ok := sess.removeSubscription(uint32(id))
+	if !ok {
+		h.replyError(ctx, sess, in, dto.OutputTypeQueueUnsubscribeFail, errNotSubscribed)
+		return
+	}
+
+	sub.cancel()
+
+	select {
+	case <-ctx.Done():
+		return
+	case <-sub.done:
 	}
 
-	go h.listenTasks(ctx, tasks, out)
+	h.reply(ctx, sess, in, dto.OutputTypeQueueUnsubscribePass, nil)
 }
 
-func (h *messageHandler) handleTaskAck(ctx context.Context, in *dto.Message, out chan<- *dto.Message) {
-	taskID, ok := in.Data.(dto.MessageDataTaskID)
+func (h *messageHandler) handleSubscriptionCredits(ctx context.Context, sess *session, in *dto.Message) {
+	data, ok := in.Data.(dto.MessageDataSubscriptionCredits)
+	if !ok {
+		return
+	}
+
+	if !validCredits(data.Credits) || data.Credits == dto.UnlimitedCredits {
+		h.replyError(ctx, sess, in, dto.OutputTypeSubscriptionCreditsFail, errInvalidCredits)
+		return
+	}
+
+	sub, ok := sess.getSubscription(data.SubscriptionID)
 	if !ok {
+		h.replyError(ctx, sess, in, dto.OutputTypeSubscriptionCreditsFail, errNotSubscribed)
 		return
 	}
 
-	if err := h.taskService.Ack(ctx, string(taskID)); err != nil {
+	sub.setCredits(data.Credits)
+
+	h.reply(ctx, sess, in, dto.OutputTypeSubscriptionCreditsPass, nil)
+}
+
+func validCredits(credits int) bool {
+	return credits == dto.UnlimitedCredits || credits >= 0 && credits <= maxCredits
+}
+
+func (h *messageHandler) handleQueuePop(ctx context.Context, sess *session, in *dto.Message) {
+	pop, ok := in.Data.(dto.MessageDataQueuePop)
+	if !ok {
+		return
+	}
+
+	if pop.QueueName == "" || pop.Max < 1 || pop.Max > maxPopTasks {
+		h.replyError(ctx, sess, in, dto.OutputTypeQueuePopFail, errInvalidQueuePop)
+		return
+	}
+
+	popCtx, cancel := h.deliveryContext(ctx)
+	defer cancel()
+
+	tasks, err := h.queueService.Pop(popCtx, pop.QueueName, pop.Max)
+	if err != nil {
 		h.logger.Log(log.LevelError).
-			With("message", "task ack error").
-			With("task_id", string(taskID)).
+			With("message", "queue pop error").
+			With("queue_name", pop.QueueName).
 			With("error", err.Error()).
 			Write()
 
-		out <- &dto.Message{
-			Type: dto.OutputTypeTaskAckFail,
+		h.replyError(ctx, sess, in, dto.OutputTypeQueuePopFail, h.deliveryError(err))
+		return
+	}
+
+	data := make(dto.MessageDataTasks, 0, len(tasks))
+	for _, task := range tasks {
+		data = append(data, toMessageDataTask(task))
+		sess.deliver(nil, task)
+	}
+
+	if !h.reply(ctx, sess, in, dto.OutputTypeQueuePopPass, data) {
+		for _, task := range tasks {
+			sess.settle(task.LeaseToken)
 		}
+	}
+}
+
+func (h *messageHandler) handleTaskAck(ctx context.Context, sess *session, in *dto.Message) {
+	lease, ok := in.Data.(dto.MessageDataTaskLease)
+	if !ok {
 		return
 	}
 
-	out <- &dto.Message{
-		Type: dto.OutputTypeTaskAckPass,
+	req := &task.AckRequest{
+		TaskID:     lease.TaskID,
+		LeaseToken: lease.LeaseToken,
 	}
+
+	err := h.taskService.Ack(ctx, req)
+	if err == nil || errors.Is(err, domain.ErrLeaseLost) {
+		sess.settle(lease.LeaseToken)
+	}
+
+	if err != nil {
+		h.logger.Log(log.LevelError).
+			With("message", "task ack error").
+			With("task_id", lease.TaskID).
+			With("error", err.Error()).
+			Write()
+
+		h.replyError(ctx, sess, in, dto.OutputTypeTaskAckFail, err)
+		return
+	}
+
+	h.reply(ctx, sess, in, dto.OutputTypeTaskAckPass, nil)
 }
 
-func (h *messageHandler) handleTaskNack(ctx context.Context, in *dto.Message, out chan<- *dto.Message) {
-	taskID, ok := in.Data.(dto.MessageDataTaskID)
+func (h *messageHandler) handleTaskNack(ctx context.Context, sess *session, in *dto.Message) {
+	lease, ok := in.Data.(dto.MessageDataTaskLease)
 	if !ok {
 		return
 	}
 
-	if err := h.taskService.Nack(ctx, string(taskID)); err != nil {
+	req := &task.NackRequest{
+		TaskID:     lease.TaskID,
+		LeaseToken: lease.LeaseToken,
+	}
+
+	err := h.taskService.Nack(ctx, req)
+	if err == nil || errors.Is(err, domain.ErrLeaseLost) {
+		sess.settle(lease.LeaseToken)
+	}
+
+	if err != nil {
 		h.logger.Log(log.LevelError).
 			With("message", "task nack error").
-			With("task_id", string(taskID)).
+			With("task_id", lease.TaskID).
 			With("error", err.Error()).
 			Write()
 
-		out <- &dto.Message{
-			Type: dto.OutputTypeTaskNackFail,
-		}
+		h.replyError(ctx, sess, in, dto.OutputTypeTaskNackFail, err)
 		return
 	}
 
-	out <- &dto.Message{
-		Type: dto.OutputTypeTaskNackPass,
+	h.reply(ctx, sess, in, dto.OutputTypeTaskNackPass, nil)
+}
+
+func (h *messageHandler) handleTaskExtend(ctx context.Context, sess *session, in *dto.Message) {
+	extend, ok := in.Data.(dto.MessageDataTaskExtend)
+	if !ok {
+		return
 	}
+
+	if extend.Duration <= 0 {
+		h.replyError(ctx, sess, in, dto.OutputTypeTaskExtendFail, task.ErrInvalidDuration)
+		return
+	}
+
+	req := &task.ExtendRequest{
+		TaskID:     extend.TaskID,
+		LeaseToken: extend.LeaseToken,
+		Duration:   extend.Duration,
+	}
+
+	if _, err := h.taskService.Extend(ctx, req); err != nil {
+		if errors.Is(err, domain.ErrLeaseLost) {
+			sess.settle(extend.LeaseToken)
+		}
+
+		h.logger.Log(log.LevelError).
+			With("message", "task extend error").
+			With("task_id", extend.TaskID).
+			With("error", err.Error()).
+			Write()
+
+		h.replyError(ctx, sess, in, dto.OutputTypeTaskExtendFail, err)
+		return
+	}
+
+	h.reply(ctx, sess, in, dto.OutputTypeTaskExtendPass, nil)
 }
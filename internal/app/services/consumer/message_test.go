@@ -0,0 +1,346 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/art-es/queue-service/internal/app/domain"
+	"github.com/art-es/queue-service/internal/app/services/consumer/dto"
+	"github.com/art-es/queue-service/internal/app/services/task"
+	"github.com/art-es/queue-service/internal/infra/log/logimpl"
+)
+
+func TestMessageHandler_Handle(t *testing.T) {
+	var (
+		ctx        = context.Background()
+		requestID  = uint64(42)
+		queueName  = "testQueueName"
+		taskID     = "testTaskID"
+		leaseToken = "testLeaseToken"
+		lease      = dto.MessageDataTaskLease{TaskID: taskID, LeaseToken: leaseToken}
+		subscribe  = dto.MessageDataQueueSubscribe{QueueName: queueName, Credits: 10}
+	)
+
+	type testDeps struct {
+		mockQueueService *MockqueueService
+		mockTaskService  *MocktaskService
+		drain            context.CancelFunc
+		sess             *session
+	}
+
+	for _, tc := range []struct {
+		name   string
+		in     *dto.Message
+		run    func(d testDeps)
+		expOut *dto.Message
+	}{
+		{
+			name: "subscribe",
+			in:   &dto.Message{Type: dto.InputTypeQueueSubscribe, RequestID: requestID, Data: subscribe},
+			run: func(d testDeps) {
+				d.mockQueueService.EXPECT().
+					Subscribe(gomock.Any(), gomock.Eq(queueName), gomock.Any()).
+					Return(make(chan *domain.Task), nil)
+			},
+			expOut: &dto.Message{
+				Type:      dto.OutputTypeQueueSubscribePass,
+				RequestID: requestID,
+				Data:      dto.MessageDataSubscriptionID(1),
+			},
+		},
+		{
+			name: "subscribe error",
+			in:   &dto.Message{Type: dto.InputTypeQueueSubscribe, RequestID: requestID, Data: subscribe},
+			run: func(d testDeps) {
+				d.mockQueueService.EXPECT().
+					Subscribe(gomock.Any(), gomock.Eq(queueName), gomock.Any()).
+					Return(nil, errors.New("test error"))
+			},
+			expOut: &dto.Message{
+				Type:      dto.OutputTypeQueueSubscribeFail,
+				RequestID: requestID,
+				Data:      dto.MessageDataError{Code: dto.ErrorCodeInternal, Message: "internal error"},
+			},
+		},
+		{
+			name: "subscribe when draining",
+			in:   &dto.Message{Type: dto.InputTypeQueueSubscribe, RequestID: requestID, Data: subscribe},
+			run: func(d testDeps) {
+				d.drain()
+
+				d.mockQueueService.EXPECT().
+					Subscribe(gomock.Any(), gomock.Eq(queueName), gomock.Any()).
+					Return(nil, context.Canceled)
+			},
+			expOut: &dto.Message{
+				Type:      dto.OutputTypeQueueSubscribeFail,
+				RequestID: requestID,
+				Data:      dto.MessageDataError{Code: dto.ErrorCodeShuttingDown, Message: "server is shutting down"},
+			},
+		},
+		{
+			name: "subscribe invalid credits",
+			in: &dto.Message{
+				Type:      dto.InputTypeQueueSubscribe,
+				RequestID: requestID,
+				Data:      dto.MessageDataQueueSubscribe{QueueName: queueName, Credits: maxCredits + 1},
+			},
+			run: func(d testDeps) {},
+			expOut: &dto.Message{
+				Type:      dto.OutputTypeQueueSubscribeFail,
+				RequestID: requestID,
+				Data: dto.MessageDataError{
+					Code:    dto.ErrorCodeInvalidRequest,
+					Message: fmt.Sprintf("credits must be from 0 to %d", maxCredits),
+				},
+			},
+		},
+		{
+			name: "subscribe twice",
+			in:   &dto.Message{Type: dto.InputTypeQueueSubscribe, RequestID: requestID, Data: subscribe},
+			run: func(d testDeps) {
+				d.sess.addSubscription(queueName, 1, func() {})
+
+				d.mockQueueService.EXPECT().
+					Subscribe(gomock.Any(), gomock.Eq(queueName), gomock.Any()).
+					Return(make(chan *domain.Task), nil)
+			},
+			expOut: &dto.Message{
+				Type:      dto.OutputTypeQueueSubscribePass,
+				RequestID: requestID,
+				Data:      dto.MessageDataSubscriptionID(2),
+			},
+		},
+		{
+			name: "unsubscribe",
+			in: &dto.Message{
+				Type:      dto.InputTypeQueueUnsubscribe,
+				RequestID: requestID,
+				Data:      dto.MessageDataSubscriptionID(1),
+			},
+			run: func(d testDeps) {
+				var sub *subscription
+				sub = d.sess.addSubscription(queueName, 1, func() { close(sub.done) })
+			},
+			expOut: &dto.Message{Type: dto.OutputTypeQueueUnsubscribePass, RequestID: requestID},
+		},
+		{
+			name: "unsubscribe unknown subscription",
+			in: &dto.Message{
+				Type:      dto.InputTypeQueueUnsubscribe,
+				RequestID: requestID,
+				Data:      dto.MessageDataSubscriptionID(1),
+			},
+			run: func(d testDeps) {},
+			expOut: &dto.Message{
+				Type:      dto.OutputTypeQueueUnsubscribeFail,
+				RequestID: requestID,
+				Data:      dto.MessageDataError{Code: dto.ErrorCodeInvalidRequest, Message: "subscription not found"},
+			},
+		},
+		{
+			name: "set credits",
+			in: &dto.Message{
+				Type:      dto.InputTypeSubscriptionCredits,
+				RequestID: requestID,
+				Data:      dto.MessageDataSubscriptionCredits{SubscriptionID: 1, Credits: 5},
+			},
+			run: func(d testDeps) {
+				d.sess.addSubscription(queueName, 1, func() {})
+			},
+			expOut: &dto.Message{Type: dto.OutputTypeSubscriptionCreditsPass, RequestID: requestID},
+		},
+		{
+			name: "set credits of unknown subscription",
+			in: &dto.Message{
+				Type:      dto.InputTypeSubscriptionCredits,
+				RequestID: requestID,
+				Data:      dto.MessageDataSubscriptionCredits{SubscriptionID: 1, Credits: 5},
+			},
+			run: func(d testDeps) {},
+			expOut: &dto.Message{
+				Type:      dto.OutputTypeSubscriptionCreditsFail,
+				RequestID: requestID,
+				Data:      dto.MessageDataError{Code: dto.ErrorCodeInvalidRequest, Message: "subscription not found"},
+			},
+		},
+		{
+			name: "pop",
+			in: &dto.Message{
+				Type:      dto.InputTypeQueuePop,
+				RequestID: requestID,
+				Data:      dto.MessageDataQueuePop{QueueName: queueName, Max: 10},
+			},
+			run: func(d testDeps) {
+				d.mockQueueService.EXPECT().
+					Pop(gomock.Any(), gomock.Eq(queueName), gomock.Eq(10)).
+					Return([]*domain.Task{{ID: taskID, QueueName: queueName, LeaseToken: leaseToken}}, nil)
+			},
+			expOut: &dto.Message{
+				Type:      dto.OutputTypeQueuePopPass,
+				RequestID: requestID,
+				Data:      dto.MessageDataTasks{{ID: taskID, QueueName: queueName, LeaseToken: leaseToken}},
+			},
+		},
+		{
+			name: "pop invalid max",
+			in: &dto.Message{
+				Type:      dto.InputTypeQueuePop,
+				RequestID: requestID,
+				Data:      dto.MessageDataQueuePop{QueueName: queueName, Max: maxPopTasks + 1},
+			},
+			run: func(d testDeps) {},
+			expOut: &dto.Message{
+				Type:      dto.OutputTypeQueuePopFail,
+				RequestID: requestID,
+				Data: dto.MessageDataError{
+					Code:    dto.ErrorCodeInvalidRequest,
+					Message: fmt.Sprintf("queue name must not be empty and max must be from 1 to %d", maxPopTasks),
+				},
+			},
+		},
+		{
+			name: "ack",
+			in:   &dto.Message{Type: dto.InputTypeTaskAck, RequestID: requestID, Data: lease},
+			run: func(d testDeps) {
+				d.mockTaskService.EXPECT().
+					Ack(gomock.Any(), gomock.Eq(&task.AckRequest{TaskID: taskID, LeaseToken: leaseToken})).
+					Return(nil)
+			},
+			expOut: &dto.Message{Type: dto.OutputTypeTaskAckPass, RequestID: requestID},
+		},
+		{
+			name: "ack lease lost",
+			in:   &dto.Message{Type: dto.InputTypeTaskAck, RequestID: requestID, Data: lease},
+			run: func(d testDeps) {
+				d.mockTaskService.EXPECT().
+					Ack(gomock.Any(), gomock.Eq(&task.AckRequest{TaskID: taskID, LeaseToken: leaseToken})).
+					Return(domain.ErrLeaseLost)
+			},
+			expOut: &dto.Message{
+				Type:      dto.OutputTypeTaskAckFail,
+				RequestID: requestID,
+				Data:      dto.MessageDataError{Code: dto.ErrorCodeLeaseLost, Message: "lease lost"},
+			},
+		},
+		{
+			name: "nack error",
+			in:   &dto.Message{Type: dto.InputTypeTaskNack, RequestID: requestID, Data: lease},
+			run: func(d testDeps) {
+				d.mockTaskService.EXPECT().
+					Nack(gomock.Any(), gomock.Eq(&task.NackRequest{TaskID: taskID, LeaseToken: leaseToken})).
+					Return(errors.New("test error"))
+			},
+			expOut: &dto.Message{
+				Type:      dto.OutputTypeTaskNackFail,
+				RequestID: requestID,
+				Data:      dto.MessageDataError{Code: dto.ErrorCodeInternal, Message: "internal error"},
+			},
+		},
+		{
+			name: "extend non-positive duration",
+			in: &dto.Message{
+				Type:      dto.InputTypeTaskExtend,
+				RequestID: requestID,
+				Data:      dto.MessageDataTaskExtend{TaskID: taskID, LeaseToken: leaseToken, Duration: -time.Minute},
+			},
+			run: func(d testDeps) {},
+			expOut: &dto.Message{
+				Type:      dto.OutputTypeTaskExtendFail,
+				RequestID: requestID,
+				Data:      dto.MessageDataError{Code: dto.ErrorCodeInvalidRequest, Message: "duration must be positive"},
+			},
+		},
+		{
+			name: "ack error when draining",
+			in:   &dto.Message{Type: dto.InputTypeTaskAck, RequestID: requestID, Data: lease},
+			run: func(d testDeps) {
+				d.drain()
+
+				d.mockTaskService.EXPECT().
+					Ack(gomock.Any(), gomock.Eq(&task.AckRequest{TaskID: taskID, LeaseToken: leaseToken})).
+					Return(errors.New("test error"))
+			},
+			expOut: &dto.Message{
+				Type:      dto.OutputTypeTaskAckFail,
+				RequestID: requestID,
+				Data:      dto.MessageDataError{Code: dto.ErrorCodeInternal, Message: "internal error"},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mc := gomock.NewController(t)
+			defer mc.Finish()
+
+			mockQueueService := NewMockqueueService(mc)
+			mockTaskService := NewMocktaskService(mc)
+			logger, _ := logimpl.NewTestLogger()
+
+			drainCtx, drain := context.WithCancel(ctx)
+			defer drain()
+
+			handler := newMessageHandler(drainCtx, mockQueueService, mockTaskService, logger)
+			handler.listenTasks = func(context.Context, *session, *subscription, <-chan *domain.Task) {}
+
+			out := make(chan *dto.Message, 1)
+			sess := newSession(out)
+
+			tc.run(testDeps{
+				mockQueueService: mockQueueService,
+				mockTaskService:  mockTaskService,
+				drain:            drain,
+				sess:             sess,
+			})
+
+			handler.handle(ctx, sess, tc.in)
+
+			assert.Equal(t, tc.expOut, <-out)
+		})
+	}
+}
+
+func TestMessageHandler_StaleLease(t *testing.T) {
+	var (
+		ctx         = context.Background()
+		queueName   = "testQueueName"
+		redelivered = &domain.Task{ID: "testTaskID", QueueName: queueName, LeaseToken: "newLeaseToken"}
+	)
+
+	mc := gomock.NewController(t)
+	defer mc.Finish()
+
+	mockTaskService := NewMocktaskService(mc)
+	logger, _ := logimpl.NewTestLogger()
+	handler := newMessageHandler(ctx, NewMockqueueService(mc), mockTaskService, logger)
+
+	out := make(chan *dto.Message, 1)
+	sess := newSession(out)
+	sub := sess.addSubscription(queueName, 1, func() {})
+	require.True(t, sub.acquire(ctx))
+	sess.deliver(sub, redelivered)
+
+	mockTaskService.EXPECT().
+		Ack(gomock.Any(), gomock.Eq(&task.AckRequest{TaskID: redelivered.ID, LeaseToken: "oldLeaseToken"})).
+		Return(domain.ErrLeaseLost)
+
+	handler.handle(ctx, sess, &dto.Message{
+		Type: dto.InputTypeTaskAck,
+		Data: dto.MessageDataTaskLease{TaskID: redelivered.ID, LeaseToken: "oldLeaseToken"},
+	})
+	assert.Equal(t, dto.OutputTypeTaskAckFail, (<-out).Type)
+
+	sess.mu.Lock()
+	assert.Contains(t, sess.deliveries, redelivered.LeaseToken, "the delivery of the new lease is kept")
+	sess.mu.Unlock()
+
+	acquireCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	assert.False(t, sub.acquire(acquireCtx), "the credit of the new lease is kept")
+}
@@ -1,34 +1,55 @@
+//go:generate mockgen -source=service.go -destination=service_mock_test.go -package=$GOPACKAGE
 package consumer
 
 import (
 	"context"
 	"errors"
 	"io"
+	"sync"
 
 	"github.com/art-es/queue-service/internal/app/domain"
 	"github.com/art-es/queue-service/internal/app/services/consumer/dto"
+	"github.com/art-es/queue-service/internal/app/services/task"
 	"github.com/art-es/queue-service/internal/infra/log"
 )
 
 type messageIO interface {
-	Read(r io.Reader) (*dto.Message, error)
-	Write(w io.Writer, m *dto.Message) error
+	// Handshake negotiates the protocol of the connection.
+	Handshake(rw io.ReadWriter) (dto.Codec, error)
 }
 
 type queueService interface {
-	Subscribe(ctx context.Context, queueName string) (<-chan *domain.Task, error)
+	Pop(ctx context.Context, queueName string, max int) ([]*domain.Task, error)
+	Subscribe(ctx context.Context, queueName string, acquire func(ctx context.Context) bool) (<-chan *domain.Task, error)
+	Release(ctx context.Context, tasks []*domain.Task) error
+	ReleaseUnacked(ctx context.Context, tasks []*domain.Task) error
 }
 
 type taskService interface {
-	Ack(ctx context.Context, taskID string) error
-	Nack(ctx context.Context, taskID string) error
+	Ack(ctx context.Context, req *task.AckRequest) error
+	Nack(ctx context.Context, req *task.NackRequest) error
+	Extend(ctx context.Context, req *task.ExtendRequest) (*domain.Task, error)
 }
 
 type Service struct {
-	baseCtx context.Context
-	io      messageIO
-	handle  func(ctx context.Context, in *dto.Message, outChan chan<- *dto.Message)
-	logger  log.Logger
+	baseCtx      context.Context
+	drainCtx     context.Context // Done once draining starts: no new tasks are delivered
+	drain        context.CancelFunc
+	io           messageIO
+	handle       func(ctx context.Context, sess *session, in *dto.Message)
+	release      func(ctx context.Context, msg *dto.Message)
+	closeSession func(ctx context.Context, sess *session)
+	logger       log.Logger
+
+	mu    sync.Mutex
+	conns map[*connection]struct{}
+	wg    sync.WaitGroup
+}
+
+type connection struct {
+	ctx   context.Context
+	out   chan<- *dto.Message
+	close func()
 }
 
 func NewService(
@@ -39,13 +60,19 @@ func NewService(
 	logger log.Logger,
 ) *Service {
 	logger = logger.With("module", "internal/app/services/consumer")
-	handler := newMessageHandler(queueService, taskService, logger)
+	drainCtx, drain := context.WithCancel(baseCtx)
+	handler := newMessageHandler(drainCtx, queueService, taskService, logger)
 
 	return &Service{
-		baseCtx: baseCtx,
-		io:      io,
-		handle:  handler.handle,
-		logger:  logger,
+		baseCtx:      baseCtx,
+		drainCtx:     drainCtx,
+		drain:        drain,
+		io:           io,
+		handle:       handler.handle,
+		release:      handler.releaseUndelivered,
+		closeSession: handler.releaseUnacked,
+		logger:       logger,
+		conns:        make(map[*connection]struct{}),
 	}
 }
 
@@ -57,17 +84,101 @@ func (s *Service) Consume(conn io.ReadWriteCloser) {
 	}
 	defer done()
 
-	ch := make(chan *dto.Message, 1)
+	// Unbuffered, so a sent message is owned by the writer which releases its tasks if it fails
+	ch := make(chan *dto.Message)
+
+	c := &connection{ctx: ctx, out: ch, close: done}
+	if !s.addConn(c) {
+		return
+	}
+	defer s.removeConn(c)
+
+	codec, err := s.io.Handshake(conn)
+	if err != nil {
+		if !errors.Is(err, io.EOF) && ctx.Err() == nil {
+			s.logger.Log(log.LevelError).
+				With("message", "handshake error").
+				With("error", err.Error()).
+				Write()
+		}
+		return
+	}
 
 	go func() {
 		defer done()
-		s.write(ctx, conn, ch)
+		s.write(ctx, codec, ch)
 	}()
 
-	s.read(ctx, conn, ch)
+	sess := newSession(ch)
+	s.read(ctx, codec, sess)
+
+	// The consumer is gone, its subscriptions are stopped before the unacked tasks are released
+	done()
+	s.closeSession(ctx, sess)
 }
 
-func (s *Service) read(ctx context.Context, r io.Reader, ch chan<- *dto.Message) {
+// Shutdown stops delivering tasks and tells the consumers to disconnect. Open connections may still
+// ack, nack and extend their tasks until they disconnect or the context is done, then they are closed.
+func (s *Service) Shutdown(ctx context.Context) {
+	s.drain()
+
+	s.mu.Lock()
+	for c := range s.conns {
+		go c.send(&dto.Message{Type: dto.OutputTypeServerDraining})
+	}
+	s.mu.Unlock()
+
+	closed := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(closed)
+	}()
+
+	select {
+	case <-closed:
+		return
+	case <-ctx.Done():
+	}
+
+	s.mu.Lock()
+	for c := range s.conns {
+		c.close()
+	}
+	s.mu.Unlock()
+
+	<-closed
+}
+
+// addConn returns false if draining has started, as no more connections are served.
+func (s *Service) addConn(c *connection) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.drainCtx.Err() != nil {
+		return false
+	}
+
+	s.conns[c] = struct{}{}
+	s.wg.Add(1)
+	return true
+}
+
+func (s *Service) removeConn(c *connection) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.conns, c)
+	s.wg.Done()
+}
+
+func (c *connection) send(msg *dto.Message) {
+	select {
+	case <-c.ctx.Done():
+	case c.out <- msg:
+	}
+}
+
+func (s *Service) read(ctx context.Context, codec dto.Codec, sess *session) {
 	for {
 		select {
 		case <-ctx.Done():
@@ -75,9 +186,10 @@ func (s *Service) read(ctx context.Context, r io.Reader, ch chan<- *dto.Message)
 		default:
 		}
 
-		msg, err := s.io.Read(r)
+		msg, err := codec.Read()
 		if err != nil {
-			if !errors.Is(err, io.EOF) {
+			// The connection is closed on shutdown
+			if !errors.Is(err, io.EOF) && ctx.Err() == nil {
 				s.logger.Log(log.LevelError).
 					With("message", "read message error").
 					With("error", err.Error()).
@@ -87,18 +199,28 @@ func (s *Service) read(ctx context.Context, r io.Reader, ch chan<- *dto.Message)
 		}
 
 		if msg != nil {
-			s.handle(ctx, msg, ch)
+			s.handle(ctx, sess, msg)
 		}
 	}
 }
 
-func (h *Service) write(ctx context.Context, w io.Writer, ch <-chan *dto.Message) {
+func (s *Service) write(ctx context.Context, codec dto.Codec, ch <-chan *dto.Message) {
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		case msg := <-ch:
-			h.io.Write(w, msg)
+			if err := codec.Write(msg); err != nil {
+				if ctx.Err() == nil {
+					s.logger.Log(log.LevelError).
+						With("message", "write message error").
+						With("error", err.Error()).
+						Write()
+				}
+
+				s.release(ctx, msg)
+				return
+			}
 		}
 	}
 }
@@ -0,0 +1,209 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: service.go
+//
+// Generated by this command:
+//
+//	mockgen -source=service.go -destination=service_mock_test.go -package=consumer
+//
+
+// Package consumer is a generated GoMock package.
+package consumer
+
+import (
+	context "context"
+	io "io"
+	reflect "reflect"
+
+	domain "github.com/art-es/queue-service/internal/app/domain"
+	dto "github.com/art-es/queue-service/internal/app/services/consumer/dto"
+	task "github.com/art-es/queue-service/internal/app/services/task"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockmessageIO is a mock of messageIO interface.
+type MockmessageIO struct {
+	ctrl     *gomock.Controller
+	recorder *MockmessageIOMockRecorder
+	isgomock struct{}
+}
+
+// MockmessageIOMockRecorder is the mock recorder for MockmessageIO.
+type MockmessageIOMockRecorder struct {
+	mock *MockmessageIO
+}
+
+// NewMockmessageIO creates a new mock instance.
+func NewMockmessageIO(ctrl *gomock.Controller) *MockmessageIO {
+	mock := &MockmessageIO{ctrl: ctrl}
+	mock.recorder = &MockmessageIOMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockmessageIO) EXPECT() *MockmessageIOMockRecorder {
+	return m.recorder
+}
+
+// Handshake mocks base method.
+func (m *MockmessageIO) Handshake(rw io.ReadWriter) (dto.Codec, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Handshake", rw)
+	ret0, _ := ret[0].(dto.Codec)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Handshake indicates an expected call of Handshake.
+func (mr *MockmessageIOMockRecorder) Handshake(rw any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Handshake", reflect.TypeOf((*MockmessageIO)(nil).Handshake), rw)
+}
+
+// MockqueueService is a mock of queueService interface.
+type MockqueueService struct {
+	ctrl     *gomock.Controller
+	recorder *MockqueueServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockqueueServiceMockRecorder is the mock recorder for MockqueueService.
+type MockqueueServiceMockRecorder struct {
+	mock *MockqueueService
+}
+
+// NewMockqueueService creates a new mock instance.
+func NewMockqueueService(ctrl *gomock.Controller) *MockqueueService {
+	mock := &MockqueueService{ctrl: ctrl}
+	mock.recorder = &MockqueueServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockqueueService) EXPECT() *MockqueueServiceMockRecorder {
+	return m.recorder
+}
+
+// Pop mocks base method.
+func (m *MockqueueService) Pop(ctx context.Context, queueName string, max int) ([]*domain.Task, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Pop", ctx, queueName, max)
+	ret0, _ := ret[0].([]*domain.Task)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Pop indicates an expected call of Pop.
+func (mr *MockqueueServiceMockRecorder) Pop(ctx, queueName, max any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Pop", reflect.TypeOf((*MockqueueService)(nil).Pop), ctx, queueName, max)
+}
+
+// Release mocks base method.
+func (m *MockqueueService) Release(ctx context.Context, tasks []*domain.Task) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Release", ctx, tasks)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Release indicates an expected call of Release.
+func (mr *MockqueueServiceMockRecorder) Release(ctx, tasks any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Release", reflect.TypeOf((*MockqueueService)(nil).Release), ctx, tasks)
+}
+
+// ReleaseUnacked mocks base method.
+func (m *MockqueueService) ReleaseUnacked(ctx context.Context, tasks []*domain.Task) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseUnacked", ctx, tasks)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleaseUnacked indicates an expected call of ReleaseUnacked.
+func (mr *MockqueueServiceMockRecorder) ReleaseUnacked(ctx, tasks any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseUnacked", reflect.TypeOf((*MockqueueService)(nil).ReleaseUnacked), ctx, tasks)
+}
+
+// Subscribe mocks base method.
+func (m *MockqueueService) Subscribe(ctx context.Context, queueName string, acquire func(context.Context) bool) (<-chan *domain.Task, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Subscribe", ctx, queueName, acquire)
+	ret0, _ := ret[0].(<-chan *domain.Task)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Subscribe indicates an expected call of Subscribe.
+func (mr *MockqueueServiceMockRecorder) Subscribe(ctx, queueName, acquire any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Subscribe", reflect.TypeOf((*MockqueueService)(nil).Subscribe), ctx, queueName, acquire)
+}
+
+// MocktaskService is a mock of taskService interface.
+type MocktaskService struct {
+	ctrl     *gomock.Controller
+	recorder *MocktaskServiceMockRecorder
+	isgomock struct{}
+}
+
+// MocktaskServiceMockRecorder is the mock recorder for MocktaskService.
+type MocktaskServiceMockRecorder struct {
+	mock *MocktaskService
+}
+
+// NewMocktaskService creates a new mock instance.
+func NewMocktaskService(ctrl *gomock.Controller) *MocktaskService {
+	mock := &MocktaskService{ctrl: ctrl}
+	mock.recorder = &MocktaskServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MocktaskService) EXPECT() *MocktaskServiceMockRecorder {
+	return m.recorder
+}
+
+// Ack mocks base method.
+func (m *MocktaskService) Ack(ctx context.Context, req *task.AckRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ack", ctx, req)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Ack indicates an expected call of Ack.
+func (mr *MocktaskServiceMockRecorder) Ack(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ack", reflect.TypeOf((*MocktaskService)(nil).Ack), ctx, req)
+}
+
+// Extend mocks base method.
+func (m *MocktaskService) Extend(ctx context.Context, req *task.ExtendRequest) (*domain.Task, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Extend", ctx, req)
+	ret0, _ := ret[0].(*domain.Task)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Extend indicates an expected call of Extend.
+func (mr *MocktaskServiceMockRecorder) Extend(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Extend", reflect.TypeOf((*MocktaskService)(nil).Extend), ctx, req)
+}
+
+// Nack mocks base method.
+func (m *MocktaskService) Nack(ctx context.Context, req *task.NackRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Nack", ctx, req)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Nack indicates an expected call of Nack.
+func (mr *MocktaskServiceMockRecorder) Nack(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Nack", reflect.TypeOf((*MocktaskService)(nil).Nack), ctx, req)
+}
@@ -0,0 +1,177 @@
+package consumer
+
+import (
+	"context"
+	"sync"
+
+	"github.com/art-es/queue-service/internal/app/domain"
+	"github.com/art-es/queue-service/internal/app/services/consumer/dto"
+)
+
+// session is the state of a consumer connection shared by the handlers of its messages.
+type session struct {
+	out chan<- *dto.Message
+	wg  sync.WaitGroup // Delivering subscriptions
+
+	mu                 sync.Mutex
+	lastSubscriptionID uint32
+	subscriptions      map[uint32]*subscription
+	deliveries         map[string]*delivery // Unacked delivered tasks by lease token
+}
+
+// delivery is a task delivered to the consumer until it is acked or nacked.
+type delivery struct {
+	task *domain.Task  // With the fields needed to release it
+	sub  *subscription // Nil if the task is popped
+}
+
+func newSession(out chan<- *dto.Message) *session {
+	return &session{
+		out:           out,
+		subscriptions: make(map[uint32]*subscription),
+		deliveries:    make(map[string]*delivery),
+	}
+}
+
+// addSubscription adds a subscription with the next ID of the connection, cancel stops its delivery.
+func (s *session) addSubscription(queueName string, credits int, cancel func()) *subscription {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastSubscriptionID++
+	sub := newSubscription(s.lastSubscriptionID, queueName, credits, cancel)
+	s.subscriptions[sub.id] = sub
+	return sub
+}
+
+// removeSubscription returns false if there is no subscription with the ID.
+func (s *session) removeSubscription(id uint32) (*subscription, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sub, ok := s.subscriptions[id]
+	delete(s.subscriptions, id)
+	return sub, ok
+}
+
+func (s *session) getSubscription(id uint32) (*subscription, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sub, ok := s.subscriptions[id]
+	return sub, ok
+}
+
+// deliver records the task as delivered, a task of a subscription holds its credit until settled.
+// A redelivery of the task has a new lease, so it is recorded apart from the previous one.
+func (s *session) deliver(sub *subscription, task *domain.Task) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.deliveries[task.LeaseToken] = &delivery{
+		task: &domain.Task{
+			ID:         task.ID,
+			QueueName:  task.QueueName,
+			LeaseToken: task.LeaseToken,
+		},
+		sub: sub,
+	}
+}
+
+// settle forgets the delivery of the lease once it is no longer held by the consumer: acked, nacked
+// or lost. The credit of the delivery, if any, is returned to its subscription.
+func (s *session) settle(leaseToken string) {
+	s.mu.Lock()
+	d, ok := s.deliveries[leaseToken]
+	delete(s.deliveries, leaseToken)
+	s.mu.Unlock()
+
+	if ok && d.sub != nil {
+		d.sub.release()
+	}
+}
+
+// unacked returns the delivered tasks that are not settled and forgets them.
+func (s *session) unacked() []*domain.Task {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tasks := make([]*domain.Task, 0, len(s.deliveries))
+	for _, d := range s.deliveries {
+		tasks = append(tasks, d.task)
+	}
+
+	clear(s.deliveries)
+	return tasks
+}
+
+// subscription limits the unacked tasks it delivers by credits: every delivered task takes a credit
+// and gives it back once it is acked or nacked.
+type subscription struct {
+	id        uint32
+	queueName string
+	cancel    func()
+	done      chan struct{} // Closed once the delivery has stopped and the undelivered tasks are released
+
+	mu       sync.Mutex
+	credits  int // Max unacked tasks, dto.UnlimitedCredits if not limited
+	acquired int
+	changed  chan struct{} // Signalled when a credit may have become available
+}
+
+func newSubscription(id uint32, queueName string, credits int, cancel func()) *subscription {
+	return &subscription{
+		id:        id,
+		queueName: queueName,
+		cancel:    cancel,
+		done:      make(chan struct{}),
+		credits:   credits,
+		changed:   make(chan struct{}, 1),
+	}
+}
+
+// acquire waits for a credit and takes it. It returns false if the context is done first.
+func (s *subscription) acquire(ctx context.Context) bool {
+	for {
+		s.mu.Lock()
+		if s.credits == dto.UnlimitedCredits || s.acquired < s.credits {
+			s.acquired++
+			s.mu.Unlock()
+			return true
+		}
+		s.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return false
+		case <-s.changed:
+		}
+	}
+}
+
+func (s *subscription) release() {
+	s.mu.Lock()
+	if s.acquired > 0 {
+		s.acquired--
+	}
+	s.mu.Unlock()
+
+	s.notify()
+}
+
+// setCredits replaces the credits. Lowering them below the unacked tasks delivers nothing
+// until enough of them are acked or nacked.
+func (s *subscription) setCredits(credits int) {
+	s.mu.Lock()
+	s.credits = credits
+	s.mu.Unlock()
+
+	s.notify()
+}
+
+func (s *subscription) notify() {
+	select {
+	case s.changed <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,112 @@
+package consumer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/art-es/queue-service/internal/app/domain"
+	"github.com/art-es/queue-service/internal/app/services/consumer/dto"
+)
+
+func TestSubscription_Credits(t *testing.T) {
+	var (
+		queueName = "testQueueName"
+		task      = &domain.Task{ID: "testTaskID", QueueName: queueName, LeaseToken: "testLeaseToken"}
+	)
+
+	// acquired reports whether a credit is taken before the timeout.
+	acquired := func(sub *subscription, timeout time.Duration) bool {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		return sub.acquire(ctx)
+	}
+
+	t.Run("unlimited", func(t *testing.T) {
+		sub := newSubscription(1, queueName, dto.UnlimitedCredits, func() {})
+
+		for range 1000 {
+			assert.True(t, acquired(sub, 0))
+		}
+	})
+
+	t.Run("settle returns the credit", func(t *testing.T) {
+		sess := newSession(nil)
+		sub := sess.addSubscription(queueName, 1, func() {})
+
+		assert.True(t, acquired(sub, time.Second))
+		sess.deliver(sub, task)
+		assert.False(t, acquired(sub, 10*time.Millisecond), "no credits are left")
+
+		got := make(chan bool)
+		go func() {
+			got <- acquired(sub, time.Second)
+		}()
+
+		sess.settle(task.LeaseToken)
+		assert.True(t, <-got, "acquire waiting for a credit is woken up")
+
+		sess.settle(task.LeaseToken)
+		assert.False(t, acquired(sub, 10*time.Millisecond), "a task is settled once")
+	})
+
+	t.Run("redelivery holds its own credit", func(t *testing.T) {
+		sess := newSession(nil)
+		sub := sess.addSubscription(queueName, 2, func() {})
+		redelivered := &domain.Task{ID: task.ID, QueueName: queueName, LeaseToken: "newLeaseToken"}
+
+		assert.True(t, acquired(sub, time.Second))
+		sess.deliver(sub, task)
+		assert.True(t, acquired(sub, time.Second))
+		sess.deliver(sub, redelivered)
+
+		sess.settle(task.LeaseToken)
+		assert.True(t, acquired(sub, time.Second), "the credit of the first delivery is returned")
+		assert.False(t, acquired(sub, 10*time.Millisecond), "the redelivery still holds its credit")
+
+		sess.settle(redelivered.LeaseToken)
+		assert.True(t, acquired(sub, time.Second))
+	})
+
+	t.Run("set credits", func(t *testing.T) {
+		sub := newSubscription(1, queueName, 0, func() {})
+		assert.False(t, acquired(sub, 10*time.Millisecond), "zero credits pause the delivery")
+
+		sub.setCredits(2)
+		assert.True(t, acquired(sub, time.Second))
+		assert.True(t, acquired(sub, time.Second))
+		assert.False(t, acquired(sub, 10*time.Millisecond))
+
+		sub.setCredits(1)
+		sub.release()
+		assert.False(t, acquired(sub, 10*time.Millisecond), "lowered credits wait for more acks")
+
+		sub.release()
+		assert.True(t, acquired(sub, time.Second))
+	})
+}
+
+func TestSession_Unacked(t *testing.T) {
+	queueName := "testQueueName"
+
+	sess := newSession(nil)
+	sub := sess.addSubscription(queueName, 2, func() {})
+
+	popped := &domain.Task{ID: "testTaskID1", QueueName: queueName, LeaseToken: "testLeaseToken1", Payload: "payload"}
+	subscribed := &domain.Task{ID: "testTaskID2", QueueName: queueName, LeaseToken: "testLeaseToken2"}
+	acked := &domain.Task{ID: "testTaskID3", QueueName: queueName, LeaseToken: "testLeaseToken3"}
+
+	sess.deliver(nil, popped)
+	sess.deliver(sub, subscribed)
+	sess.deliver(sub, acked)
+	sess.settle(acked.LeaseToken)
+
+	assert.ElementsMatch(t, []*domain.Task{
+		{ID: popped.ID, QueueName: queueName, LeaseToken: popped.LeaseToken},
+		subscribed,
+	}, sess.unacked())
+	assert.Empty(t, sess.unacked(), "unacked tasks are forgotten")
+}
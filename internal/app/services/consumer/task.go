@@ -2,27 +2,99 @@ package consumer
 
 import (
 	"context"
+	"strconv"
 
 	"github.com/art-es/queue-service/internal/app/domain"
 	"github.com/art-es/queue-service/internal/app/services/consumer/dto"
+	"github.com/art-es/queue-service/internal/infra/log"
 )
 
-func listenTasks(ctx context.Context, tasks <-chan *domain.Task, out chan<- *dto.Message) {
-	var task *domain.Task
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case task = <-tasks:
-		}
+// forwardTasks sends the subscription tasks to the consumer tagged with the subscription,
+// each holds a credit of the subscription. It returns once the tasks channel is closed
+// after the context is done, the tasks got meanwhile are released, as they are not sent.
+func (h *messageHandler) forwardTasks(ctx context.Context, sess *session, sub *subscription, tasks <-chan *domain.Task) {
+	for task := range tasks {
+		data := toMessageDataTask(task)
+		data.SubscriptionID = sub.id
+
+		sess.deliver(sub, task)
 
-		out <- &dto.Message{
+		sent := h.send(ctx, sess.out, &dto.Message{
 			Type: dto.OutputTypeTaskProcess,
-			Data: dto.MessageDataTask{
-				ID:        task.ID,
-				Payload:   task.Payload,
-				CreatedAt: task.CreatedAt,
-			},
+			Data: data,
+		})
+		if !sent {
+			sess.settle(task.LeaseToken)
 		}
 	}
 }
+
+// releaseUnacked waits for the subscriptions of the closed connection to stop, then returns the tasks
+// the consumer has not acked or nacked to pending right away instead of after their visibility timeout.
+func (h *messageHandler) releaseUnacked(ctx context.Context, sess *session) {
+	sess.wg.Wait()
+
+	tasks := sess.unacked()
+	if len(tasks) == 0 {
+		return
+	}
+
+	if err := h.queueService.ReleaseUnacked(ctx, tasks); err != nil {
+		h.logger.Log(log.LevelError).
+			With("message", "release unacked tasks error").
+			With("error", err.Error()).
+			Write()
+		return
+	}
+
+	h.logger.Log(log.LevelDebug).
+		With("message", "released unacked tasks of closed connection").
+		With("count", strconv.Itoa(len(tasks))).
+		Write()
+}
+
+// releaseUndelivered releases the tasks of the message, if any, and logs an error.
+func (h *messageHandler) releaseUndelivered(ctx context.Context, msg *dto.Message) {
+	tasks := messageTasks(msg)
+	if len(tasks) == 0 {
+		return
+	}
+
+	if err := h.queueService.Release(ctx, tasks); err != nil {
+		h.logger.Log(log.LevelError).
+			With("message", "release undelivered tasks error").
+			With("error", err.Error()).
+			Write()
+	}
+}
+
+// messageTasks returns the tasks the message delivers with the fields needed to release them.
+func messageTasks(msg *dto.Message) []*domain.Task {
+	var data dto.MessageDataTasks
+	switch d := msg.Data.(type) {
+	case dto.MessageDataTask:
+		data = dto.MessageDataTasks{d}
+	case dto.MessageDataTasks:
+		data = d
+	}
+
+	tasks := make([]*domain.Task, 0, len(data))
+	for _, task := range data {
+		tasks = append(tasks, &domain.Task{
+			ID:         task.ID,
+			QueueName:  task.QueueName,
+			LeaseToken: task.LeaseToken,
+		})
+	}
+	return tasks
+}
+
+func toMessageDataTask(task *domain.Task) dto.MessageDataTask {
+	return dto.MessageDataTask{
+		ID:         task.ID,
+		QueueName:  task.QueueName,
+		Payload:    task.Payload,
+		CreatedAt:  task.CreatedAt,
+		LeaseToken: task.LeaseToken,
+	}
+}
@@ -0,0 +1,148 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/art-es/queue-service/internal/app/domain"
+	"github.com/art-es/queue-service/internal/app/services/consumer/dto"
+	"github.com/art-es/queue-service/internal/infra/log/logimpl"
+)
+
+func TestMessageHandler_ForwardTasks(t *testing.T) {
+	var (
+		queueName = "testQueueName"
+		task1     = &domain.Task{ID: "testTaskID1", QueueName: queueName, LeaseToken: "testLeaseToken1"}
+		task2     = &domain.Task{ID: "testTaskID2", QueueName: queueName, LeaseToken: "testLeaseToken2"}
+	)
+
+	mc := gomock.NewController(t)
+	defer mc.Finish()
+
+	mockQueueService := NewMockqueueService(mc)
+	logger, logbuf := logimpl.NewTestLogger()
+	handler := newMessageHandler(context.Background(), mockQueueService, nil, logger)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	out := make(chan *dto.Message, 1)
+	sess := newSession(out)
+	sub := sess.addSubscription(queueName, 2, cancel)
+
+	tasks := make(chan *domain.Task, 2)
+	tasks <- task1
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		handler.forwardTasks(ctx, sess, sub, tasks)
+	}()
+
+	assert.Equal(t, &dto.Message{
+		Type: dto.OutputTypeTaskProcess,
+		Data: dto.MessageDataTask{
+			SubscriptionID: sub.id,
+			ID:             task1.ID,
+			QueueName:      queueName,
+			LeaseToken:     task1.LeaseToken,
+		},
+	}, <-out, "the delivery is tagged with the subscription")
+
+	// Got after the subscription is stopped, so released instead of delivered
+	mockQueueService.EXPECT().
+		Release(gomock.Any(), gomock.Eq([]*domain.Task{{
+			ID:         task2.ID,
+			QueueName:  queueName,
+			LeaseToken: task2.LeaseToken,
+		}})).
+		Return(nil)
+
+	cancel()
+	tasks <- task2
+	close(tasks)
+	<-done
+
+	assert.Empty(t, out)
+	assert.Empty(t, logbuf.Logs())
+
+	sess.mu.Lock()
+	assert.Equal(t, map[string]*delivery{task1.LeaseToken: {task: task1, sub: sub}}, sess.deliveries, "only the delivered task is unacked")
+	sess.mu.Unlock()
+}
+
+func TestMessageHandler_ReleaseUnacked(t *testing.T) {
+	var (
+		queueName = "testQueueName"
+		task      = &domain.Task{ID: "testTaskID", QueueName: queueName, LeaseToken: "testLeaseToken"}
+	)
+
+	t.Run("release after subscriptions stop", func(t *testing.T) {
+		mc := gomock.NewController(t)
+		defer mc.Finish()
+
+		mockQueueService := NewMockqueueService(mc)
+		logger, logbuf := logimpl.NewTestLogger()
+		handler := newMessageHandler(context.Background(), mockQueueService, nil, logger)
+
+		sess := newSession(nil)
+		sess.deliver(nil, task)
+
+		// A subscription delivering its last task while the connection is closed
+		lastTask := &domain.Task{ID: "testTaskID2", QueueName: queueName, LeaseToken: "testLeaseToken2"}
+		sess.wg.Add(1)
+		go func() {
+			defer sess.wg.Done()
+			time.Sleep(10 * time.Millisecond)
+			sess.deliver(nil, lastTask)
+		}()
+
+		mockQueueService.EXPECT().
+			ReleaseUnacked(gomock.Any(), gomock.InAnyOrder([]*domain.Task{task, lastTask})).
+			Return(nil)
+
+		handler.releaseUnacked(context.Background(), sess)
+
+		assert.Empty(t, sess.unacked())
+		assert.Equal(t, []string{
+			`{"count":"2","created":"2006-01-02 15:04:05","level":"debug","message":"released unacked tasks of closed connection"}`,
+		}, logbuf.Logs())
+	})
+
+	t.Run("nothing to release", func(t *testing.T) {
+		mc := gomock.NewController(t)
+		defer mc.Finish()
+
+		logger, logbuf := logimpl.NewTestLogger()
+		handler := newMessageHandler(context.Background(), NewMockqueueService(mc), nil, logger)
+
+		handler.releaseUnacked(context.Background(), newSession(nil))
+
+		assert.Empty(t, logbuf.Logs())
+	})
+
+	t.Run("release error", func(t *testing.T) {
+		mc := gomock.NewController(t)
+		defer mc.Finish()
+
+		mockQueueService := NewMockqueueService(mc)
+		logger, logbuf := logimpl.NewTestLogger()
+		handler := newMessageHandler(context.Background(), mockQueueService, nil, logger)
+
+		sess := newSession(nil)
+		sess.deliver(nil, task)
+
+		mockQueueService.EXPECT().
+			ReleaseUnacked(gomock.Any(), gomock.Eq([]*domain.Task{task})).
+			Return(errors.New("test error"))
+
+		handler.releaseUnacked(context.Background(), sess)
+
+		assert.Len(t, logbuf.Logs(), 1)
+	})
+}
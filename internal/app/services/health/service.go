@@ -0,0 +1,82 @@
+//go:generate mockgen -source=service.go -destination=service_mock_test.go -package=$GOPACKAGE
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/art-es/queue-service/internal/app/repository"
+)
+
+// readyTimeout bounds the readiness checks, so a wedged database fails them instead of hanging.
+const readyTimeout = 2 * time.Second
+
+var (
+	ErrShuttingDown     = errors.New("shutting down")
+	ErrMigrationsBehind = errors.New("migrations are behind")
+)
+
+type pinger interface {
+	Ping(ctx context.Context) error
+}
+
+type migrationRepository interface {
+	GetVersion(ctx context.Context) (version int64, dirty bool, err error)
+}
+
+type Service struct {
+	pinger              pinger
+	migrationRepository migrationRepository
+	expectedVersion     int64
+	shuttingDown        atomic.Bool
+}
+
+func NewService(
+	pinger pinger,
+	migrationRepository migrationRepository,
+	expectedVersion int64,
+) *Service {
+	return &Service{
+		pinger:              pinger,
+		migrationRepository: migrationRepository,
+		expectedVersion:     expectedVersion,
+	}
+}
+
+// Shutdown makes the service not ready, so no new traffic is routed to it.
+func (s *Service) Shutdown() {
+	s.shuttingDown.Store(true)
+}
+
+// Ready returns an error if the service should not get traffic: it is shutting down,
+// Postgres is unreachable or its schema is older than the code expects.
+func (s *Service) Ready(ctx context.Context) error {
+	if s.shuttingDown.Load() {
+		return ErrShuttingDown
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, readyTimeout)
+	defer cancel()
+
+	if err := s.pinger.Ping(ctx); err != nil {
+		return fmt.Errorf("ping psql: %w", err)
+	}
+
+	version, dirty, err := s.migrationRepository.GetVersion(ctx)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrMigrationsBehind
+		}
+
+		return fmt.Errorf("get migration version: %w", err)
+	}
+
+	if dirty || version < s.expectedVersion {
+		return fmt.Errorf("%w: version %d, dirty %t, expected %d", ErrMigrationsBehind, version, dirty, s.expectedVersion)
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: service.go
+//
+// Generated by this command:
+//
+//	mockgen -source=service.go -destination=service_mock_test.go -package=health
+//
+
+// Package health is a generated GoMock package.
+package health
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// Mockpinger is a mock of pinger interface.
+type Mockpinger struct {
+	ctrl     *gomock.Controller
+	recorder *MockpingerMockRecorder
+	isgomock struct{}
+}
+
+// MockpingerMockRecorder is the mock recorder for Mockpinger.
+type MockpingerMockRecorder struct {
+	mock *Mockpinger
+}
+
+// NewMockpinger creates a new mock instance.
+func NewMockpinger(ctrl *gomock.Controller) *Mockpinger {
+	mock := &Mockpinger{ctrl: ctrl}
+	mock.recorder = &MockpingerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *Mockpinger) EXPECT() *MockpingerMockRecorder {
+	return m.recorder
+}
+
+// Ping mocks base method.
+func (m *Mockpinger) Ping(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ping", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Ping indicates an expected call of Ping.
+func (mr *MockpingerMockRecorder) Ping(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ping", reflect.TypeOf((*Mockpinger)(nil).Ping), ctx)
+}
+
+// MockmigrationRepository is a mock of migrationRepository interface.
+type MockmigrationRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockmigrationRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockmigrationRepositoryMockRecorder is the mock recorder for MockmigrationRepository.
+type MockmigrationRepositoryMockRecorder struct {
+	mock *MockmigrationRepository
+}
+
+// NewMockmigrationRepository creates a new mock instance.
+func NewMockmigrationRepository(ctrl *gomock.Controller) *MockmigrationRepository {
+	mock := &MockmigrationRepository{ctrl: ctrl}
+	mock.recorder = &MockmigrationRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockmigrationRepository) EXPECT() *MockmigrationRepositoryMockRecorder {
+	return m.recorder
+}
+
+// GetVersion mocks base method.
+func (m *MockmigrationRepository) GetVersion(ctx context.Context) (int64, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetVersion", ctx)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetVersion indicates an expected call of GetVersion.
+func (mr *MockmigrationRepositoryMockRecorder) GetVersion(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVersion", reflect.TypeOf((*MockmigrationRepository)(nil).GetVersion), ctx)
+}
@@ -0,0 +1,147 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/art-es/queue-service/internal/app/repository"
+)
+
+func TestService_Ready(t *testing.T) {
+	var (
+		ctx             = context.Background()
+		expectedVersion = int64(20260320090000)
+	)
+
+	type testDeps struct {
+		mockPinger              *Mockpinger
+		mockMigrationRepository *MockmigrationRepository
+		service                 *Service
+	}
+
+	for _, tc := range []struct {
+		name string
+		run  func(t *testing.T, d testDeps)
+	}{
+		{
+			name: "ready",
+			run: func(t *testing.T, d testDeps) {
+				d.mockPinger.EXPECT().
+					Ping(gomock.Any()).
+					Return(nil)
+
+				d.mockMigrationRepository.EXPECT().
+					GetVersion(gomock.Any()).
+					Return(expectedVersion, false, nil)
+
+				assert.NoError(t, d.service.Ready(ctx))
+			},
+		},
+		{
+			name: "ready with newer migrations",
+			run: func(t *testing.T, d testDeps) {
+				d.mockPinger.EXPECT().
+					Ping(gomock.Any()).
+					Return(nil)
+
+				d.mockMigrationRepository.EXPECT().
+					GetVersion(gomock.Any()).
+					Return(expectedVersion+1, false, nil)
+
+				assert.NoError(t, d.service.Ready(ctx))
+			},
+		},
+		{
+			name: "shutting down",
+			run: func(t *testing.T, d testDeps) {
+				d.service.Shutdown()
+
+				assert.ErrorIs(t, d.service.Ready(ctx), ErrShuttingDown)
+			},
+		},
+		{
+			name: "ping error",
+			run: func(t *testing.T, d testDeps) {
+				d.mockPinger.EXPECT().
+					Ping(gomock.Any()).
+					Return(errors.New("test error"))
+
+				assert.EqualError(t, d.service.Ready(ctx), "ping psql: test error")
+			},
+		},
+		{
+			name: "migrations behind",
+			run: func(t *testing.T, d testDeps) {
+				d.mockPinger.EXPECT().
+					Ping(gomock.Any()).
+					Return(nil)
+
+				d.mockMigrationRepository.EXPECT().
+					GetVersion(gomock.Any()).
+					Return(expectedVersion-1, false, nil)
+
+				assert.ErrorIs(t, d.service.Ready(ctx), ErrMigrationsBehind)
+			},
+		},
+		{
+			name: "dirty migration",
+			run: func(t *testing.T, d testDeps) {
+				d.mockPinger.EXPECT().
+					Ping(gomock.Any()).
+					Return(nil)
+
+				d.mockMigrationRepository.EXPECT().
+					GetVersion(gomock.Any()).
+					Return(expectedVersion, true, nil)
+
+				assert.ErrorIs(t, d.service.Ready(ctx), ErrMigrationsBehind)
+			},
+		},
+		{
+			name: "no migrations",
+			run: func(t *testing.T, d testDeps) {
+				d.mockPinger.EXPECT().
+					Ping(gomock.Any()).
+					Return(nil)
+
+				d.mockMigrationRepository.EXPECT().
+					GetVersion(gomock.Any()).
+					Return(int64(0), false, repository.ErrNotFound)
+
+				assert.ErrorIs(t, d.service.Ready(ctx), ErrMigrationsBehind)
+			},
+		},
+		{
+			name: "get migration version error",
+			run: func(t *testing.T, d testDeps) {
+				d.mockPinger.EXPECT().
+					Ping(gomock.Any()).
+					Return(nil)
+
+				d.mockMigrationRepository.EXPECT().
+					GetVersion(gomock.Any()).
+					Return(int64(0), false, errors.New("test error"))
+
+				assert.EqualError(t, d.service.Ready(ctx), "get migration version: test error")
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mc := gomock.NewController(t)
+			defer mc.Finish()
+
+			mockPinger := NewMockpinger(mc)
+			mockMigrationRepository := NewMockmigrationRepository(mc)
+
+			tc.run(t, testDeps{
+				mockPinger:              mockPinger,
+				mockMigrationRepository: mockMigrationRepository,
+				service:                 NewService(mockPinger, mockMigrationRepository, expectedVersion),
+			})
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/art-es/queue-service/internal/app/domain"
+	"github.com/art-es/queue-service/internal/app/repository"
+	"github.com/art-es/queue-service/internal/infra/trx/trxutil"
+)
+
+type PushBatchRequest struct {
+	QueueName string
+	Items     []*PushBatchItem
+}
+
+type PushBatchItem struct {
+	Payload        string
+	IdempotencyKey *string
+	Delay          *time.Duration // Delivery delay from now
+	NotBefore      *time.Time     // Delivery time, ignored if Delay is set
+	Priority       int
+}
+
+// PushBatchResult is the result of one item, either Task or Err is set.
+type PushBatchResult struct {
+	Task *domain.Task
+	Err  error
+}
+
+// PushBatch pushes all the items with a single insert. Items rejected by the queue settings
+// get an error result and do not fail the others. Results are in the order of the items.
+func (s *Service) PushBatch(ctx context.Context, req *PushBatchRequest) ([]*PushBatchResult, error) {
+	results, err := s.pushBatch(ctx, req)
+	if errors.Is(err, repository.ErrAlreadyExists) {
+		// A concurrent push has stored one of the idempotency keys first, the retry gets its task.
+		return s.pushBatch(ctx, req)
+	}
+
+	return results, err
+}
+
+func (s *Service) pushBatch(ctx context.Context, req *PushBatchRequest) ([]*PushBatchResult, error) {
+	queue, err := s.getQueue(ctx, req.QueueName)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*PushBatchResult, len(req.Items))
+	tasks := make([]*domain.Task, 0, len(req.Items))
+	fingerprints := make([]string, len(req.Items))
+	// Items repeating an idempotency key of the batch get the task of the first one
+	keyIndexes := make(map[string]int)
+	repeatIndexes := make(map[int]int)
+
+	for i, item := range req.Items {
+		fingerprints[i] = domain.PushFingerprint(req.QueueName, item.Payload)
+
+		if item.IdempotencyKey != nil {
+			record, ok, err := s.idempotencyKeyCache.GetQueuePush(ctx, req.QueueName, *item.IdempotencyKey)
+			if err != nil {
+				return nil, fmt.Errorf("get idempotency key: %w", err)
+			}
+
+			s.countIdempotencyKeyLookup(ok)
+
+			if ok {
+				if err = record.Check(fingerprints[i]); err != nil {
+					results[i] = &PushBatchResult{Err: err}
+					continue
+				}
+
+				results[i] = &PushBatchResult{Task: record.Task}
+				continue
+			}
+
+			if first, ok := keyIndexes[*item.IdempotencyKey]; ok {
+				repeatIndexes[i] = first
+				continue
+			}
+			keyIndexes[*item.IdempotencyKey] = i
+		}
+
+		if err = queue.CheckPayload(item.Payload); err != nil {
+			results[i] = &PushBatchResult{Err: err}
+			continue
+		}
+
+		task := domain.NewTask(req.QueueName, item.Payload, item.Priority, s.visibleAt(item.Delay, item.NotBefore))
+		task.ID = s.idGenerator.NewID()
+
+		tasks = append(tasks, task)
+		results[i] = &PushBatchResult{Task: task}
+	}
+
+	if len(tasks) > 0 {
+		records := make(map[string]*domain.PushRecord, len(keyIndexes))
+		for key, i := range keyIndexes {
+			if results[i].Task != nil {
+				records[key] = &domain.PushRecord{Fingerprint: fingerprints[i], Task: results[i].Task}
+			}
+		}
+		keysInTrx := len(records) > 0 && s.idempotencyKeyCache.Transactional()
+
+		err = trxutil.DoOrLogError(s.logger, s.metrics, "queue.push_batch", ctx, func(ctx context.Context) error {
+			if err := s.taskRepository.InsertBatch(ctx, tasks); err != nil {
+				return fmt.Errorf("insert tasks: %w", err)
+			}
+
+			if !keysInTrx {
+				return nil
+			}
+
+			for key, record := range records {
+				if err := s.idempotencyKeyCache.SetQueuePush(ctx, req.QueueName, key, record); err != nil {
+					return fmt.Errorf("set idempotency key: %w", err)
+				}
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if !keysInTrx {
+			for key, record := range records {
+				s.setPushKeyAfterCommit(ctx, req.QueueName, key, record)
+			}
+		}
+
+		s.pushedTasks.Add(float64(len(tasks)), req.QueueName)
+	}
+
+	for _, task := range tasks {
+		s.notifyAvailable(task)
+	}
+
+	for i, first := range repeatIndexes {
+		if fingerprints[i] != fingerprints[first] {
+			results[i] = &PushBatchResult{Err: domain.ErrIdempotencyKeyReused}
+			continue
+		}
+		results[i] = results[first]
+	}
+
+	return results, nil
+}
@@ -0,0 +1,215 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/art-es/queue-service/internal/app/domain"
+	"github.com/art-es/queue-service/internal/app/repository"
+	"github.com/art-es/queue-service/internal/infra/log/logimpl"
+	"github.com/art-es/queue-service/internal/infra/metrics/metricsimpl"
+	"github.com/art-es/queue-service/internal/infra/ops"
+)
+
+func TestService_PushBatch(t *testing.T) {
+	var (
+		ctx       = context.Background()
+		queueName = "testQueueName"
+	)
+
+	type testDeps struct {
+		mockIDGenerator         *MockidGenerator
+		mockIdempotencyKeyCache *MockidempotencyKeyCache
+		mockNotifier            *Mocknotifier
+		mockQueueRepository     *MockqueueRepository
+		mockTaskRepository      *MocktaskRepository
+		service                 *Service
+	}
+
+	for _, tc := range []struct {
+		name string
+		run  func(t *testing.T, d testDeps)
+	}{
+		{
+			name: "push batch",
+			run: func(t *testing.T, d testDeps) {
+				queue := domain.NewQueue(queueName)
+				queue.MaxPayloadSize = 4
+
+				cachedTask := &domain.Task{ID: "cachedTaskID", QueueName: queueName, Payload: "c"}
+
+				expTasks := []*domain.Task{
+					{ID: "taskID1", QueueName: queueName, Payload: "a", Status: domain.TaskStatusPending, Priority: 1},
+					{ID: "taskID2", QueueName: queueName, Payload: "b", Status: domain.TaskStatusPending},
+				}
+
+				d.mockQueueRepository.EXPECT().
+					Get(gomock.Any(), gomock.Eq(queueName)).
+					Return(queue, nil)
+
+				d.mockIdempotencyKeyCache.EXPECT().
+					GetQueuePush(gomock.Any(), gomock.Eq(queueName), gomock.Eq("cachedKey")).
+					Return(&domain.PushRecord{Fingerprint: domain.PushFingerprint(queueName, "c"), Task: cachedTask}, true, nil)
+
+				d.mockIdempotencyKeyCache.EXPECT().
+					GetQueuePush(gomock.Any(), gomock.Eq(queueName), gomock.Eq("reusedKey")).
+					Return(&domain.PushRecord{Fingerprint: domain.PushFingerprint(queueName, "other"), Task: cachedTask}, true, nil)
+
+				d.mockIdempotencyKeyCache.EXPECT().
+					GetQueuePush(gomock.Any(), gomock.Eq(queueName), gomock.Eq("newKey")).
+					Return(nil, false, nil).
+					Times(3)
+
+				gomock.InOrder(
+					d.mockIDGenerator.EXPECT().NewID().Return("taskID1"),
+					d.mockIDGenerator.EXPECT().NewID().Return("taskID2"),
+				)
+
+				d.mockIdempotencyKeyCache.EXPECT().
+					Transactional().
+					Return(true)
+
+				d.mockTaskRepository.EXPECT().
+					InsertBatch(gomock.Any(), gomock.Eq(expTasks)).
+					Return(nil)
+
+				d.mockNotifier.EXPECT().
+					Notify(gomock.Eq(queueName)).
+					Times(2)
+
+				d.mockIdempotencyKeyCache.EXPECT().
+					SetQueuePush(gomock.Any(), gomock.Eq(queueName), gomock.Eq("newKey"), gomock.Eq(&domain.PushRecord{
+						Fingerprint: domain.PushFingerprint(queueName, "a"),
+						Task:        expTasks[0],
+					})).
+					Return(nil)
+
+				results, err := d.service.PushBatch(ctx, &PushBatchRequest{
+					QueueName: queueName,
+					Items: []*PushBatchItem{
+						{Payload: "a", IdempotencyKey: ops.Pointer("newKey"), Priority: 1},
+						{Payload: "c", IdempotencyKey: ops.Pointer("cachedKey")},
+						{Payload: "too large"},
+						{Payload: "b"},
+						{Payload: "a", IdempotencyKey: ops.Pointer("newKey")},
+						{Payload: "b", IdempotencyKey: ops.Pointer("newKey")},
+						{Payload: "c", IdempotencyKey: ops.Pointer("reusedKey")},
+					},
+				})
+
+				assert.NoError(t, err)
+				assert.Equal(t, []*PushBatchResult{
+					{Task: expTasks[0]},
+					{Task: cachedTask},
+					{Err: domain.ErrPayloadTooLarge},
+					{Task: expTasks[1]},
+					{Task: expTasks[0]},
+					{Err: domain.ErrIdempotencyKeyReused},
+					{Err: domain.ErrIdempotencyKeyReused},
+				}, results)
+			},
+		},
+		{
+			name: "push batch without valid items",
+			run: func(t *testing.T, d testDeps) {
+				queue := domain.NewQueue(queueName)
+				queue.MaxPayloadSize = 4
+
+				d.mockQueueRepository.EXPECT().
+					Get(gomock.Any(), gomock.Eq(queueName)).
+					Return(queue, nil)
+
+				results, err := d.service.PushBatch(ctx, &PushBatchRequest{
+					QueueName: queueName,
+					Items:     []*PushBatchItem{{Payload: "too large"}},
+				})
+
+				assert.NoError(t, err)
+				assert.Equal(t, []*PushBatchResult{{Err: domain.ErrPayloadTooLarge}}, results)
+			},
+		},
+		{
+			name: "get queue error",
+			run: func(t *testing.T, d testDeps) {
+				d.mockQueueRepository.EXPECT().
+					Get(gomock.Any(), gomock.Eq(queueName)).
+					Return(nil, errors.New("test error"))
+
+				results, err := d.service.PushBatch(ctx, &PushBatchRequest{
+					QueueName: queueName,
+					Items:     []*PushBatchItem{{Payload: "a"}},
+				})
+
+				assert.EqualError(t, err, "get queue: test error")
+				assert.Nil(t, results)
+			},
+		},
+		{
+			name: "insert error",
+			run: func(t *testing.T, d testDeps) {
+				d.mockQueueRepository.EXPECT().
+					Get(gomock.Any(), gomock.Eq(queueName)).
+					Return(nil, repository.ErrNotFound)
+
+				d.mockIdempotencyKeyCache.EXPECT().
+					GetQueuePush(gomock.Any(), gomock.Eq(queueName), gomock.Eq("newKey")).
+					Return(nil, false, nil)
+
+				d.mockIDGenerator.EXPECT().
+					NewID().
+					Return("taskID1")
+
+				// A store out of the trx gets no key as the insert is rolled back
+				d.mockIdempotencyKeyCache.EXPECT().
+					Transactional().
+					Return(false)
+
+				d.mockTaskRepository.EXPECT().
+					InsertBatch(gomock.Any(), gomock.Any()).
+					Return(errors.New("test error"))
+
+				results, err := d.service.PushBatch(ctx, &PushBatchRequest{
+					QueueName: queueName,
+					Items:     []*PushBatchItem{{Payload: "a", IdempotencyKey: ops.Pointer("newKey")}},
+				})
+
+				assert.EqualError(t, err, "insert tasks: test error")
+				assert.Nil(t, results)
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mc := gomock.NewController(t)
+			defer mc.Finish()
+
+			mockIDGenerator := NewMockidGenerator(mc)
+			mockIdempotencyKeyCache := NewMockidempotencyKeyCache(mc)
+			mockNotifier := NewMocknotifier(mc)
+			mockQueueRepository := NewMockqueueRepository(mc)
+			mockTaskRepository := NewMocktaskRepository(mc)
+			logger, _ := logimpl.NewTestLogger()
+
+			tc.run(t, testDeps{
+				mockIDGenerator:         mockIDGenerator,
+				mockIdempotencyKeyCache: mockIdempotencyKeyCache,
+				mockNotifier:            mockNotifier,
+				mockQueueRepository:     mockQueueRepository,
+				mockTaskRepository:      mockTaskRepository,
+				service: NewService(
+					nil,
+					mockIDGenerator,
+					mockIdempotencyKeyCache,
+					mockNotifier,
+					mockQueueRepository,
+					mockTaskRepository,
+					metricsimpl.NewRegistry(),
+					logger,
+				),
+			})
+		})
+	}
+}
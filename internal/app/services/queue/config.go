@@ -0,0 +1,64 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/art-es/queue-service/internal/app/domain"
+	"github.com/art-es/queue-service/internal/app/repository"
+)
+
+var (
+	ErrQueueNotFound = errors.New("queue not found")
+)
+
+// GetQueue returns stored queue settings.
+func (s *Service) GetQueue(ctx context.Context, name string) (*domain.Queue, error) {
+	queue, err := s.queueRepository.Get(ctx, name)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrQueueNotFound
+		}
+
+		return nil, fmt.Errorf("get queue: %w", err)
+	}
+
+	return queue, nil
+}
+
+// SaveQueue creates or replaces queue settings.
+func (s *Service) SaveQueue(ctx context.Context, queue *domain.Queue) error {
+	if err := s.queueRepository.Save(ctx, queue); err != nil {
+		return fmt.Errorf("save queue: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteQueue deletes queue settings, the queue falls back to the defaults.
+func (s *Service) DeleteQueue(ctx context.Context, name string) error {
+	if err := s.queueRepository.Delete(ctx, name); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrQueueNotFound
+		}
+
+		return fmt.Errorf("delete queue: %w", err)
+	}
+
+	return nil
+}
+
+// getQueue returns stored queue settings or the defaults.
+func (s *Service) getQueue(ctx context.Context, name string) (*domain.Queue, error) {
+	queue, err := s.queueRepository.Get(ctx, name)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return domain.NewQueue(name), nil
+		}
+
+		return nil, fmt.Errorf("get queue: %w", err)
+	}
+
+	return queue, nil
+}
@@ -0,0 +1,118 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/art-es/queue-service/internal/app/domain"
+	"github.com/art-es/queue-service/internal/app/repository"
+	"github.com/art-es/queue-service/internal/infra/log/logimpl"
+	"github.com/art-es/queue-service/internal/infra/metrics/metricsimpl"
+)
+
+func TestService_Queues(t *testing.T) {
+	var (
+		ctx       = context.Background()
+		queueName = "testQueueName"
+	)
+
+	type testDeps struct {
+		mockQueueRepository *MockqueueRepository
+		service             *Service
+	}
+
+	for _, tc := range []struct {
+		name string
+		run  func(t *testing.T, d testDeps)
+	}{
+		{
+			name: "get queue",
+			run: func(t *testing.T, d testDeps) {
+				expQueue := domain.NewQueue(queueName)
+
+				d.mockQueueRepository.EXPECT().
+					Get(gomock.Any(), gomock.Eq(queueName)).
+					Return(expQueue, nil)
+
+				queue, err := d.service.GetQueue(ctx, queueName)
+
+				assert.NoError(t, err)
+				assert.Equal(t, expQueue, queue)
+			},
+		},
+		{
+			name: "get not stored queue",
+			run: func(t *testing.T, d testDeps) {
+				d.mockQueueRepository.EXPECT().
+					Get(gomock.Any(), gomock.Eq(queueName)).
+					Return(nil, repository.ErrNotFound)
+
+				queue, err := d.service.GetQueue(ctx, queueName)
+
+				assert.ErrorIs(t, err, ErrQueueNotFound)
+				assert.Nil(t, queue)
+			},
+		},
+		{
+			name: "save queue",
+			run: func(t *testing.T, d testDeps) {
+				queue := domain.NewQueue(queueName)
+
+				d.mockQueueRepository.EXPECT().
+					Save(gomock.Any(), gomock.Eq(queue)).
+					Return(nil)
+
+				assert.NoError(t, d.service.SaveQueue(ctx, queue))
+			},
+		},
+		{
+			name: "save queue error",
+			run: func(t *testing.T, d testDeps) {
+				queue := domain.NewQueue(queueName)
+
+				d.mockQueueRepository.EXPECT().
+					Save(gomock.Any(), gomock.Eq(queue)).
+					Return(errors.New("test error"))
+
+				assert.EqualError(t, d.service.SaveQueue(ctx, queue), "save queue: test error")
+			},
+		},
+		{
+			name: "delete queue",
+			run: func(t *testing.T, d testDeps) {
+				d.mockQueueRepository.EXPECT().
+					Delete(gomock.Any(), gomock.Eq(queueName)).
+					Return(nil)
+
+				assert.NoError(t, d.service.DeleteQueue(ctx, queueName))
+			},
+		},
+		{
+			name: "delete not stored queue",
+			run: func(t *testing.T, d testDeps) {
+				d.mockQueueRepository.EXPECT().
+					Delete(gomock.Any(), gomock.Eq(queueName)).
+					Return(repository.ErrNotFound)
+
+				assert.ErrorIs(t, d.service.DeleteQueue(ctx, queueName), ErrQueueNotFound)
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mc := gomock.NewController(t)
+			defer mc.Finish()
+
+			mockQueueRepository := NewMockqueueRepository(mc)
+			logger, _ := logimpl.NewTestLogger()
+
+			tc.run(t, testDeps{
+				mockQueueRepository: mockQueueRepository,
+				service:             NewService(nil, nil, nil, nil, mockQueueRepository, nil, metricsimpl.NewRegistry(), logger),
+			})
+		})
+	}
+}
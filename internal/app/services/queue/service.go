@@ -5,97 +5,287 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/art-es/queue-service/internal/app/domain"
 	"github.com/art-es/queue-service/internal/app/repository"
 	"github.com/art-es/queue-service/internal/infra/log"
+	"github.com/art-es/queue-service/internal/infra/metrics"
+	"github.com/art-es/queue-service/internal/infra/ops"
 	"github.com/art-es/queue-service/internal/infra/trx/trxutil"
 )
 
+const (
+	// Tasks may become available without a push: after a nack backoff or a lock expiry.
+	// Waiting pops recheck the queue at this interval even without a wake-up signal.
+	waitPollInterval = 5 * time.Second
+
+	// releaseTimeout bounds the release of undelivered tasks, it is done after the pop context is done.
+	releaseTimeout = 5 * time.Second
+)
+
+// throughputPeriods are the periods of the queue throughput stats, ascending.
+var throughputPeriods = []time.Duration{time.Minute, 5 * time.Minute, time.Hour}
+
 type clock interface {
 	Now() time.Time
+	// After sends the current time on the returned channel once the duration has elapsed.
+	After(d time.Duration) <-chan time.Time
+}
+
+type idGenerator interface {
+	NewID() string
 }
 
 type idempotencyKeyCache interface {
-	GetQueuePush(key string) (*domain.Task, bool)
-	SetQueuePush(key string, result *domain.Task)
+	GetQueuePush(ctx context.Context, queueName, key string) (*domain.PushRecord, bool, error)
+	// SetQueuePush returns repository.ErrAlreadyExists if the key is already set.
+	SetQueuePush(ctx context.Context, queueName, key string, record *domain.PushRecord) error
+	DeleteExpired(ctx context.Context) (int64, error)
+	// Transactional reports whether the keys are stored in the trx of the operation,
+	// otherwise they are set after its commit, so a failed commit leaves no key behind.
+	Transactional() bool
+}
+
+type notifier interface {
+	Wait(key string) <-chan struct{}
+	Notify(key string)
+	NotifyAt(key string, at time.Time)
+}
+
+type queueRepository interface {
+	Get(ctx context.Context, name string) (*domain.Queue, error)
+	Save(ctx context.Context, queue *domain.Queue) error
+	Delete(ctx context.Context, name string) error
 }
 
 type taskRepository interface {
-	GetFirstPending(ctx context.Context, queueName string) (*domain.Task, error)
+	GetPending(ctx context.Context, queueName string, limit int) ([]*domain.Task, error)
+	GetDead(ctx context.Context, queueName string, limit int) ([]*domain.Task, error)
+	RedriveDead(ctx context.Context, queueName string) (int64, error)
+	DeleteDead(ctx context.Context, queueName string) (int64, error)
+	DeleteExpired(ctx context.Context) (int64, error)
+	GetStats(ctx context.Context, queueName string) (*domain.QueueStats, error)
+	ListStats(ctx context.Context) ([]*domain.QueueStats, error)
+	GetThroughput(ctx context.Context, queueName string, periods []time.Duration) ([]*domain.QueueThroughput, error)
+	DeleteStaleThroughput(ctx context.Context, period time.Duration) (int64, error)
+	InsertBatch(ctx context.Context, tasks []*domain.Task) error
 	Save(ctx context.Context, task *domain.Task) error
+	UpdateBatch(ctx context.Context, tasks []*domain.Task) error
+	ReleaseBatch(ctx context.Context, tasks []*domain.Task, countAttempts bool) (int64, error)
 }
 
 type PushRequest struct {
 	QueueName      string
 	Payload        string
 	IdempotencyKey *string
+	Delay          *time.Duration // Delivery delay from now
+	NotBefore      *time.Time     // Delivery time, ignored if Delay is set
+	Priority       int
 }
 
 type Service struct {
 	clock               clock
+	idGenerator         idGenerator
 	idempotencyKeyCache idempotencyKeyCache
+	notifier            notifier
+	queueRepository     queueRepository
 	taskRepository      taskRepository
+	metrics             metrics.Metrics
 	logger              log.Logger
+
+	pushedTasks           metrics.Counter
+	poppedTasks           metrics.Counter
+	idempotencyKeyLookups metrics.Counter
 }
 
 func NewService(
 	clock clock,
+	idGenerator idGenerator,
 	idempotencyKeyCache idempotencyKeyCache,
+	notifier notifier,
+	queueRepository queueRepository,
 	taskRepository taskRepository,
+	metrics metrics.Metrics,
 	logger log.Logger,
 ) *Service {
 	logger = logger.With("module", "internal/app/services/queue")
 
 	return &Service{
 		clock:               clock,
+		idGenerator:         idGenerator,
 		idempotencyKeyCache: idempotencyKeyCache,
+		notifier:            notifier,
+		queueRepository:     queueRepository,
 		taskRepository:      taskRepository,
+		metrics:             metrics,
 		logger:              logger,
+
+		pushedTasks: metrics.Counter("queue_tasks_pushed_total", "Pushed tasks by queue.", "queue"),
+		poppedTasks: metrics.Counter("queue_tasks_popped_total", "Popped tasks by queue.", "queue"),
+		idempotencyKeyLookups: metrics.Counter(
+			"idempotency_key_lookups_total",
+			"Idempotency key lookups by operation and result.",
+			"operation", "result",
+		),
 	}
 }
 
 func (s *Service) Push(ctx context.Context, req *PushRequest) (*domain.Task, error) {
+	task, err := s.push(ctx, req)
+	if errors.Is(err, repository.ErrAlreadyExists) {
+		// A concurrent push has stored the idempotency key first, the retry gets its task.
+		return s.push(ctx, req)
+	}
+
+	return task, err
+}
+
+func (s *Service) push(ctx context.Context, req *PushRequest) (*domain.Task, error) {
+	fingerprint := domain.PushFingerprint(req.QueueName, req.Payload)
+
 	if req.IdempotencyKey != nil {
-		if task, ok := s.idempotencyKeyCache.GetQueuePush(*req.IdempotencyKey); ok {
-			return task, nil
+		record, ok, err := s.idempotencyKeyCache.GetQueuePush(ctx, req.QueueName, *req.IdempotencyKey)
+		if err != nil {
+			return nil, fmt.Errorf("get idempotency key: %w", err)
+		}
+
+		s.countIdempotencyKeyLookup(ok)
+
+		if ok {
+			if err = record.Check(fingerprint); err != nil {
+				return nil, err
+			}
+			return record.Task, nil
 		}
 	}
 
-	task := domain.NewTask(req.QueueName, req.Payload)
+	queue, err := s.getQueue(ctx, req.QueueName)
+	if err != nil {
+		return nil, err
+	}
 
-	if err := s.taskRepository.Save(ctx, task); err != nil {
-		return nil, fmt.Errorf("save task: %w", err)
+	if err = queue.CheckPayload(req.Payload); err != nil {
+		return nil, err
 	}
 
+	task := domain.NewTask(req.QueueName, req.Payload, req.Priority, s.visibleAt(req.Delay, req.NotBefore))
+
+	var record *domain.PushRecord
 	if req.IdempotencyKey != nil {
-		s.idempotencyKeyCache.SetQueuePush(*req.IdempotencyKey, task)
+		record = &domain.PushRecord{Fingerprint: fingerprint, Task: task}
 	}
+	keyInTrx := record != nil && s.idempotencyKeyCache.Transactional()
+
+	err = trxutil.DoOrLogError(s.logger, s.metrics, "queue.push", ctx, func(ctx context.Context) error {
+		if err := s.taskRepository.Save(ctx, task); err != nil {
+			return fmt.Errorf("save task: %w", err)
+		}
 
+		if keyInTrx {
+			if err := s.idempotencyKeyCache.SetQueuePush(ctx, req.QueueName, *req.IdempotencyKey, record); err != nil {
+				return fmt.Errorf("set idempotency key: %w", err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if record != nil && !keyInTrx {
+		s.setPushKeyAfterCommit(ctx, req.QueueName, *req.IdempotencyKey, record)
+	}
+
+	s.pushedTasks.Inc(task.QueueName)
+	s.notifyAvailable(task)
 	return task, nil
 }
 
-func (s *Service) Pop(ctx context.Context, queueName string) (*domain.Task, error) {
-	var task *domain.Task
+// setPushKeyAfterCommit sets the idempotency key of a committed push in a store out of the trx.
+// The task is pushed anyway, so an error is only logged, and a key set by a concurrent push is kept.
+func (s *Service) setPushKeyAfterCommit(ctx context.Context, queueName, key string, record *domain.PushRecord) {
+	err := s.idempotencyKeyCache.SetQueuePush(ctx, queueName, key, record)
+	if err != nil && !errors.Is(err, repository.ErrAlreadyExists) {
+		s.logger.Log(log.LevelError).
+			With("message", "set idempotency key error on queue.push").
+			With("error", err.Error()).
+			With("queue_name", queueName).
+			Write()
+	}
+}
 
-	now := s.clock.Now()
-	err := trxutil.DoOrLogError(s.logger, "queue.pop", ctx, func(ctx context.Context) error {
-		var err error
+// countIdempotencyKeyLookup counts a push idempotency key lookup as a hit if the key is found.
+func (s *Service) countIdempotencyKeyLookup(ok bool) {
+	result := "miss"
+	if ok {
+		result = "hit"
+	}
+
+	s.idempotencyKeyLookups.Inc("queue.push", result)
+}
 
-		task, err = s.taskRepository.GetFirstPending(ctx, queueName)
+// notifyAvailable wakes up waiting pops of the task queue once the task is visible.
+func (s *Service) notifyAvailable(task *domain.Task) {
+	if task.LockedUntil == nil {
+		s.notifier.Notify(task.QueueName)
+		return
+	}
+
+	s.notifier.NotifyAt(task.QueueName, *task.LockedUntil)
+}
+
+func (s *Service) visibleAt(delay *time.Duration, notBefore *time.Time) *time.Time {
+	if delay != nil {
+		return ops.Pointer(s.clock.Now().Add(*delay))
+	}
+	return notBefore
+}
+
+// Pop takes up to max pending tasks into processing.
+func (s *Service) Pop(ctx context.Context, queueName string, max int) ([]*domain.Task, error) {
+	var tasks []*domain.Task
+
+	now := s.clock.Now()
+	err := trxutil.DoOrLogError(s.logger, s.metrics, "queue.pop", ctx, func(ctx context.Context) error {
+		queue, err := s.getQueue(ctx, queueName)
 		if err != nil {
-			if errors.Is(err, repository.ErrNotFound) {
+			return err
+		}
+
+		// Repeats only if some of the got tasks have been dead-lettered.
+		for len(tasks) < max {
+			pending, err := s.taskRepository.GetPending(ctx, queueName, max-len(tasks))
+			if err != nil {
+				return fmt.Errorf("get pending tasks: %w", err)
+			}
+
+			if len(pending) == 0 {
 				return nil
 			}
 
-			return fmt.Errorf("get first pending task: %w", err)
-		}
+			dead := false
+			for _, task := range pending {
+				// The previous delivery was the last attempt and has never been acked or nacked.
+				if task.IsExhausted(queue.MaxAttempts) {
+					task.ToDead()
+					dead = true
+					continue
+				}
+
+				task.ToProcessing(now, s.idGenerator.NewID(), queue)
+				tasks = append(tasks, task)
+			}
 
-		task.ToProcessing(now)
+			if err = s.taskRepository.UpdateBatch(ctx, pending); err != nil {
+				return fmt.Errorf("update tasks: %w", err)
+			}
 
-		if err = s.taskRepository.Save(ctx, task); err != nil {
-			return fmt.Errorf("save task: %w", err)
+			if !dead {
+				return nil
+			}
 		}
 
 		return nil
@@ -104,5 +294,298 @@ func (s *Service) Pop(ctx context.Context, queueName string) (*domain.Task, erro
 		return nil, err
 	}
 
-	return task, nil
+	if len(tasks) > 0 {
+		s.poppedTasks.Add(float64(len(tasks)), queueName)
+	}
+
+	return tasks, nil
+}
+
+// Release returns popped tasks that have not been delivered to pending, so they are available again
+// right away instead of after the visibility timeout. Their attempts are not counted.
+// It is done even if the context is done, as it is usually called once the requester is gone.
+func (s *Service) Release(ctx context.Context, tasks []*domain.Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), releaseTimeout)
+	defer cancel()
+
+	return s.release(ctx, tasks, false)
+}
+
+// ReleaseUnacked returns tasks delivered to a consumer which is gone without acking or nacking them
+// to pending right away. Their deliveries count as failed attempts in the queues with
+// DisconnectCountsAttempt set, otherwise they are not counted. It is done even if the context is done.
+func (s *Service) ReleaseUnacked(ctx context.Context, tasks []*domain.Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), releaseTimeout)
+	defer cancel()
+
+	queues := make(map[string]*domain.Queue)
+	var counted, uncounted []*domain.Task
+
+	for _, task := range tasks {
+		queue, ok := queues[task.QueueName]
+		if !ok {
+			var err error
+			if queue, err = s.getQueue(ctx, task.QueueName); err != nil {
+				return err
+			}
+			queues[task.QueueName] = queue
+		}
+
+		if queue.DisconnectCountsAttempt {
+			counted = append(counted, task)
+		} else {
+			uncounted = append(uncounted, task)
+		}
+	}
+
+	if err := s.release(ctx, uncounted, false); err != nil {
+		return err
+	}
+
+	return s.release(ctx, counted, true)
+}
+
+func (s *Service) release(ctx context.Context, tasks []*domain.Task, countAttempts bool) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	count, err := s.taskRepository.ReleaseBatch(ctx, tasks, countAttempts)
+	if err != nil {
+		return fmt.Errorf("release tasks: %w", err)
+	}
+
+	if count > 0 {
+		notified := make(map[string]struct{})
+		for _, task := range tasks {
+			if _, ok := notified[task.QueueName]; !ok {
+				notified[task.QueueName] = struct{}{}
+				s.notifier.Notify(task.QueueName)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) releaseUndelivered(ctx context.Context, tasks []*domain.Task) {
+	if err := s.Release(ctx, tasks); err != nil {
+		s.logger.Log(log.LevelError).
+			With("message", "release undelivered tasks error").
+			With("error", err.Error()).
+			Write()
+	}
+}
+
+// PopWait pops like Pop, but if the queue is empty it waits up to wait for tasks to become available.
+// It returns no tasks and no error if the context is done while waiting.
+func (s *Service) PopWait(ctx context.Context, queueName string, max int, wait time.Duration) ([]*domain.Task, error) {
+	deadline := s.clock.After(wait)
+
+	for {
+		signal := s.notifier.Wait(queueName)
+
+		tasks, err := s.Pop(ctx, queueName, max)
+		if err != nil || len(tasks) > 0 {
+			return tasks, err
+		}
+
+		if !s.waitAvailable(ctx, signal, deadline) {
+			return nil, nil
+		}
+	}
+}
+
+// waitAvailable waits for a wake-up signal or the poll interval.
+// It returns false if the context is done or the deadline is reached.
+func (s *Service) waitAvailable(ctx context.Context, signal <-chan struct{}, deadline <-chan time.Time) bool {
+	poll := s.clock.After(waitPollInterval)
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-deadline:
+		return false
+	case <-signal:
+		return true
+	case <-poll:
+		return true
+	}
+}
+
+// PurgeExpired deletes tasks older than their queue's retention.
+func (s *Service) PurgeExpired(ctx context.Context) (int64, error) {
+	count, err := s.taskRepository.DeleteExpired(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("delete expired tasks: %w", err)
+	}
+
+	return count, nil
+}
+
+// PurgeExpiredIdempotencyKeys deletes idempotency keys older than their retention.
+func (s *Service) PurgeExpiredIdempotencyKeys(ctx context.Context) (int64, error) {
+	count, err := s.idempotencyKeyCache.DeleteExpired(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("delete expired idempotency keys: %w", err)
+	}
+
+	return count, nil
+}
+
+// RunRetention purges expired tasks, idempotency keys and stale throughput counters
+// every interval until the context is cancelled.
+func (s *Service) RunRetention(ctx context.Context, interval time.Duration) {
+	for wait(ctx, interval) {
+		s.runPurge(ctx, "expired tasks", s.PurgeExpired)
+		s.runPurge(ctx, "expired idempotency keys", s.PurgeExpiredIdempotencyKeys)
+		s.runPurge(ctx, "stale throughput counters", s.PurgeStaleThroughput)
+	}
+}
+
+func (s *Service) runPurge(ctx context.Context, what string, purge func(ctx context.Context) (int64, error)) {
+	count, err := purge(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			s.logger.Log(log.LevelError).
+				With("message", "purge "+what+" error").
+				With("error", err.Error()).
+				Write()
+		}
+		return
+	}
+
+	if count > 0 {
+		s.logger.Log(log.LevelDebug).
+			With("message", what+" purged").
+			With("count", strconv.FormatInt(count, 10)).
+			Write()
+	}
+}
+
+func (s *Service) ListDead(ctx context.Context, queueName string, limit int) ([]*domain.Task, error) {
+	tasks, err := s.taskRepository.GetDead(ctx, queueName, limit)
+	if err != nil {
+		return nil, fmt.Errorf("get dead tasks: %w", err)
+	}
+
+	return tasks, nil
+}
+
+// RedriveDead moves all dead tasks of the queue back to pending with reset attempts.
+func (s *Service) RedriveDead(ctx context.Context, queueName string) (int64, error) {
+	count, err := s.taskRepository.RedriveDead(ctx, queueName)
+	if err != nil {
+		return 0, fmt.Errorf("redrive dead tasks: %w", err)
+	}
+
+	if count > 0 {
+		s.notifier.Notify(queueName)
+	}
+
+	return count, nil
+}
+
+func (s *Service) PurgeDead(ctx context.Context, queueName string) (int64, error) {
+	count, err := s.taskRepository.DeleteDead(ctx, queueName)
+	if err != nil {
+		return 0, fmt.Errorf("delete dead tasks: %w", err)
+	}
+
+	return count, nil
+}
+
+// Subscribe delivers the tasks of the queue until the context is done. A task is taken into processing
+// only after acquire has returned true, so a subscriber holds no locks on tasks it can not receive yet.
+// Acquire waits for the subscriber to be ready and returns false if the context is done first.
+func (s *Service) Subscribe(
+	ctx context.Context,
+	queueName string,
+	acquire func(ctx context.Context) bool,
+) (<-chan *domain.Task, error) {
+	if queueName == "" {
+		return nil, errors.New("empty queue name")
+	}
+
+	tasks := make(chan *domain.Task)
+	go s.subscribe(ctx, queueName, acquire, tasks)
+
+	return tasks, nil
+}
+
+func (s *Service) subscribe(
+	ctx context.Context,
+	queueName string,
+	acquire func(ctx context.Context) bool,
+	tasks chan<- *domain.Task,
+) {
+	defer close(tasks)
+
+	for {
+		if !acquire(ctx) {
+			return
+		}
+
+		task, ok := s.popNext(ctx, queueName)
+		if !ok {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			s.releaseUndelivered(ctx, []*domain.Task{task})
+			return
+		case tasks <- task:
+		}
+	}
+}
+
+// popNext takes the next task of the queue into processing, waiting for one while the queue is empty.
+// It returns false if the context is done.
+func (s *Service) popNext(ctx context.Context, queueName string) (*domain.Task, bool) {
+	for {
+		signal := s.notifier.Wait(queueName)
+
+		popped, err := s.Pop(ctx, queueName, 1)
+		if ctx.Err() != nil {
+			s.releaseUndelivered(ctx, popped)
+			return nil, false
+		}
+
+		if err != nil {
+			s.logger.Log(log.LevelError).
+				With("message", "subscription pop error").
+				With("error", err.Error()).
+				With("queue_name", queueName).
+				Write()
+		}
+
+		if len(popped) > 0 {
+			return popped[0], true
+		}
+
+		if !s.waitAvailable(ctx, signal, nil) {
+			return nil, false
+		}
+	}
+}
+
+func wait(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
 }
@@ -42,6 +42,20 @@ func (m *Mockclock) EXPECT() *MockclockMockRecorder {
 	return m.recorder
 }
 
+// After mocks base method.
+func (m *Mockclock) After(d time.Duration) <-chan time.Time {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "After", d)
+	ret0, _ := ret[0].(<-chan time.Time)
+	return ret0
+}
+
+// After indicates an expected call of After.
+func (mr *MockclockMockRecorder) After(d any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "After", reflect.TypeOf((*Mockclock)(nil).After), d)
+}
+
 // Now mocks base method.
 func (m *Mockclock) Now() time.Time {
 	m.ctrl.T.Helper()
@@ -56,6 +70,44 @@ func (mr *MockclockMockRecorder) Now() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Now", reflect.TypeOf((*Mockclock)(nil).Now))
 }
 
+// MockidGenerator is a mock of idGenerator interface.
+type MockidGenerator struct {
+	ctrl     *gomock.Controller
+	recorder *MockidGeneratorMockRecorder
+	isgomock struct{}
+}
+
+// MockidGeneratorMockRecorder is the mock recorder for MockidGenerator.
+type MockidGeneratorMockRecorder struct {
+	mock *MockidGenerator
+}
+
+// NewMockidGenerator creates a new mock instance.
+func NewMockidGenerator(ctrl *gomock.Controller) *MockidGenerator {
+	mock := &MockidGenerator{ctrl: ctrl}
+	mock.recorder = &MockidGeneratorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockidGenerator) EXPECT() *MockidGeneratorMockRecorder {
+	return m.recorder
+}
+
+// NewID mocks base method.
+func (m *MockidGenerator) NewID() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NewID")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// NewID indicates an expected call of NewID.
+func (mr *MockidGeneratorMockRecorder) NewID() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewID", reflect.TypeOf((*MockidGenerator)(nil).NewID))
+}
+
 // MockidempotencyKeyCache is a mock of idempotencyKeyCache interface.
 type MockidempotencyKeyCache struct {
 	ctrl     *gomock.Controller
@@ -80,31 +132,192 @@ func (m *MockidempotencyKeyCache) EXPECT() *MockidempotencyKeyCacheMockRecorder
 	return m.recorder
 }
 
+// DeleteExpired mocks base method.
+func (m *MockidempotencyKeyCache) DeleteExpired(ctx context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpired", ctx)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpired indicates an expected call of DeleteExpired.
+func (mr *MockidempotencyKeyCacheMockRecorder) DeleteExpired(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpired", reflect.TypeOf((*MockidempotencyKeyCache)(nil).DeleteExpired), ctx)
+}
+
 // GetQueuePush mocks base method.
-func (m *MockidempotencyKeyCache) GetQueuePush(key string) (*domain.Task, bool) {
+func (m *MockidempotencyKeyCache) GetQueuePush(ctx context.Context, queueName, key string) (*domain.PushRecord, bool, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetQueuePush", key)
-	ret0, _ := ret[0].(*domain.Task)
+	ret := m.ctrl.Call(m, "GetQueuePush", ctx, queueName, key)
+	ret0, _ := ret[0].(*domain.PushRecord)
 	ret1, _ := ret[1].(bool)
-	return ret0, ret1
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
 }
 
 // GetQueuePush indicates an expected call of GetQueuePush.
-func (mr *MockidempotencyKeyCacheMockRecorder) GetQueuePush(key any) *gomock.Call {
+func (mr *MockidempotencyKeyCacheMockRecorder) GetQueuePush(ctx, queueName, key any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetQueuePush", reflect.TypeOf((*MockidempotencyKeyCache)(nil).GetQueuePush), key)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetQueuePush", reflect.TypeOf((*MockidempotencyKeyCache)(nil).GetQueuePush), ctx, queueName, key)
 }
 
 // SetQueuePush mocks base method.
-func (m *MockidempotencyKeyCache) SetQueuePush(key string, result *domain.Task) {
+func (m *MockidempotencyKeyCache) SetQueuePush(ctx context.Context, queueName, key string, record *domain.PushRecord) error {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "SetQueuePush", key, result)
+	ret := m.ctrl.Call(m, "SetQueuePush", ctx, queueName, key, record)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
 // SetQueuePush indicates an expected call of SetQueuePush.
-func (mr *MockidempotencyKeyCacheMockRecorder) SetQueuePush(key, result any) *gomock.Call {
+func (mr *MockidempotencyKeyCacheMockRecorder) SetQueuePush(ctx, queueName, key, record any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetQueuePush", reflect.TypeOf((*MockidempotencyKeyCache)(nil).SetQueuePush), ctx, queueName, key, record)
+}
+
+// Transactional mocks base method.
+func (m *MockidempotencyKeyCache) Transactional() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Transactional")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// Transactional indicates an expected call of Transactional.
+func (mr *MockidempotencyKeyCacheMockRecorder) Transactional() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Transactional", reflect.TypeOf((*MockidempotencyKeyCache)(nil).Transactional))
+}
+
+// Mocknotifier is a mock of notifier interface.
+type Mocknotifier struct {
+	ctrl     *gomock.Controller
+	recorder *MocknotifierMockRecorder
+	isgomock struct{}
+}
+
+// MocknotifierMockRecorder is the mock recorder for Mocknotifier.
+type MocknotifierMockRecorder struct {
+	mock *Mocknotifier
+}
+
+// NewMocknotifier creates a new mock instance.
+func NewMocknotifier(ctrl *gomock.Controller) *Mocknotifier {
+	mock := &Mocknotifier{ctrl: ctrl}
+	mock.recorder = &MocknotifierMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *Mocknotifier) EXPECT() *MocknotifierMockRecorder {
+	return m.recorder
+}
+
+// Notify mocks base method.
+func (m *Mocknotifier) Notify(key string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Notify", key)
+}
+
+// Notify indicates an expected call of Notify.
+func (mr *MocknotifierMockRecorder) Notify(key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Notify", reflect.TypeOf((*Mocknotifier)(nil).Notify), key)
+}
+
+// NotifyAt mocks base method.
+func (m *Mocknotifier) NotifyAt(key string, at time.Time) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "NotifyAt", key, at)
+}
+
+// NotifyAt indicates an expected call of NotifyAt.
+func (mr *MocknotifierMockRecorder) NotifyAt(key, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NotifyAt", reflect.TypeOf((*Mocknotifier)(nil).NotifyAt), key, at)
+}
+
+// Wait mocks base method.
+func (m *Mocknotifier) Wait(key string) <-chan struct{} {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Wait", key)
+	ret0, _ := ret[0].(<-chan struct{})
+	return ret0
+}
+
+// Wait indicates an expected call of Wait.
+func (mr *MocknotifierMockRecorder) Wait(key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Wait", reflect.TypeOf((*Mocknotifier)(nil).Wait), key)
+}
+
+// MockqueueRepository is a mock of queueRepository interface.
+type MockqueueRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockqueueRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockqueueRepositoryMockRecorder is the mock recorder for MockqueueRepository.
+type MockqueueRepositoryMockRecorder struct {
+	mock *MockqueueRepository
+}
+
+// NewMockqueueRepository creates a new mock instance.
+func NewMockqueueRepository(ctrl *gomock.Controller) *MockqueueRepository {
+	mock := &MockqueueRepository{ctrl: ctrl}
+	mock.recorder = &MockqueueRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockqueueRepository) EXPECT() *MockqueueRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Delete mocks base method.
+func (m *MockqueueRepository) Delete(ctx context.Context, name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockqueueRepositoryMockRecorder) Delete(ctx, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockqueueRepository)(nil).Delete), ctx, name)
+}
+
+// Get mocks base method.
+func (m *MockqueueRepository) Get(ctx context.Context, name string) (*domain.Queue, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, name)
+	ret0, _ := ret[0].(*domain.Queue)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockqueueRepositoryMockRecorder) Get(ctx, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockqueueRepository)(nil).Get), ctx, name)
+}
+
+// Save mocks base method.
+func (m *MockqueueRepository) Save(ctx context.Context, queue *domain.Queue) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Save", ctx, queue)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Save indicates an expected call of Save.
+func (mr *MockqueueRepositoryMockRecorder) Save(ctx, queue any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetQueuePush", reflect.TypeOf((*MockidempotencyKeyCache)(nil).SetQueuePush), key, result)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockqueueRepository)(nil).Save), ctx, queue)
 }
 
 // MocktaskRepository is a mock of taskRepository interface.
@@ -131,19 +344,168 @@ func (m *MocktaskRepository) EXPECT() *MocktaskRepositoryMockRecorder {
 	return m.recorder
 }
 
-// GetFirstPending mocks base method.
-func (m *MocktaskRepository) GetFirstPending(ctx context.Context, queueName string) (*domain.Task, error) {
+// DeleteDead mocks base method.
+func (m *MocktaskRepository) DeleteDead(ctx context.Context, queueName string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteDead", ctx, queueName)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteDead indicates an expected call of DeleteDead.
+func (mr *MocktaskRepositoryMockRecorder) DeleteDead(ctx, queueName any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteDead", reflect.TypeOf((*MocktaskRepository)(nil).DeleteDead), ctx, queueName)
+}
+
+// DeleteExpired mocks base method.
+func (m *MocktaskRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpired", ctx)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpired indicates an expected call of DeleteExpired.
+func (mr *MocktaskRepositoryMockRecorder) DeleteExpired(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpired", reflect.TypeOf((*MocktaskRepository)(nil).DeleteExpired), ctx)
+}
+
+// DeleteStaleThroughput mocks base method.
+func (m *MocktaskRepository) DeleteStaleThroughput(ctx context.Context, period time.Duration) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteStaleThroughput", ctx, period)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteStaleThroughput indicates an expected call of DeleteStaleThroughput.
+func (mr *MocktaskRepositoryMockRecorder) DeleteStaleThroughput(ctx, period any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteStaleThroughput", reflect.TypeOf((*MocktaskRepository)(nil).DeleteStaleThroughput), ctx, period)
+}
+
+// GetDead mocks base method.
+func (m *MocktaskRepository) GetDead(ctx context.Context, queueName string, limit int) ([]*domain.Task, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDead", ctx, queueName, limit)
+	ret0, _ := ret[0].([]*domain.Task)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDead indicates an expected call of GetDead.
+func (mr *MocktaskRepositoryMockRecorder) GetDead(ctx, queueName, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDead", reflect.TypeOf((*MocktaskRepository)(nil).GetDead), ctx, queueName, limit)
+}
+
+// GetPending mocks base method.
+func (m *MocktaskRepository) GetPending(ctx context.Context, queueName string, limit int) ([]*domain.Task, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPending", ctx, queueName, limit)
+	ret0, _ := ret[0].([]*domain.Task)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPending indicates an expected call of GetPending.
+func (mr *MocktaskRepositoryMockRecorder) GetPending(ctx, queueName, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPending", reflect.TypeOf((*MocktaskRepository)(nil).GetPending), ctx, queueName, limit)
+}
+
+// GetStats mocks base method.
+func (m *MocktaskRepository) GetStats(ctx context.Context, queueName string) (*domain.QueueStats, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetFirstPending", ctx, queueName)
-	ret0, _ := ret[0].(*domain.Task)
+	ret := m.ctrl.Call(m, "GetStats", ctx, queueName)
+	ret0, _ := ret[0].(*domain.QueueStats)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetFirstPending indicates an expected call of GetFirstPending.
-func (mr *MocktaskRepositoryMockRecorder) GetFirstPending(ctx, queueName any) *gomock.Call {
+// GetStats indicates an expected call of GetStats.
+func (mr *MocktaskRepositoryMockRecorder) GetStats(ctx, queueName any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStats", reflect.TypeOf((*MocktaskRepository)(nil).GetStats), ctx, queueName)
+}
+
+// GetThroughput mocks base method.
+func (m *MocktaskRepository) GetThroughput(ctx context.Context, queueName string, periods []time.Duration) ([]*domain.QueueThroughput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetThroughput", ctx, queueName, periods)
+	ret0, _ := ret[0].([]*domain.QueueThroughput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetThroughput indicates an expected call of GetThroughput.
+func (mr *MocktaskRepositoryMockRecorder) GetThroughput(ctx, queueName, periods any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetThroughput", reflect.TypeOf((*MocktaskRepository)(nil).GetThroughput), ctx, queueName, periods)
+}
+
+// InsertBatch mocks base method.
+func (m *MocktaskRepository) InsertBatch(ctx context.Context, tasks []*domain.Task) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertBatch", ctx, tasks)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InsertBatch indicates an expected call of InsertBatch.
+func (mr *MocktaskRepositoryMockRecorder) InsertBatch(ctx, tasks any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFirstPending", reflect.TypeOf((*MocktaskRepository)(nil).GetFirstPending), ctx, queueName)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertBatch", reflect.TypeOf((*MocktaskRepository)(nil).InsertBatch), ctx, tasks)
+}
+
+// ListStats mocks base method.
+func (m *MocktaskRepository) ListStats(ctx context.Context) ([]*domain.QueueStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListStats", ctx)
+	ret0, _ := ret[0].([]*domain.QueueStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListStats indicates an expected call of ListStats.
+func (mr *MocktaskRepositoryMockRecorder) ListStats(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListStats", reflect.TypeOf((*MocktaskRepository)(nil).ListStats), ctx)
+}
+
+// RedriveDead mocks base method.
+func (m *MocktaskRepository) RedriveDead(ctx context.Context, queueName string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RedriveDead", ctx, queueName)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RedriveDead indicates an expected call of RedriveDead.
+func (mr *MocktaskRepositoryMockRecorder) RedriveDead(ctx, queueName any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RedriveDead", reflect.TypeOf((*MocktaskRepository)(nil).RedriveDead), ctx, queueName)
+}
+
+// ReleaseBatch mocks base method.
+func (m *MocktaskRepository) ReleaseBatch(ctx context.Context, tasks []*domain.Task, countAttempts bool) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseBatch", ctx, tasks, countAttempts)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReleaseBatch indicates an expected call of ReleaseBatch.
+func (mr *MocktaskRepositoryMockRecorder) ReleaseBatch(ctx, tasks, countAttempts any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseBatch", reflect.TypeOf((*MocktaskRepository)(nil).ReleaseBatch), ctx, tasks, countAttempts)
 }
 
 // Save mocks base method.
@@ -159,3 +521,17 @@ func (mr *MocktaskRepositoryMockRecorder) Save(ctx, task any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MocktaskRepository)(nil).Save), ctx, task)
 }
+
+// UpdateBatch mocks base method.
+func (m *MocktaskRepository) UpdateBatch(ctx context.Context, tasks []*domain.Task) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateBatch", ctx, tasks)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateBatch indicates an expected call of UpdateBatch.
+func (mr *MocktaskRepositoryMockRecorder) UpdateBatch(ctx, tasks any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateBatch", reflect.TypeOf((*MocktaskRepository)(nil).UpdateBatch), ctx, tasks)
+}
@@ -14,6 +14,8 @@ import (
 	"github.com/art-es/queue-service/internal/app/repository"
 	"github.com/art-es/queue-service/internal/infra/log"
 	"github.com/art-es/queue-service/internal/infra/log/logimpl"
+	"github.com/art-es/queue-service/internal/infra/metrics/metricsimpl"
+	"github.com/art-es/queue-service/internal/infra/notify"
 	"github.com/art-es/queue-service/internal/infra/ops"
 	"github.com/art-es/queue-service/internal/infra/trx"
 )
@@ -32,7 +34,10 @@ func TestService_Push(t *testing.T) {
 	)
 
 	type testDeps struct {
+		mockClock               *Mockclock
 		mockIdempotencyKeyCache *MockidempotencyKeyCache
+		mockNotifier            *Mocknotifier
+		mockQueueRepository     *MockqueueRepository
 		mockTaskRepository      *MocktaskRepository
 		service                 *Service
 	}
@@ -57,11 +62,18 @@ func TestService_Push(t *testing.T) {
 					Status:    domain.TaskStatusPending,
 				}
 
+				d.mockQueueRepository.EXPECT().
+					Get(gomock.Any(), gomock.Eq(queueName)).
+					Return(nil, repository.ErrNotFound)
+
 				d.mockTaskRepository.EXPECT().
 					Save(gomock.Any(), gomock.Eq(expTaskBeforeSave)).
 					Do(mockTaskSave).
 					Return(nil)
 
+				d.mockNotifier.EXPECT().
+					Notify(gomock.Eq(queueName))
+
 				task, err := d.service.Push(ctx, &PushRequest{
 					QueueName: queueName,
 					Payload:   payload,
@@ -71,6 +83,76 @@ func TestService_Push(t *testing.T) {
 				assert.Equal(t, expTaskAfterSave, task)
 			},
 		},
+		{
+			name: "push delayed task",
+			run: func(t *testing.T, d testDeps) {
+				expTaskBeforeSave := &domain.Task{
+					QueueName:   queueName,
+					Payload:     payload,
+					Status:      domain.TaskStatusPending,
+					LockedUntil: ops.Pointer(getTime(t, "2006-01-02 15:05:05")),
+				}
+
+				d.mockClock.EXPECT().
+					Now().
+					Return(getTime(t, "2006-01-02 15:04:05"))
+
+				d.mockQueueRepository.EXPECT().
+					Get(gomock.Any(), gomock.Eq(queueName)).
+					Return(nil, repository.ErrNotFound)
+
+				d.mockTaskRepository.EXPECT().
+					Save(gomock.Any(), gomock.Eq(expTaskBeforeSave)).
+					Return(nil)
+
+				d.mockNotifier.EXPECT().
+					NotifyAt(gomock.Eq(queueName), gomock.Eq(*expTaskBeforeSave.LockedUntil))
+
+				task, err := d.service.Push(ctx, &PushRequest{
+					QueueName: queueName,
+					Payload:   payload,
+					Delay:     ops.Pointer(time.Minute),
+				})
+
+				assert.NoError(t, err)
+				assert.Equal(t, expTaskBeforeSave, task)
+			},
+		},
+		{
+			name: "push scheduled task",
+			run: func(t *testing.T, d testDeps) {
+				notBefore := getTime(t, "2006-01-03 09:00:00")
+
+				expTaskBeforeSave := &domain.Task{
+					QueueName:   queueName,
+					Payload:     payload,
+					Status:      domain.TaskStatusPending,
+					LockedUntil: &notBefore,
+					Priority:    10,
+				}
+
+				d.mockQueueRepository.EXPECT().
+					Get(gomock.Any(), gomock.Eq(queueName)).
+					Return(nil, repository.ErrNotFound)
+
+				d.mockTaskRepository.EXPECT().
+					Save(gomock.Any(), gomock.Eq(expTaskBeforeSave)).
+					Return(nil)
+
+				d.mockNotifier.EXPECT().
+					NotifyAt(gomock.Eq(queueName), gomock.Eq(*expTaskBeforeSave.LockedUntil))
+
+				task, err := d.service.Push(ctx, &PushRequest{
+					QueueName: queueName,
+					Payload:   payload,
+					NotBefore: &notBefore,
+					Priority:  10,
+				})
+
+				assert.NoError(t, err)
+				assert.Equal(t, expTaskBeforeSave, task)
+			},
+		},
 		{
 			name: "push new task with idempotency key",
 			run: func(t *testing.T, d testDeps) {
@@ -88,16 +170,34 @@ func TestService_Push(t *testing.T) {
 				}
 
 				d.mockIdempotencyKeyCache.EXPECT().
-					GetQueuePush(gomock.Eq(idempotencyKey)).
-					Return(nil, false)
+					GetQueuePush(gomock.Any(), gomock.Eq(queueName), gomock.Eq(idempotencyKey)).
+					Return(nil, false, nil)
+
+				d.mockQueueRepository.EXPECT().
+					Get(gomock.Any(), gomock.Eq(queueName)).
+					Return(nil, repository.ErrNotFound)
+
+				d.mockIdempotencyKeyCache.EXPECT().
+					Transactional().
+					Return(true)
 
 				d.mockTaskRepository.EXPECT().
 					Save(gomock.Any(), gomock.Eq(expTaskBeforeSave)).
 					Do(mockTaskSave).
 					Return(nil)
 
+				d.mockNotifier.EXPECT().
+					Notify(gomock.Eq(queueName))
+
 				d.mockIdempotencyKeyCache.EXPECT().
-					SetQueuePush(gomock.Eq(idempotencyKey), gomock.Eq(expTaskAfterSave))
+					SetQueuePush(gomock.Any(), gomock.Eq(queueName), gomock.Eq(idempotencyKey), gomock.Eq(&domain.PushRecord{
+						Fingerprint: domain.PushFingerprint(queueName, payload),
+						Task:        expTaskAfterSave,
+					})).
+					Do(func(ctx context.Context, _, _ string, _ *domain.PushRecord) {
+						assert.True(t, trx.Exists(ctx), "transaction exists")
+					}).
+					Return(nil)
 
 				task, err := d.service.Push(ctx, &PushRequest{
 					QueueName:      queueName,
@@ -120,8 +220,8 @@ func TestService_Push(t *testing.T) {
 				}
 
 				d.mockIdempotencyKeyCache.EXPECT().
-					GetQueuePush(gomock.Eq(idempotencyKey)).
-					Return(cachedTask, true)
+					GetQueuePush(gomock.Any(), gomock.Eq(queueName), gomock.Eq(idempotencyKey)).
+					Return(&domain.PushRecord{Fingerprint: domain.PushFingerprint(queueName, payload), Task: cachedTask}, true, nil)
 
 				task, err := d.service.Push(ctx, &PushRequest{
 					QueueName:      queueName,
@@ -133,6 +233,198 @@ func TestService_Push(t *testing.T) {
 				assert.Equal(t, cachedTask, task)
 			},
 		},
+		{
+			name: "idempotency key reused with a different payload",
+			run: func(t *testing.T, d testDeps) {
+				cachedTask := &domain.Task{
+					ID:        taskID,
+					QueueName: queueName,
+					Payload:   "otherPayload",
+					Status:    domain.TaskStatusPending,
+				}
+
+				d.mockIdempotencyKeyCache.EXPECT().
+					GetQueuePush(gomock.Any(), gomock.Eq(queueName), gomock.Eq(idempotencyKey)).
+					Return(&domain.PushRecord{Fingerprint: domain.PushFingerprint(queueName, "otherPayload"), Task: cachedTask}, true, nil)
+
+				task, err := d.service.Push(ctx, &PushRequest{
+					QueueName:      queueName,
+					Payload:        payload,
+					IdempotencyKey: ops.Pointer(idempotencyKey),
+				})
+
+				assert.ErrorIs(t, err, domain.ErrIdempotencyKeyReused)
+				assert.Nil(t, task)
+			},
+		},
+		{
+			name: "idempotency key stored by a concurrent push",
+			run: func(t *testing.T, d testDeps) {
+				storedTask := &domain.Task{
+					ID:        "storedTaskID",
+					QueueName: queueName,
+					Payload:   payload,
+					Status:    domain.TaskStatusPending,
+				}
+
+				gomock.InOrder(
+					d.mockIdempotencyKeyCache.EXPECT().
+						GetQueuePush(gomock.Any(), gomock.Eq(queueName), gomock.Eq(idempotencyKey)).
+						Return(nil, false, nil),
+					d.mockIdempotencyKeyCache.EXPECT().
+						GetQueuePush(gomock.Any(), gomock.Eq(queueName), gomock.Eq(idempotencyKey)).
+						Return(&domain.PushRecord{Fingerprint: domain.PushFingerprint(queueName, payload), Task: storedTask}, true, nil),
+				)
+
+				d.mockQueueRepository.EXPECT().
+					Get(gomock.Any(), gomock.Eq(queueName)).
+					Return(nil, repository.ErrNotFound)
+
+				d.mockIdempotencyKeyCache.EXPECT().
+					Transactional().
+					Return(true)
+
+				d.mockTaskRepository.EXPECT().
+					Save(gomock.Any(), gomock.Any()).
+					Do(mockTaskSave).
+					Return(nil)
+
+				d.mockIdempotencyKeyCache.EXPECT().
+					SetQueuePush(gomock.Any(), gomock.Eq(queueName), gomock.Eq(idempotencyKey), gomock.Any()).
+					Return(repository.ErrAlreadyExists)
+
+				task, err := d.service.Push(ctx, &PushRequest{
+					QueueName:      queueName,
+					Payload:        payload,
+					IdempotencyKey: ops.Pointer(idempotencyKey),
+				})
+
+				assert.NoError(t, err)
+				assert.Equal(t, storedTask, task)
+			},
+		},
+		{
+			name: "idempotency key set after commit",
+			run: func(t *testing.T, d testDeps) {
+				d.mockIdempotencyKeyCache.EXPECT().
+					GetQueuePush(gomock.Any(), gomock.Eq(queueName), gomock.Eq(idempotencyKey)).
+					Return(nil, false, nil)
+
+				d.mockIdempotencyKeyCache.EXPECT().
+					Transactional().
+					Return(false)
+
+				d.mockQueueRepository.EXPECT().
+					Get(gomock.Any(), gomock.Eq(queueName)).
+					Return(nil, repository.ErrNotFound)
+
+				gomock.InOrder(
+					d.mockTaskRepository.EXPECT().
+						Save(gomock.Any(), gomock.Any()).
+						Do(mockTaskSave).
+						Return(nil),
+					d.mockIdempotencyKeyCache.EXPECT().
+						SetQueuePush(gomock.Any(), gomock.Eq(queueName), gomock.Eq(idempotencyKey), gomock.Any()).
+						Do(func(ctx context.Context, _, _ string, _ *domain.PushRecord) {
+							assert.False(t, trx.Exists(ctx), "transaction is committed")
+						}).
+						Return(nil),
+				)
+
+				d.mockNotifier.EXPECT().
+					Notify(gomock.Eq(queueName))
+
+				task, err := d.service.Push(ctx, &PushRequest{
+					QueueName:      queueName,
+					Payload:        payload,
+					IdempotencyKey: ops.Pointer(idempotencyKey),
+				})
+
+				assert.NoError(t, err)
+				assert.Equal(t, taskID, task.ID)
+			},
+		},
+		{
+			name: "idempotency key is not set after rollback",
+			run: func(t *testing.T, d testDeps) {
+				d.mockIdempotencyKeyCache.EXPECT().
+					GetQueuePush(gomock.Any(), gomock.Eq(queueName), gomock.Eq(idempotencyKey)).
+					Return(nil, false, nil)
+
+				d.mockIdempotencyKeyCache.EXPECT().
+					Transactional().
+					Return(false)
+
+				d.mockQueueRepository.EXPECT().
+					Get(gomock.Any(), gomock.Eq(queueName)).
+					Return(nil, repository.ErrNotFound)
+
+				d.mockTaskRepository.EXPECT().
+					Save(gomock.Any(), gomock.Any()).
+					Return(errors.New("test error"))
+
+				task, err := d.service.Push(ctx, &PushRequest{
+					QueueName:      queueName,
+					Payload:        payload,
+					IdempotencyKey: ops.Pointer(idempotencyKey),
+				})
+
+				assert.EqualError(t, err, "save task: test error")
+				assert.Nil(t, task)
+			},
+		},
+		{
+			name: "get idempotency key error",
+			run: func(t *testing.T, d testDeps) {
+				d.mockIdempotencyKeyCache.EXPECT().
+					GetQueuePush(gomock.Any(), gomock.Eq(queueName), gomock.Eq(idempotencyKey)).
+					Return(nil, false, errors.New("test error"))
+
+				task, err := d.service.Push(ctx, &PushRequest{
+					QueueName:      queueName,
+					Payload:        payload,
+					IdempotencyKey: ops.Pointer(idempotencyKey),
+				})
+
+				assert.EqualError(t, err, "get idempotency key: test error")
+				assert.Nil(t, task)
+			},
+		},
+		{
+			name: "payload too large",
+			run: func(t *testing.T, d testDeps) {
+				queue := domain.NewQueue(queueName)
+				queue.MaxPayloadSize = len(payload) - 1
+
+				d.mockQueueRepository.EXPECT().
+					Get(gomock.Any(), gomock.Eq(queueName)).
+					Return(queue, nil)
+
+				task, err := d.service.Push(ctx, &PushRequest{
+					QueueName: queueName,
+					Payload:   payload,
+				})
+
+				assert.ErrorIs(t, err, domain.ErrPayloadTooLarge)
+				assert.Nil(t, task)
+			},
+		},
+		{
+			name: "get queue error",
+			run: func(t *testing.T, d testDeps) {
+				d.mockQueueRepository.EXPECT().
+					Get(gomock.Any(), gomock.Eq(queueName)).
+					Return(nil, errors.New("test error"))
+
+				task, err := d.service.Push(ctx, &PushRequest{
+					QueueName: queueName,
+					Payload:   payload,
+				})
+
+				assert.EqualError(t, err, "get queue: test error")
+				assert.Nil(t, task)
+			},
+		},
 		{
 			name: "task save error",
 			run: func(t *testing.T, d testDeps) {
@@ -142,6 +434,10 @@ func TestService_Push(t *testing.T) {
 					Status:    domain.TaskStatusPending,
 				}
 
+				d.mockQueueRepository.EXPECT().
+					Get(gomock.Any(), gomock.Eq(queueName)).
+					Return(nil, repository.ErrNotFound)
+
 				d.mockTaskRepository.EXPECT().
 					Save(gomock.Any(), gomock.Eq(expTask)).
 					Do(mockTaskSave).
@@ -161,14 +457,29 @@ func TestService_Push(t *testing.T) {
 			mc := gomock.NewController(t)
 			defer mc.Finish()
 
+			mockClock := NewMockclock(mc)
 			mockIdempotencyKeyCache := NewMockidempotencyKeyCache(mc)
+			mockNotifier := NewMocknotifier(mc)
+			mockQueueRepository := NewMockqueueRepository(mc)
 			mockTaskRepository := NewMocktaskRepository(mc)
 			logger, _ := logimpl.NewTestLogger()
 
 			tc.run(t, testDeps{
+				mockClock:               mockClock,
 				mockIdempotencyKeyCache: mockIdempotencyKeyCache,
+				mockNotifier:            mockNotifier,
+				mockQueueRepository:     mockQueueRepository,
 				mockTaskRepository:      mockTaskRepository,
-				service:                 NewService(nil, mockIdempotencyKeyCache, mockTaskRepository, logger),
+				service: NewService(
+					mockClock,
+					nil,
+					mockIdempotencyKeyCache,
+					mockNotifier,
+					mockQueueRepository,
+					mockTaskRepository,
+					metricsimpl.NewRegistry(),
+					logger,
+				),
 			})
 		})
 	}
@@ -176,16 +487,26 @@ func TestService_Push(t *testing.T) {
 
 func TestService_Pop(t *testing.T) {
 	var (
-		ctx       = context.Background()
-		taskID    = "testTaskID"
-		queueName = "testQueueName"
+		ctx         = context.Background()
+		taskID      = "testTaskID"
+		queueName   = "testQueueName"
+		leaseToken  = "testLeaseToken"
+		maxAttempts = 3
+
+		queue = &domain.Queue{
+			Name:              queueName,
+			VisibilityTimeout: 5 * time.Minute,
+			MaxAttempts:       maxAttempts,
+		}
 	)
 
 	type testDeps struct {
-		mockClock          *Mockclock
-		mockTaskRepository *MocktaskRepository
-		logbuf             log.Buffer
-		service            *Service
+		mockClock           *Mockclock
+		mockIDGenerator     *MockidGenerator
+		mockQueueRepository *MockqueueRepository
+		mockTaskRepository  *MocktaskRepository
+		logbuf              log.Buffer
+		service             *Service
 	}
 
 	for _, tc := range []struct {
@@ -208,30 +529,120 @@ func TestService_Pop(t *testing.T) {
 					QueueName:   queueName,
 					Status:      domain.TaskStatusProcessing,
 					LockedUntil: ops.Pointer(getTime(t, "2006-01-02 15:09:05")),
+					LeaseToken:  leaseToken,
+					Attempts:    1,
 				}
 
 				d.mockClock.EXPECT().
 					Now().
 					Return(now)
 
+				d.mockQueueRepository.EXPECT().
+					Get(gomock.Any(), gomock.Eq(queueName)).
+					Return(queue, nil)
+
+				d.mockIDGenerator.EXPECT().
+					NewID().
+					Return(leaseToken)
+
 				d.mockTaskRepository.EXPECT().
-					GetFirstPending(gomock.Any(), gomock.Eq(queueName)).
-					Do(func(ctx context.Context, _ string) {
+					GetPending(gomock.Any(), gomock.Eq(queueName), gomock.Eq(1)).
+					Do(func(ctx context.Context, _ string, _ int) {
 						assert.True(t, trx.Exists(ctx), "transaction exists")
 					}).
-					Return(expTaskFromRepo, nil)
+					Return([]*domain.Task{expTaskFromRepo}, nil)
 
 				d.mockTaskRepository.EXPECT().
-					Save(gomock.Any(), gomock.Eq(expTaskAfterTransition)).
-					Do(func(ctx context.Context, _ *domain.Task) {
+					UpdateBatch(gomock.Any(), gomock.Eq([]*domain.Task{expTaskAfterTransition})).
+					Do(func(ctx context.Context, _ []*domain.Task) {
 						assert.True(t, trx.Exists(ctx), "transaction exists")
 					}).
 					Return(nil)
 
-				task, err := d.service.Pop(ctx, queueName)
+				tasks, err := d.service.Pop(ctx, queueName, 1)
+
+				assert.NoError(t, err)
+				assert.Equal(t, []*domain.Task{expTaskAfterTransition}, tasks)
+				assert.Empty(t, d.logbuf.Logs())
+			},
+		},
+		{
+			name: "pop tasks after dead-lettering exhausted task",
+			run: func(t *testing.T, d testDeps) {
+				now := getTime(t, "2006-01-02 15:04:05")
+
+				exhaustedTask := &domain.Task{
+					ID:          "exhaustedTaskID",
+					QueueName:   queueName,
+					Status:      domain.TaskStatusProcessing,
+					LockedUntil: ops.Pointer(getTime(t, "2006-01-02 15:00:00")),
+					LeaseToken:  "expiredLeaseToken",
+					Attempts:    maxAttempts,
+				}
+
+				expDeadTask := &domain.Task{
+					ID:        "exhaustedTaskID",
+					QueueName: queueName,
+					Status:    domain.TaskStatusDead,
+					Attempts:  maxAttempts,
+				}
+
+				expTasksAfterTransition := []*domain.Task{
+					{
+						ID:          "testTaskID1",
+						QueueName:   queueName,
+						Status:      domain.TaskStatusProcessing,
+						LockedUntil: ops.Pointer(getTime(t, "2006-01-02 15:09:05")),
+						LeaseToken:  "testLeaseToken1",
+						Attempts:    1,
+					},
+					{
+						ID:          "testTaskID2",
+						QueueName:   queueName,
+						Status:      domain.TaskStatusProcessing,
+						LockedUntil: ops.Pointer(getTime(t, "2006-01-02 15:09:05")),
+						LeaseToken:  "testLeaseToken2",
+						Attempts:    1,
+					},
+				}
+
+				d.mockClock.EXPECT().
+					Now().
+					Return(now)
+
+				d.mockQueueRepository.EXPECT().
+					Get(gomock.Any(), gomock.Eq(queueName)).
+					Return(queue, nil)
+
+				gomock.InOrder(
+					d.mockIDGenerator.EXPECT().NewID().Return("testLeaseToken1"),
+					d.mockIDGenerator.EXPECT().NewID().Return("testLeaseToken2"),
+				)
+
+				gomock.InOrder(
+					d.mockTaskRepository.EXPECT().
+						GetPending(gomock.Any(), gomock.Eq(queueName), gomock.Eq(2)).
+						Return([]*domain.Task{
+							exhaustedTask,
+							{ID: "testTaskID1", QueueName: queueName, Status: domain.TaskStatusPending},
+						}, nil),
+					d.mockTaskRepository.EXPECT().
+						UpdateBatch(gomock.Any(), gomock.Eq([]*domain.Task{expDeadTask, expTasksAfterTransition[0]})).
+						Return(nil),
+					d.mockTaskRepository.EXPECT().
+						GetPending(gomock.Any(), gomock.Eq(queueName), gomock.Eq(1)).
+						Return([]*domain.Task{
+							{ID: "testTaskID2", QueueName: queueName, Status: domain.TaskStatusPending},
+						}, nil),
+					d.mockTaskRepository.EXPECT().
+						UpdateBatch(gomock.Any(), gomock.Eq([]*domain.Task{expTasksAfterTransition[1]})).
+						Return(nil),
+				)
+
+				tasks, err := d.service.Pop(ctx, queueName, 2)
 
 				assert.NoError(t, err)
-				assert.Equal(t, expTaskAfterTransition, task)
+				assert.Equal(t, expTasksAfterTransition, tasks)
 				assert.Empty(t, d.logbuf.Logs())
 			},
 		},
@@ -251,22 +662,32 @@ func TestService_Pop(t *testing.T) {
 					QueueName:   queueName,
 					Status:      domain.TaskStatusProcessing,
 					LockedUntil: ops.Pointer(getTime(t, "2006-01-02 15:09:05")),
+					LeaseToken:  leaseToken,
+					Attempts:    1,
 				}
 
 				d.mockClock.EXPECT().
 					Now().
 					Return(now)
 
+				d.mockQueueRepository.EXPECT().
+					Get(gomock.Any(), gomock.Eq(queueName)).
+					Return(queue, nil)
+
+				d.mockIDGenerator.EXPECT().
+					NewID().
+					Return(leaseToken)
+
 				d.mockTaskRepository.EXPECT().
-					GetFirstPending(gomock.Any(), gomock.Eq(queueName)).
-					Do(func(ctx context.Context, _ string) {
+					GetPending(gomock.Any(), gomock.Eq(queueName), gomock.Eq(1)).
+					Do(func(ctx context.Context, _ string, _ int) {
 						assert.True(t, trx.Exists(ctx), "transaction exists")
 					}).
-					Return(expTaskFromRepo, nil)
+					Return([]*domain.Task{expTaskFromRepo}, nil)
 
 				d.mockTaskRepository.EXPECT().
-					Save(gomock.Any(), gomock.Eq(expTaskAfterTransition)).
-					Do(func(ctx context.Context, _ *domain.Task) {
+					UpdateBatch(gomock.Any(), gomock.Eq([]*domain.Task{expTaskAfterTransition})).
+					Do(func(ctx context.Context, _ []*domain.Task) {
 						assert.True(t, trx.Exists(ctx), "transaction exists")
 
 						trx.AddCommit(ctx, func() error {
@@ -275,15 +696,15 @@ func TestService_Pop(t *testing.T) {
 					}).
 					Return(nil)
 
-				task, err := d.service.Pop(ctx, queueName)
+				tasks, err := d.service.Pop(ctx, queueName, 1)
 
 				assert.EqualError(t, err, "commit trx: test error")
-				assert.Nil(t, task)
+				assert.Nil(t, tasks)
 				assert.Empty(t, d.logbuf.Logs())
 			},
 		},
 		{
-			name: "save task error",
+			name: "update tasks error",
 			run: func(t *testing.T, d testDeps) {
 				now := getTime(t, "2006-01-02 15:04:05")
 
@@ -298,35 +719,45 @@ func TestService_Pop(t *testing.T) {
 					QueueName:   queueName,
 					Status:      domain.TaskStatusProcessing,
 					LockedUntil: ops.Pointer(getTime(t, "2006-01-02 15:09:05")),
+					LeaseToken:  leaseToken,
+					Attempts:    1,
 				}
 
 				d.mockClock.EXPECT().
 					Now().
 					Return(now)
 
+				d.mockQueueRepository.EXPECT().
+					Get(gomock.Any(), gomock.Eq(queueName)).
+					Return(queue, nil)
+
+				d.mockIDGenerator.EXPECT().
+					NewID().
+					Return(leaseToken)
+
 				d.mockTaskRepository.EXPECT().
-					GetFirstPending(gomock.Any(), gomock.Eq(queueName)).
-					Do(func(ctx context.Context, _ string) {
+					GetPending(gomock.Any(), gomock.Eq(queueName), gomock.Eq(1)).
+					Do(func(ctx context.Context, _ string, _ int) {
 						assert.True(t, trx.Exists(ctx), "transaction exists")
 					}).
-					Return(expTaskFromRepo, nil)
+					Return([]*domain.Task{expTaskFromRepo}, nil)
 
 				d.mockTaskRepository.EXPECT().
-					Save(gomock.Any(), gomock.Eq(expTaskAfterTransition)).
-					Do(func(ctx context.Context, _ *domain.Task) {
+					UpdateBatch(gomock.Any(), gomock.Eq([]*domain.Task{expTaskAfterTransition})).
+					Do(func(ctx context.Context, _ []*domain.Task) {
 						assert.True(t, trx.Exists(ctx), "transaction exists")
 					}).
 					Return(errors.New("test error"))
 
-				task, err := d.service.Pop(ctx, queueName)
+				tasks, err := d.service.Pop(ctx, queueName, 1)
 
-				assert.EqualError(t, err, "save task: test error")
-				assert.Nil(t, task)
+				assert.EqualError(t, err, "update tasks: test error")
+				assert.Nil(t, tasks)
 				assert.Empty(t, d.logbuf.Logs())
 			},
 		},
 		{
-			name: "rollback trx error on save task error",
+			name: "rollback trx error on update tasks error",
 			run: func(t *testing.T, d testDeps) {
 				now := getTime(t, "2006-01-02 15:04:05")
 
@@ -341,34 +772,44 @@ func TestService_Pop(t *testing.T) {
 					QueueName:   queueName,
 					Status:      domain.TaskStatusProcessing,
 					LockedUntil: ops.Pointer(getTime(t, "2006-01-02 15:09:05")),
+					LeaseToken:  leaseToken,
+					Attempts:    1,
 				}
 
 				d.mockClock.EXPECT().
 					Now().
 					Return(now)
 
+				d.mockQueueRepository.EXPECT().
+					Get(gomock.Any(), gomock.Eq(queueName)).
+					Return(queue, nil)
+
+				d.mockIDGenerator.EXPECT().
+					NewID().
+					Return(leaseToken)
+
 				d.mockTaskRepository.EXPECT().
-					GetFirstPending(gomock.Any(), gomock.Eq(queueName)).
-					Do(func(ctx context.Context, _ string) {
+					GetPending(gomock.Any(), gomock.Eq(queueName), gomock.Eq(1)).
+					Do(func(ctx context.Context, _ string, _ int) {
 						assert.True(t, trx.Exists(ctx), "transaction exists")
 					}).
-					Return(expTaskFromRepo, nil)
+					Return([]*domain.Task{expTaskFromRepo}, nil)
 
 				d.mockTaskRepository.EXPECT().
-					Save(gomock.Any(), gomock.Eq(expTaskAfterTransition)).
-					Do(func(ctx context.Context, _ *domain.Task) {
+					UpdateBatch(gomock.Any(), gomock.Eq([]*domain.Task{expTaskAfterTransition})).
+					Do(func(ctx context.Context, _ []*domain.Task) {
 						assert.True(t, trx.Exists(ctx), "transaction exists")
 
 						trx.AddRollback(ctx, func() error {
 							return errors.New("test rollback trx error")
 						})
 					}).
-					Return(errors.New("test save task error"))
+					Return(errors.New("test update tasks error"))
 
-				task, err := d.service.Pop(ctx, queueName)
+				tasks, err := d.service.Pop(ctx, queueName, 1)
 
-				assert.EqualError(t, err, "save task: test save task error")
-				assert.Nil(t, task)
+				assert.EqualError(t, err, "update tasks: test update tasks error")
+				assert.Nil(t, tasks)
 
 				logs := d.logbuf.Logs()
 				assert.Len(t, logs, 1)
@@ -378,28 +819,53 @@ func TestService_Pop(t *testing.T) {
 					"level": "error",
 					"message": "rollback error on queue.pop",
 					"rb_error": "test rollback trx error",
-					"op_error": "save task: test save task error"
+					"op_error": "update tasks: test update tasks error"
 				}`, logs[0])
 			},
 		},
 		{
-			name: "get first pending task error",
+			name: "get queue error",
 			run: func(t *testing.T, d testDeps) {
 				d.mockClock.EXPECT().
 					Now().
 					Return(getTime(t, "2006-01-02 15:04:05"))
 
-				d.mockTaskRepository.EXPECT().
-					GetFirstPending(gomock.Any(), gomock.Eq(queueName)).
+				d.mockQueueRepository.EXPECT().
+					Get(gomock.Any(), gomock.Eq(queueName)).
 					Do(func(ctx context.Context, _ string) {
 						assert.True(t, trx.Exists(ctx), "transaction exists")
 					}).
 					Return(nil, errors.New("test error"))
 
-				task, err := d.service.Pop(ctx, queueName)
+				tasks, err := d.service.Pop(ctx, queueName, 1)
 
-				assert.EqualError(t, err, "get first pending task: test error")
-				assert.Nil(t, task)
+				assert.EqualError(t, err, "get queue: test error")
+				assert.Nil(t, tasks)
+				assert.Empty(t, d.logbuf.Logs())
+			},
+		},
+		{
+			name: "get pending tasks error",
+			run: func(t *testing.T, d testDeps) {
+				d.mockClock.EXPECT().
+					Now().
+					Return(getTime(t, "2006-01-02 15:04:05"))
+
+				d.mockQueueRepository.EXPECT().
+					Get(gomock.Any(), gomock.Eq(queueName)).
+					Return(queue, nil)
+
+				d.mockTaskRepository.EXPECT().
+					GetPending(gomock.Any(), gomock.Eq(queueName), gomock.Eq(1)).
+					Do(func(ctx context.Context, _ string, _ int) {
+						assert.True(t, trx.Exists(ctx), "transaction exists")
+					}).
+					Return(nil, errors.New("test error"))
+
+				tasks, err := d.service.Pop(ctx, queueName, 1)
+
+				assert.EqualError(t, err, "get pending tasks: test error")
+				assert.Nil(t, tasks)
 				assert.Empty(t, d.logbuf.Logs())
 			},
 		},
@@ -410,14 +876,18 @@ func TestService_Pop(t *testing.T) {
 					Now().
 					Return(getTime(t, "2006-01-02 15:04:05"))
 
+				d.mockQueueRepository.EXPECT().
+					Get(gomock.Any(), gomock.Eq(queueName)).
+					Return(queue, nil)
+
 				d.mockTaskRepository.EXPECT().
-					GetFirstPending(gomock.Any(), gomock.Eq(queueName)).
-					Return(nil, repository.ErrNotFound)
+					GetPending(gomock.Any(), gomock.Eq(queueName), gomock.Eq(1)).
+					Return(nil, nil)
 
-				task, err := d.service.Pop(ctx, queueName)
+				tasks, err := d.service.Pop(ctx, queueName, 1)
 
 				assert.NoError(t, err)
-				assert.Nil(t, task)
+				assert.Nil(t, tasks)
 				assert.Empty(t, d.logbuf.Logs())
 			},
 		},
@@ -427,19 +897,646 @@ func TestService_Pop(t *testing.T) {
 			defer mc.Finish()
 
 			mockClock := NewMockclock(mc)
+			mockIDGenerator := NewMockidGenerator(mc)
+			mockQueueRepository := NewMockqueueRepository(mc)
 			mockTaskRepository := NewMocktaskRepository(mc)
 			logger, logbuf := logimpl.NewTestLogger()
 
 			tc.run(t, testDeps{
+				mockClock:           mockClock,
+				mockIDGenerator:     mockIDGenerator,
+				mockQueueRepository: mockQueueRepository,
+				mockTaskRepository:  mockTaskRepository,
+				logbuf:              logbuf,
+				service:             NewService(mockClock, mockIDGenerator, nil, nil, mockQueueRepository, mockTaskRepository, metricsimpl.NewRegistry(), logger),
+			})
+		})
+	}
+}
+
+func TestService_PopWait(t *testing.T) {
+	var (
+		taskID     = "testTaskID"
+		queueName  = "testQueueName"
+		leaseToken = "testLeaseToken"
+	)
+
+	type testDeps struct {
+		hub                *notify.Hub
+		mockClock          *Mockclock
+		mockTaskRepository *MocktaskRepository
+		service            *Service
+	}
+
+	for _, tc := range []struct {
+		name string
+		run  func(t *testing.T, d testDeps)
+	}{
+		{
+			name: "pop task pushed while waiting",
+			run: func(t *testing.T, d testDeps) {
+				expTask := &domain.Task{
+					ID:          taskID,
+					QueueName:   queueName,
+					Status:      domain.TaskStatusProcessing,
+					LockedUntil: ops.Pointer(getTime(t, "2006-01-02 15:09:05")),
+					LeaseToken:  leaseToken,
+					Attempts:    1,
+				}
+
+				d.mockClock.EXPECT().
+					After(gomock.Eq(time.Minute)).
+					Return(make(<-chan time.Time))
+
+				gomock.InOrder(
+					d.mockTaskRepository.EXPECT().
+						GetPending(gomock.Any(), gomock.Eq(queueName), gomock.Eq(1)).
+						Do(func(context.Context, string, int) {
+							go d.hub.Notify(queueName)
+						}).
+						Return(nil, nil),
+					d.mockTaskRepository.EXPECT().
+						GetPending(gomock.Any(), gomock.Eq(queueName), gomock.Eq(1)).
+						Return([]*domain.Task{{ID: taskID, QueueName: queueName, Status: domain.TaskStatusPending}}, nil),
+					d.mockTaskRepository.EXPECT().
+						UpdateBatch(gomock.Any(), gomock.Eq([]*domain.Task{expTask})).
+						Return(nil),
+				)
+
+				tasks, err := d.service.PopWait(context.Background(), queueName, 1, time.Minute)
+
+				assert.NoError(t, err)
+				assert.Equal(t, []*domain.Task{expTask}, tasks)
+			},
+		},
+		{
+			name: "wait elapses",
+			run: func(t *testing.T, d testDeps) {
+				deadline := make(chan time.Time, 1)
+				deadline <- getTime(t, "2006-01-02 15:05:05")
+
+				d.mockClock.EXPECT().
+					After(gomock.Eq(time.Minute)).
+					Return((<-chan time.Time)(deadline))
+
+				d.mockTaskRepository.EXPECT().
+					GetPending(gomock.Any(), gomock.Eq(queueName), gomock.Eq(1)).
+					Return(nil, nil)
+
+				tasks, err := d.service.PopWait(context.Background(), queueName, 1, time.Minute)
+
+				assert.NoError(t, err)
+				assert.Empty(t, tasks)
+			},
+		},
+		{
+			name: "context is cancelled while waiting",
+			run: func(t *testing.T, d testDeps) {
+				ctx, cancel := context.WithCancel(context.Background())
+
+				d.mockClock.EXPECT().
+					After(gomock.Eq(time.Minute)).
+					Return(make(<-chan time.Time))
+
+				d.mockTaskRepository.EXPECT().
+					GetPending(gomock.Any(), gomock.Eq(queueName), gomock.Eq(1)).
+					Do(func(context.Context, string, int) {
+						cancel()
+					}).
+					Return(nil, nil)
+
+				tasks, err := d.service.PopWait(ctx, queueName, 1, time.Minute)
+
+				assert.NoError(t, err)
+				assert.Empty(t, tasks)
+			},
+		},
+		{
+			name: "pop error",
+			run: func(t *testing.T, d testDeps) {
+				d.mockClock.EXPECT().
+					After(gomock.Eq(time.Minute)).
+					Return(make(<-chan time.Time))
+
+				d.mockTaskRepository.EXPECT().
+					GetPending(gomock.Any(), gomock.Eq(queueName), gomock.Eq(1)).
+					Return(nil, errors.New("test error"))
+
+				tasks, err := d.service.PopWait(context.Background(), queueName, 1, time.Minute)
+
+				assert.EqualError(t, err, "get pending tasks: test error")
+				assert.Nil(t, tasks)
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mc := gomock.NewController(t)
+			defer mc.Finish()
+
+			mockClock := NewMockclock(mc)
+			mockIDGenerator := NewMockidGenerator(mc)
+			mockQueueRepository := NewMockqueueRepository(mc)
+			mockTaskRepository := NewMocktaskRepository(mc)
+			hub := notify.NewHub()
+			logger, _ := logimpl.NewTestLogger()
+
+			mockClock.EXPECT().
+				Now().
+				Return(getTime(t, "2006-01-02 15:04:05")).
+				AnyTimes()
+
+			// Tasks are only got on a push, never by polling
+			mockClock.EXPECT().
+				After(gomock.Eq(waitPollInterval)).
+				Return(make(<-chan time.Time)).
+				AnyTimes()
+
+			mockIDGenerator.EXPECT().
+				NewID().
+				Return(leaseToken).
+				AnyTimes()
+
+			mockQueueRepository.EXPECT().
+				Get(gomock.Any(), gomock.Eq(queueName)).
+				Return(nil, repository.ErrNotFound).
+				AnyTimes()
+
+			tc.run(t, testDeps{
+				hub:                hub,
 				mockClock:          mockClock,
 				mockTaskRepository: mockTaskRepository,
-				logbuf:             logbuf,
-				service:            NewService(mockClock, nil, mockTaskRepository, logger),
+				service:            NewService(mockClock, mockIDGenerator, nil, hub, mockQueueRepository, mockTaskRepository, metricsimpl.NewRegistry(), logger),
+			})
+		})
+	}
+}
+
+func TestService_DeadAndExpiredTasks(t *testing.T) {
+	var (
+		ctx       = context.Background()
+		queueName = "testQueueName"
+	)
+
+	type testDeps struct {
+		mockIdempotencyKeyCache *MockidempotencyKeyCache
+		mockNotifier            *Mocknotifier
+		mockTaskRepository      *MocktaskRepository
+		service                 *Service
+	}
+
+	for _, tc := range []struct {
+		name string
+		run  func(t *testing.T, d testDeps)
+	}{
+		{
+			name: "list dead tasks",
+			run: func(t *testing.T, d testDeps) {
+				expTasks := []*domain.Task{
+					{ID: "testTaskID", QueueName: queueName, Status: domain.TaskStatusDead, Attempts: 3},
+				}
+
+				d.mockTaskRepository.EXPECT().
+					GetDead(gomock.Any(), gomock.Eq(queueName), gomock.Eq(10)).
+					Return(expTasks, nil)
+
+				tasks, err := d.service.ListDead(ctx, queueName, 10)
+
+				assert.NoError(t, err)
+				assert.Equal(t, expTasks, tasks)
+			},
+		},
+		{
+			name: "list dead tasks error",
+			run: func(t *testing.T, d testDeps) {
+				d.mockTaskRepository.EXPECT().
+					GetDead(gomock.Any(), gomock.Eq(queueName), gomock.Eq(10)).
+					Return(nil, errors.New("test error"))
+
+				tasks, err := d.service.ListDead(ctx, queueName, 10)
+
+				assert.EqualError(t, err, "get dead tasks: test error")
+				assert.Nil(t, tasks)
+			},
+		},
+		{
+			name: "redrive dead tasks",
+			run: func(t *testing.T, d testDeps) {
+				d.mockTaskRepository.EXPECT().
+					RedriveDead(gomock.Any(), gomock.Eq(queueName)).
+					Return(int64(2), nil)
+
+				d.mockNotifier.EXPECT().
+					Notify(gomock.Eq(queueName))
+
+				count, err := d.service.RedriveDead(ctx, queueName)
+
+				assert.NoError(t, err)
+				assert.Equal(t, int64(2), count)
+			},
+		},
+		{
+			name: "purge expired tasks",
+			run: func(t *testing.T, d testDeps) {
+				d.mockTaskRepository.EXPECT().
+					DeleteExpired(gomock.Any()).
+					Return(int64(5), nil)
+
+				count, err := d.service.PurgeExpired(ctx)
+
+				assert.NoError(t, err)
+				assert.Equal(t, int64(5), count)
+			},
+		},
+		{
+			name: "purge expired idempotency keys",
+			run: func(t *testing.T, d testDeps) {
+				d.mockIdempotencyKeyCache.EXPECT().
+					DeleteExpired(gomock.Any()).
+					Return(int64(3), nil)
+
+				count, err := d.service.PurgeExpiredIdempotencyKeys(ctx)
+
+				assert.NoError(t, err)
+				assert.Equal(t, int64(3), count)
+			},
+		},
+		{
+			name: "purge dead tasks",
+			run: func(t *testing.T, d testDeps) {
+				d.mockTaskRepository.EXPECT().
+					DeleteDead(gomock.Any(), gomock.Eq(queueName)).
+					Return(int64(0), errors.New("test error"))
+
+				count, err := d.service.PurgeDead(ctx, queueName)
+
+				assert.EqualError(t, err, "delete dead tasks: test error")
+				assert.Zero(t, count)
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mc := gomock.NewController(t)
+			defer mc.Finish()
+
+			mockIdempotencyKeyCache := NewMockidempotencyKeyCache(mc)
+			mockNotifier := NewMocknotifier(mc)
+			mockTaskRepository := NewMocktaskRepository(mc)
+			logger, _ := logimpl.NewTestLogger()
+
+			tc.run(t, testDeps{
+				mockIdempotencyKeyCache: mockIdempotencyKeyCache,
+				mockNotifier:            mockNotifier,
+				mockTaskRepository:      mockTaskRepository,
+				service:                 NewService(nil, nil, mockIdempotencyKeyCache, mockNotifier, nil, mockTaskRepository, metricsimpl.NewRegistry(), logger),
 			})
 		})
 	}
 }
 
+func TestService_Subscribe(t *testing.T) {
+	var (
+		taskID     = "testTaskID"
+		queueName  = "testQueueName"
+		leaseToken = "testLeaseToken"
+		acquireAll = func(ctx context.Context) bool { return ctx.Err() == nil }
+	)
+
+	t.Run("empty queue name", func(t *testing.T) {
+		logger, _ := logimpl.NewTestLogger()
+		service := NewService(nil, nil, nil, nil, nil, nil, metricsimpl.NewRegistry(), logger)
+
+		tasks, err := service.Subscribe(context.Background(), "", acquireAll)
+
+		assert.EqualError(t, err, "empty queue name")
+		assert.Nil(t, tasks)
+	})
+
+	t.Run("deliver tasks until context is cancelled", func(t *testing.T) {
+		mc := gomock.NewController(t)
+		defer mc.Finish()
+
+		mockClock := NewMockclock(mc)
+		mockIDGenerator := NewMockidGenerator(mc)
+		mockQueueRepository := NewMockqueueRepository(mc)
+		mockTaskRepository := NewMocktaskRepository(mc)
+		logger, logbuf := logimpl.NewTestLogger()
+		service := NewService(mockClock, mockIDGenerator, nil, notify.NewHub(), mockQueueRepository, mockTaskRepository, metricsimpl.NewRegistry(), logger)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		expTask := &domain.Task{
+			ID:          taskID,
+			QueueName:   queueName,
+			Status:      domain.TaskStatusProcessing,
+			LockedUntil: ops.Pointer(getTime(t, "2006-01-02 15:09:05")),
+			LeaseToken:  leaseToken,
+			Attempts:    1,
+		}
+
+		mockClock.EXPECT().
+			Now().
+			Return(getTime(t, "2006-01-02 15:04:05")).
+			AnyTimes()
+
+		mockClock.EXPECT().
+			After(gomock.Eq(waitPollInterval)).
+			Return(make(<-chan time.Time)).
+			AnyTimes()
+
+		mockIDGenerator.EXPECT().
+			NewID().
+			Return(leaseToken)
+
+		mockQueueRepository.EXPECT().
+			Get(gomock.Any(), gomock.Eq(queueName)).
+			Return(nil, repository.ErrNotFound).
+			AnyTimes()
+
+		gomock.InOrder(
+			mockTaskRepository.EXPECT().
+				GetPending(gomock.Any(), gomock.Eq(queueName), gomock.Eq(1)).
+				Return([]*domain.Task{{
+					ID:        taskID,
+					QueueName: queueName,
+					Status:    domain.TaskStatusPending,
+				}}, nil),
+			mockTaskRepository.EXPECT().
+				UpdateBatch(gomock.Any(), gomock.Eq([]*domain.Task{expTask})).
+				Return(nil),
+			mockTaskRepository.EXPECT().
+				GetPending(gomock.Any(), gomock.Eq(queueName), gomock.Eq(1)).
+				Return(nil, nil).
+				AnyTimes(),
+		)
+
+		tasks, err := service.Subscribe(ctx, queueName, acquireAll)
+		require.NoError(t, err)
+
+		select {
+		case task := <-tasks:
+			assert.Equal(t, expTask, task)
+		case <-time.After(time.Second):
+			t.Fatal("task is not delivered")
+		}
+
+		cancel()
+
+		select {
+		case _, ok := <-tasks:
+			assert.False(t, ok, "tasks channel is closed")
+		case <-time.After(time.Second):
+			t.Fatal("tasks channel is not closed")
+		}
+
+		assert.Empty(t, logbuf.Logs())
+	})
+
+	t.Run("take no task until acquired", func(t *testing.T) {
+		mc := gomock.NewController(t)
+		defer mc.Finish()
+
+		mockTaskRepository := NewMocktaskRepository(mc)
+		logger, logbuf := logimpl.NewTestLogger()
+		service := NewService(nil, nil, nil, notify.NewHub(), nil, mockTaskRepository, metricsimpl.NewRegistry(), logger)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		acquiring := make(chan struct{})
+		acquire := func(ctx context.Context) bool {
+			close(acquiring)
+			<-ctx.Done()
+			return false
+		}
+
+		tasks, err := service.Subscribe(ctx, queueName, acquire)
+		require.NoError(t, err)
+
+		<-acquiring
+		cancel()
+
+		select {
+		case _, ok := <-tasks:
+			assert.False(t, ok, "tasks channel is closed")
+		case <-time.After(time.Second):
+			t.Fatal("tasks channel is not closed")
+		}
+
+		assert.Empty(t, logbuf.Logs())
+	})
+
+	t.Run("release undelivered task when context is cancelled", func(t *testing.T) {
+		mc := gomock.NewController(t)
+		defer mc.Finish()
+
+		mockClock := NewMockclock(mc)
+		mockIDGenerator := NewMockidGenerator(mc)
+		mockQueueRepository := NewMockqueueRepository(mc)
+		mockTaskRepository := NewMocktaskRepository(mc)
+		logger, logbuf := logimpl.NewTestLogger()
+		service := NewService(mockClock, mockIDGenerator, nil, notify.NewHub(), mockQueueRepository, mockTaskRepository, metricsimpl.NewRegistry(), logger)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		expTask := &domain.Task{
+			ID:          taskID,
+			QueueName:   queueName,
+			Status:      domain.TaskStatusProcessing,
+			LockedUntil: ops.Pointer(getTime(t, "2006-01-02 15:09:05")),
+			LeaseToken:  leaseToken,
+			Attempts:    1,
+		}
+
+		mockClock.EXPECT().
+			Now().
+			Return(getTime(t, "2006-01-02 15:04:05"))
+
+		mockIDGenerator.EXPECT().
+			NewID().
+			Return(leaseToken)
+
+		mockQueueRepository.EXPECT().
+			Get(gomock.Any(), gomock.Eq(queueName)).
+			Return(nil, repository.ErrNotFound)
+
+		mockTaskRepository.EXPECT().
+			GetPending(gomock.Any(), gomock.Eq(queueName), gomock.Eq(1)).
+			Return([]*domain.Task{{
+				ID:        taskID,
+				QueueName: queueName,
+				Status:    domain.TaskStatusPending,
+			}}, nil)
+
+		popped := make(chan struct{})
+		mockTaskRepository.EXPECT().
+			UpdateBatch(gomock.Any(), gomock.Eq([]*domain.Task{expTask})).
+			DoAndReturn(func(context.Context, []*domain.Task) error {
+				close(popped)
+				return nil
+			})
+
+		released := make(chan struct{})
+		mockTaskRepository.EXPECT().
+			ReleaseBatch(gomock.Any(), gomock.Eq([]*domain.Task{expTask}), gomock.Eq(false)).
+			DoAndReturn(func(ctx context.Context, _ []*domain.Task, _ bool) (int64, error) {
+				assert.NoError(t, ctx.Err(), "release is not cancelled with the subscription")
+				close(released)
+				return 1, nil
+			})
+
+		tasks, err := service.Subscribe(ctx, queueName, acquireAll)
+		require.NoError(t, err)
+
+		<-popped
+		cancel()
+
+		select {
+		case <-released:
+		case <-time.After(time.Second):
+			t.Fatal("task is not released")
+		}
+
+		select {
+		case _, ok := <-tasks:
+			assert.False(t, ok, "tasks channel is closed")
+		case <-time.After(time.Second):
+			t.Fatal("tasks channel is not closed")
+		}
+
+		assert.Empty(t, logbuf.Logs())
+	})
+}
+
+func TestService_Release(t *testing.T) {
+	ctx := context.Background()
+	tasks := []*domain.Task{
+		{ID: "taskID1", QueueName: "queue1", LeaseToken: "leaseToken1"},
+		{ID: "taskID2", QueueName: "queue1", LeaseToken: "leaseToken2"},
+		{ID: "taskID3", QueueName: "queue2", LeaseToken: "leaseToken3"},
+	}
+
+	t.Run("release and notify every queue once", func(t *testing.T) {
+		mc := gomock.NewController(t)
+		defer mc.Finish()
+
+		mockNotifier := NewMocknotifier(mc)
+		mockTaskRepository := NewMocktaskRepository(mc)
+		logger, _ := logimpl.NewTestLogger()
+		service := NewService(nil, nil, nil, mockNotifier, nil, mockTaskRepository, metricsimpl.NewRegistry(), logger)
+
+		cancelledCtx, cancel := context.WithCancel(ctx)
+		cancel()
+
+		mockTaskRepository.EXPECT().
+			ReleaseBatch(gomock.Any(), gomock.Eq(tasks), gomock.Eq(false)).
+			DoAndReturn(func(ctx context.Context, _ []*domain.Task, _ bool) (int64, error) {
+				assert.NoError(t, ctx.Err(), "release is done even if the context is done")
+				return 3, nil
+			})
+
+		mockNotifier.EXPECT().Notify(gomock.Eq("queue1"))
+		mockNotifier.EXPECT().Notify(gomock.Eq("queue2"))
+
+		assert.NoError(t, service.Release(cancelledCtx, tasks))
+	})
+
+	t.Run("nothing to release", func(t *testing.T) {
+		logger, _ := logimpl.NewTestLogger()
+		service := NewService(nil, nil, nil, nil, nil, nil, metricsimpl.NewRegistry(), logger)
+
+		assert.NoError(t, service.Release(ctx, nil))
+	})
+
+	t.Run("release error", func(t *testing.T) {
+		mc := gomock.NewController(t)
+		defer mc.Finish()
+
+		mockTaskRepository := NewMocktaskRepository(mc)
+		logger, _ := logimpl.NewTestLogger()
+		service := NewService(nil, nil, nil, nil, nil, mockTaskRepository, metricsimpl.NewRegistry(), logger)
+
+		mockTaskRepository.EXPECT().
+			ReleaseBatch(gomock.Any(), gomock.Eq(tasks), gomock.Eq(false)).
+			Return(int64(0), errors.New("test error"))
+
+		assert.EqualError(t, service.Release(ctx, tasks), "release tasks: test error")
+	})
+}
+
+func TestService_ReleaseUnacked(t *testing.T) {
+	ctx := context.Background()
+	tasks := []*domain.Task{
+		{ID: "taskID1", QueueName: "queue1", LeaseToken: "leaseToken1"},
+		{ID: "taskID2", QueueName: "queue2", LeaseToken: "leaseToken2"},
+		{ID: "taskID3", QueueName: "queue1", LeaseToken: "leaseToken3"},
+	}
+
+	t.Run("count attempts per queue setting", func(t *testing.T) {
+		mc := gomock.NewController(t)
+		defer mc.Finish()
+
+		mockNotifier := NewMocknotifier(mc)
+		mockQueueRepository := NewMockqueueRepository(mc)
+		mockTaskRepository := NewMocktaskRepository(mc)
+		logger, _ := logimpl.NewTestLogger()
+		service := NewService(nil, nil, nil, mockNotifier, mockQueueRepository, mockTaskRepository, metricsimpl.NewRegistry(), logger)
+
+		cancelledCtx, cancel := context.WithCancel(ctx)
+		cancel()
+
+		queue2 := domain.NewQueue("queue2")
+		queue2.DisconnectCountsAttempt = true
+
+		mockQueueRepository.EXPECT().
+			Get(gomock.Any(), gomock.Eq("queue1")).
+			Return(nil, repository.ErrNotFound)
+
+		mockQueueRepository.EXPECT().
+			Get(gomock.Any(), gomock.Eq("queue2")).
+			Return(queue2, nil)
+
+		mockTaskRepository.EXPECT().
+			ReleaseBatch(gomock.Any(), gomock.Eq([]*domain.Task{tasks[0], tasks[2]}), gomock.Eq(false)).
+			DoAndReturn(func(ctx context.Context, _ []*domain.Task, _ bool) (int64, error) {
+				assert.NoError(t, ctx.Err(), "release is done even if the context is done")
+				return 2, nil
+			})
+
+		mockTaskRepository.EXPECT().
+			ReleaseBatch(gomock.Any(), gomock.Eq([]*domain.Task{tasks[1]}), gomock.Eq(true)).
+			Return(int64(1), nil)
+
+		mockNotifier.EXPECT().Notify(gomock.Eq("queue1"))
+		mockNotifier.EXPECT().Notify(gomock.Eq("queue2"))
+
+		assert.NoError(t, service.ReleaseUnacked(cancelledCtx, tasks))
+	})
+
+	t.Run("nothing to release", func(t *testing.T) {
+		logger, _ := logimpl.NewTestLogger()
+		service := NewService(nil, nil, nil, nil, nil, nil, metricsimpl.NewRegistry(), logger)
+
+		assert.NoError(t, service.ReleaseUnacked(ctx, nil))
+	})
+
+	t.Run("get queue error", func(t *testing.T) {
+		mc := gomock.NewController(t)
+		defer mc.Finish()
+
+		mockQueueRepository := NewMockqueueRepository(mc)
+		logger, _ := logimpl.NewTestLogger()
+		service := NewService(nil, nil, nil, nil, mockQueueRepository, nil, metricsimpl.NewRegistry(), logger)
+
+		mockQueueRepository.EXPECT().
+			Get(gomock.Any(), gomock.Eq("queue1")).
+			Return(nil, errors.New("test error"))
+
+		assert.EqualError(t, service.ReleaseUnacked(ctx, tasks), "get queue: test error")
+	})
+}
+
 func getTime(t *testing.T, value string) time.Time {
 	out, err := time.Parse(time.DateTime, value)
 	require.NoError(t, err)
@@ -0,0 +1,44 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/art-es/queue-service/internal/app/domain"
+)
+
+// GetQueueStats returns the task counts and the throughput of the queue.
+// A queue without settings and tasks has zero stats.
+func (s *Service) GetQueueStats(ctx context.Context, queueName string) (*domain.QueueStats, error) {
+	stats, err := s.taskRepository.GetStats(ctx, queueName)
+	if err != nil {
+		return nil, fmt.Errorf("get stats: %w", err)
+	}
+
+	stats.Throughput, err = s.taskRepository.GetThroughput(ctx, queueName, throughputPeriods)
+	if err != nil {
+		return nil, fmt.Errorf("get throughput: %w", err)
+	}
+
+	return stats, nil
+}
+
+// ListQueueStats returns the task counts of all the queues having settings or tasks.
+func (s *Service) ListQueueStats(ctx context.Context) ([]*domain.QueueStats, error) {
+	list, err := s.taskRepository.ListStats(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list stats: %w", err)
+	}
+
+	return list, nil
+}
+
+// PurgeStaleThroughput deletes the throughput counters older than the longest throughput period.
+func (s *Service) PurgeStaleThroughput(ctx context.Context) (int64, error) {
+	count, err := s.taskRepository.DeleteStaleThroughput(ctx, throughputPeriods[len(throughputPeriods)-1])
+	if err != nil {
+		return 0, fmt.Errorf("delete stale throughput: %w", err)
+	}
+
+	return count, nil
+}
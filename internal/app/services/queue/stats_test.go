@@ -0,0 +1,140 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/art-es/queue-service/internal/app/domain"
+	"github.com/art-es/queue-service/internal/infra/log/logimpl"
+	"github.com/art-es/queue-service/internal/infra/metrics/metricsimpl"
+	"github.com/art-es/queue-service/internal/infra/ops"
+)
+
+func TestService_Stats(t *testing.T) {
+	var (
+		ctx       = context.Background()
+		queueName = "testQueueName"
+	)
+
+	type testDeps struct {
+		mockTaskRepository *MocktaskRepository
+		service            *Service
+	}
+
+	for _, tc := range []struct {
+		name string
+		run  func(t *testing.T, d testDeps)
+	}{
+		{
+			name: "get queue stats",
+			run: func(t *testing.T, d testDeps) {
+				stats := &domain.QueueStats{
+					QueueName:        queueName,
+					Pending:          3,
+					Processing:       2,
+					OldestPendingAge: ops.Pointer(time.Minute),
+				}
+				throughput := []*domain.QueueThroughput{
+					{Period: time.Minute, Pushed: 5, Acked: 4, Failed: 1},
+					{Period: 5 * time.Minute, Pushed: 10, Acked: 8, Failed: 2},
+					{Period: time.Hour, Pushed: 100, Acked: 80, Failed: 20},
+				}
+
+				d.mockTaskRepository.EXPECT().
+					GetStats(gomock.Any(), gomock.Eq(queueName)).
+					Return(stats, nil)
+
+				d.mockTaskRepository.EXPECT().
+					GetThroughput(gomock.Any(), gomock.Eq(queueName), gomock.Eq(throughputPeriods)).
+					Return(throughput, nil)
+
+				got, err := d.service.GetQueueStats(ctx, queueName)
+
+				assert.NoError(t, err)
+				assert.Equal(t, &domain.QueueStats{
+					QueueName:        queueName,
+					Pending:          3,
+					Processing:       2,
+					OldestPendingAge: ops.Pointer(time.Minute),
+					Throughput:       throughput,
+				}, got)
+			},
+		},
+		{
+			name: "get queue stats error",
+			run: func(t *testing.T, d testDeps) {
+				d.mockTaskRepository.EXPECT().
+					GetStats(gomock.Any(), gomock.Eq(queueName)).
+					Return(nil, errors.New("test error"))
+
+				got, err := d.service.GetQueueStats(ctx, queueName)
+
+				assert.EqualError(t, err, "get stats: test error")
+				assert.Nil(t, got)
+			},
+		},
+		{
+			name: "get queue throughput error",
+			run: func(t *testing.T, d testDeps) {
+				d.mockTaskRepository.EXPECT().
+					GetStats(gomock.Any(), gomock.Eq(queueName)).
+					Return(&domain.QueueStats{QueueName: queueName}, nil)
+
+				d.mockTaskRepository.EXPECT().
+					GetThroughput(gomock.Any(), gomock.Eq(queueName), gomock.Any()).
+					Return(nil, errors.New("test error"))
+
+				got, err := d.service.GetQueueStats(ctx, queueName)
+
+				assert.EqualError(t, err, "get throughput: test error")
+				assert.Nil(t, got)
+			},
+		},
+		{
+			name: "list queue stats",
+			run: func(t *testing.T, d testDeps) {
+				list := []*domain.QueueStats{{QueueName: "a", Pending: 1}, {QueueName: "b", Dead: 2}}
+
+				d.mockTaskRepository.EXPECT().
+					ListStats(gomock.Any()).
+					Return(list, nil)
+
+				got, err := d.service.ListQueueStats(ctx)
+
+				assert.NoError(t, err)
+				assert.Equal(t, list, got)
+			},
+		},
+		{
+			name: "purge stale throughput",
+			run: func(t *testing.T, d testDeps) {
+				d.mockTaskRepository.EXPECT().
+					DeleteStaleThroughput(gomock.Any(), gomock.Eq(time.Hour)).
+					Return(int64(7), nil)
+
+				count, err := d.service.PurgeStaleThroughput(ctx)
+
+				assert.NoError(t, err)
+				assert.Equal(t, int64(7), count)
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mc := gomock.NewController(t)
+			defer mc.Finish()
+
+			mockTaskRepository := NewMocktaskRepository(mc)
+			logger, _ := logimpl.NewTestLogger()
+
+			tc.run(t, testDeps{
+				mockTaskRepository: mockTaskRepository,
+				service:            NewService(nil, nil, nil, nil, nil, mockTaskRepository, metricsimpl.NewRegistry(), logger),
+			})
+		})
+	}
+}
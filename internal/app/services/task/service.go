@@ -10,18 +10,34 @@ import (
 	"github.com/art-es/queue-service/internal/app/domain"
 	"github.com/art-es/queue-service/internal/app/repository"
 	"github.com/art-es/queue-service/internal/infra/log"
+	"github.com/art-es/queue-service/internal/infra/metrics"
 	"github.com/art-es/queue-service/internal/infra/trx/trxutil"
 )
 
+var ErrInvalidDuration = errors.New("duration must be positive")
+
 type clock interface {
 	Now() time.Time
 }
 
+type random interface {
+	Int64N(n int64) int64
+}
+
 type idempotencyKeyCache interface {
-	HasTaskAck(key string) bool
-	SetTaskAck(key string)
-	HasTaskNack(key string) bool
-	SetTaskNack(key string)
+	HasTaskAck(ctx context.Context, key string) (bool, error)
+	// SetTaskAck returns repository.ErrAlreadyExists if the key is already set.
+	SetTaskAck(ctx context.Context, key string) error
+	HasTaskNack(ctx context.Context, key string) (bool, error)
+	// SetTaskNack returns repository.ErrAlreadyExists if the key is already set.
+	SetTaskNack(ctx context.Context, key string) error
+	// Transactional reports whether the keys are stored in the trx of the operation,
+	// otherwise they are set after its commit, so a failed commit leaves no key behind.
+	Transactional() bool
+}
+
+type queueRepository interface {
+	Get(ctx context.Context, name string) (*domain.Queue, error)
 }
 
 type taskRepository interface {
@@ -30,65 +46,263 @@ type taskRepository interface {
 	Save(ctx context.Context, task *domain.Task) error
 }
 
+type AckRequest struct {
+	TaskID         string
+	LeaseToken     string
+	IdempotencyKey *string
+}
+
+type NackRequest struct {
+	TaskID         string
+	LeaseToken     string
+	IdempotencyKey *string
+	RetryAfter     *time.Duration // Overrides the queue backoff, if set
+}
+
+type ExtendRequest struct {
+	TaskID     string
+	LeaseToken string
+	Duration   time.Duration // Must be positive
+}
+
 type Service struct {
 	clock               clock
+	random              random
 	idempotencyKeyCache idempotencyKeyCache
+	queueRepository     queueRepository
 	taskRepository      taskRepository
+	metrics             metrics.Metrics
 	logger              log.Logger
+
+	ackedTasks            metrics.Counter
+	nackedTasks           metrics.Counter
+	idempotencyKeyLookups metrics.Counter
 }
 
 func NewService(
 	clock clock,
+	random random,
 	idempotencyKeyCache idempotencyKeyCache,
+	queueRepository queueRepository,
 	taskRepository taskRepository,
+	metrics metrics.Metrics,
 	logger log.Logger,
 ) *Service {
 	logger = logger.With("module", "internal/app/services/task")
 
 	return &Service{
 		clock:               clock,
+		random:              random,
 		idempotencyKeyCache: idempotencyKeyCache,
+		queueRepository:     queueRepository,
 		taskRepository:      taskRepository,
+		metrics:             metrics,
 		logger:              logger,
+
+		ackedTasks:  metrics.Counter("queue_tasks_acked_total", "Acked tasks by queue.", "queue"),
+		nackedTasks: metrics.Counter("queue_tasks_nacked_total", "Nacked tasks by queue.", "queue"),
+		idempotencyKeyLookups: metrics.Counter(
+			"idempotency_key_lookups_total",
+			"Idempotency key lookups by operation and result.",
+			"operation", "result",
+		),
 	}
 }
 
-func (s *Service) Ack(ctx context.Context, taskID string, idempotencyKey *string) error {
-	if idempotencyKey != nil {
-		if s.idempotencyKeyCache.HasTaskAck(*idempotencyKey) {
+func (s *Service) Ack(ctx context.Context, req *AckRequest) error {
+	if req.IdempotencyKey != nil {
+		ok, err := s.idempotencyKeyCache.HasTaskAck(ctx, *req.IdempotencyKey)
+		if err != nil {
+			return fmt.Errorf("get idempotency key: %w", err)
+		}
+
+		s.countIdempotencyKeyLookup("task.ack", ok)
+
+		if ok {
 			return nil
 		}
 	}
 
-	if err := s.taskRepository.Complete(ctx, taskID); err != nil {
-		return fmt.Errorf("complete task: %w", err)
+	// Set once the task is acked
+	var queueName string
+	keyInTrx := req.IdempotencyKey != nil && s.idempotencyKeyCache.Transactional()
+
+	err := trxutil.DoOrLogError(s.logger, s.metrics, "task.ack", ctx, func(ctx context.Context) error {
+		task, err := s.taskRepository.GetProcessingWithID(ctx, req.TaskID)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				// The lock has expired, or the task is already acked or nacked
+				return domain.ErrLeaseLost
+			}
+
+			return fmt.Errorf("get processing task: %w", err)
+		}
+
+		if err = task.CheckLease(req.LeaseToken); err != nil {
+			return err
+		}
+
+		if err = s.taskRepository.Complete(ctx, task.ID); err != nil {
+			return fmt.Errorf("complete task: %w", err)
+		}
+
+		if keyInTrx {
+			if err = s.idempotencyKeyCache.SetTaskAck(ctx, *req.IdempotencyKey); err != nil {
+				return fmt.Errorf("set idempotency key: %w", err)
+			}
+		}
+
+		queueName = task.QueueName
+		return nil
+	})
+	if errors.Is(err, repository.ErrAlreadyExists) {
+		// A concurrent ack with the same idempotency key has been done first
+		return nil
 	}
 
-	if idempotencyKey != nil {
-		s.idempotencyKeyCache.SetTaskAck(*idempotencyKey)
+	if err == nil {
+		s.ackedTasks.Inc(queueName)
+
+		if req.IdempotencyKey != nil && !keyInTrx {
+			s.setKeyAfterCommit(ctx, "task.ack", s.idempotencyKeyCache.SetTaskAck, *req.IdempotencyKey)
+		}
 	}
-	return nil
+
+	return err
 }
 
-func (s *Service) Nack(ctx context.Context, taskID string, idempotencyKey *string) error {
-	if idempotencyKey != nil {
-		if s.idempotencyKeyCache.HasTaskNack(*idempotencyKey) {
+func (s *Service) Nack(ctx context.Context, req *NackRequest) error {
+	if req.IdempotencyKey != nil {
+		ok, err := s.idempotencyKeyCache.HasTaskNack(ctx, *req.IdempotencyKey)
+		if err != nil {
+			return fmt.Errorf("get idempotency key: %w", err)
+		}
+
+		s.countIdempotencyKeyLookup("task.nack", ok)
+
+		if ok {
 			return nil
 		}
 	}
 
 	now := s.clock.Now()
-	err := trxutil.DoOrLogError(s.logger, "task.nack", ctx, func(ctx context.Context) error {
-		task, err := s.taskRepository.GetProcessingWithID(ctx, taskID)
+	// Set once the task is nacked
+	var queueName string
+	keyInTrx := req.IdempotencyKey != nil && s.idempotencyKeyCache.Transactional()
+
+	err := trxutil.DoOrLogError(s.logger, s.metrics, "task.nack", ctx, func(ctx context.Context) error {
+		task, err := s.taskRepository.GetProcessingWithID(ctx, req.TaskID)
 		if err != nil {
 			if errors.Is(err, repository.ErrNotFound) {
-				return nil
+				// The lock has expired, or the task is already acked or nacked
+				return domain.ErrLeaseLost
 			}
 
 			return fmt.Errorf("get processing task: %w", err)
 		}
 
-		task.ToFailed(now)
+		if err = task.CheckLease(req.LeaseToken); err != nil {
+			return err
+		}
+
+		queue, err := s.getQueue(ctx, task.QueueName)
+		if err != nil {
+			return err
+		}
+
+		delay := queue.Backoff.Delay(task.LastFailDuration, s.random)
+		if req.RetryAfter != nil {
+			delay = *req.RetryAfter
+		}
+
+		task.ToFailed(now, delay, queue)
+
+		if err = s.taskRepository.Save(ctx, task); err != nil {
+			return fmt.Errorf("save task: %w", err)
+		}
+
+		if keyInTrx {
+			if err = s.idempotencyKeyCache.SetTaskNack(ctx, *req.IdempotencyKey); err != nil {
+				return fmt.Errorf("set idempotency key: %w", err)
+			}
+		}
+
+		queueName = task.QueueName
+		return nil
+	})
+	if errors.Is(err, repository.ErrAlreadyExists) {
+		// A concurrent nack with the same idempotency key has been done first
+		return nil
+	}
+
+	if err == nil {
+		s.nackedTasks.Inc(queueName)
+
+		if req.IdempotencyKey != nil && !keyInTrx {
+			s.setKeyAfterCommit(ctx, "task.nack", s.idempotencyKeyCache.SetTaskNack, *req.IdempotencyKey)
+		}
+	}
+
+	return err
+}
+
+// setKeyAfterCommit sets the idempotency key of a committed operation in a store out of the trx.
+// The operation is done anyway, so an error is only logged.
+func (s *Service) setKeyAfterCommit(
+	ctx context.Context,
+	operation string,
+	set func(ctx context.Context, key string) error,
+	key string,
+) {
+	if err := set(ctx, key); err != nil && !errors.Is(err, repository.ErrAlreadyExists) {
+		s.logger.Log(log.LevelError).
+			With("message", "set idempotency key error on "+operation).
+			With("error", err.Error()).
+			Write()
+	}
+}
+
+// countIdempotencyKeyLookup counts an idempotency key lookup of the operation as a hit if the key is found.
+func (s *Service) countIdempotencyKeyLookup(operation string, ok bool) {
+	result := "miss"
+	if ok {
+		result = "hit"
+	}
+
+	s.idempotencyKeyLookups.Inc(operation, result)
+}
+
+func (s *Service) Extend(ctx context.Context, req *ExtendRequest) (*domain.Task, error) {
+	// A non-positive duration would move the lock back and redeliver the task still held by the consumer
+	if req.Duration <= 0 {
+		return nil, ErrInvalidDuration
+	}
+
+	var task *domain.Task
+
+	now := s.clock.Now()
+	err := trxutil.DoOrLogError(s.logger, s.metrics, "task.extend", ctx, func(ctx context.Context) error {
+		var err error
+
+		task, err = s.taskRepository.GetProcessingWithID(ctx, req.TaskID)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return domain.ErrLeaseLost
+			}
+
+			return fmt.Errorf("get processing task: %w", err)
+		}
+
+		if err = task.CheckLease(req.LeaseToken); err != nil {
+			return err
+		}
+
+		queue, err := s.getQueue(ctx, task.QueueName)
+		if err != nil {
+			return err
+		}
+
+		task.ExtendLock(now, req.Duration, queue)
 
 		if err = s.taskRepository.Save(ctx, task); err != nil {
 			return fmt.Errorf("save task: %w", err)
@@ -97,11 +311,22 @@ func (s *Service) Nack(ctx context.Context, taskID string, idempotencyKey *strin
 		return nil
 	})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if idempotencyKey != nil {
-		s.idempotencyKeyCache.SetTaskNack(*idempotencyKey)
+	return task, nil
+}
+
+// getQueue returns stored queue settings or the defaults.
+func (s *Service) getQueue(ctx context.Context, name string) (*domain.Queue, error) {
+	queue, err := s.queueRepository.Get(ctx, name)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return domain.NewQueue(name), nil
+		}
+
+		return nil, fmt.Errorf("get queue: %w", err)
 	}
-	return nil
+
+	return queue, nil
 }
@@ -56,6 +56,44 @@ func (mr *MockclockMockRecorder) Now() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Now", reflect.TypeOf((*Mockclock)(nil).Now))
 }
 
+// Mockrandom is a mock of random interface.
+type Mockrandom struct {
+	ctrl     *gomock.Controller
+	recorder *MockrandomMockRecorder
+	isgomock struct{}
+}
+
+// MockrandomMockRecorder is the mock recorder for Mockrandom.
+type MockrandomMockRecorder struct {
+	mock *Mockrandom
+}
+
+// NewMockrandom creates a new mock instance.
+func NewMockrandom(ctrl *gomock.Controller) *Mockrandom {
+	mock := &Mockrandom{ctrl: ctrl}
+	mock.recorder = &MockrandomMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *Mockrandom) EXPECT() *MockrandomMockRecorder {
+	return m.recorder
+}
+
+// Int64N mocks base method.
+func (m *Mockrandom) Int64N(n int64) int64 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Int64N", n)
+	ret0, _ := ret[0].(int64)
+	return ret0
+}
+
+// Int64N indicates an expected call of Int64N.
+func (mr *MockrandomMockRecorder) Int64N(n any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Int64N", reflect.TypeOf((*Mockrandom)(nil).Int64N), n)
+}
+
 // MockidempotencyKeyCache is a mock of idempotencyKeyCache interface.
 type MockidempotencyKeyCache struct {
 	ctrl     *gomock.Controller
@@ -81,55 +119,114 @@ func (m *MockidempotencyKeyCache) EXPECT() *MockidempotencyKeyCacheMockRecorder
 }
 
 // HasTaskAck mocks base method.
-func (m *MockidempotencyKeyCache) HasTaskAck(key string) bool {
+func (m *MockidempotencyKeyCache) HasTaskAck(ctx context.Context, key string) (bool, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "HasTaskAck", key)
+	ret := m.ctrl.Call(m, "HasTaskAck", ctx, key)
 	ret0, _ := ret[0].(bool)
-	return ret0
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // HasTaskAck indicates an expected call of HasTaskAck.
-func (mr *MockidempotencyKeyCacheMockRecorder) HasTaskAck(key any) *gomock.Call {
+func (mr *MockidempotencyKeyCacheMockRecorder) HasTaskAck(ctx, key any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasTaskAck", reflect.TypeOf((*MockidempotencyKeyCache)(nil).HasTaskAck), key)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasTaskAck", reflect.TypeOf((*MockidempotencyKeyCache)(nil).HasTaskAck), ctx, key)
 }
 
 // HasTaskNack mocks base method.
-func (m *MockidempotencyKeyCache) HasTaskNack(key string) bool {
+func (m *MockidempotencyKeyCache) HasTaskNack(ctx context.Context, key string) (bool, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "HasTaskNack", key)
+	ret := m.ctrl.Call(m, "HasTaskNack", ctx, key)
 	ret0, _ := ret[0].(bool)
-	return ret0
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // HasTaskNack indicates an expected call of HasTaskNack.
-func (mr *MockidempotencyKeyCacheMockRecorder) HasTaskNack(key any) *gomock.Call {
+func (mr *MockidempotencyKeyCacheMockRecorder) HasTaskNack(ctx, key any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasTaskNack", reflect.TypeOf((*MockidempotencyKeyCache)(nil).HasTaskNack), key)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasTaskNack", reflect.TypeOf((*MockidempotencyKeyCache)(nil).HasTaskNack), ctx, key)
 }
 
 // SetTaskAck mocks base method.
-func (m *MockidempotencyKeyCache) SetTaskAck(key string) {
+func (m *MockidempotencyKeyCache) SetTaskAck(ctx context.Context, key string) error {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "SetTaskAck", key)
+	ret := m.ctrl.Call(m, "SetTaskAck", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
 // SetTaskAck indicates an expected call of SetTaskAck.
-func (mr *MockidempotencyKeyCacheMockRecorder) SetTaskAck(key any) *gomock.Call {
+func (mr *MockidempotencyKeyCacheMockRecorder) SetTaskAck(ctx, key any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetTaskAck", reflect.TypeOf((*MockidempotencyKeyCache)(nil).SetTaskAck), key)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetTaskAck", reflect.TypeOf((*MockidempotencyKeyCache)(nil).SetTaskAck), ctx, key)
 }
 
 // SetTaskNack mocks base method.
-func (m *MockidempotencyKeyCache) SetTaskNack(key string) {
+func (m *MockidempotencyKeyCache) SetTaskNack(ctx context.Context, key string) error {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "SetTaskNack", key)
+	ret := m.ctrl.Call(m, "SetTaskNack", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
 // SetTaskNack indicates an expected call of SetTaskNack.
-func (mr *MockidempotencyKeyCacheMockRecorder) SetTaskNack(key any) *gomock.Call {
+func (mr *MockidempotencyKeyCacheMockRecorder) SetTaskNack(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetTaskNack", reflect.TypeOf((*MockidempotencyKeyCache)(nil).SetTaskNack), ctx, key)
+}
+
+// Transactional mocks base method.
+func (m *MockidempotencyKeyCache) Transactional() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Transactional")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// Transactional indicates an expected call of Transactional.
+func (mr *MockidempotencyKeyCacheMockRecorder) Transactional() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Transactional", reflect.TypeOf((*MockidempotencyKeyCache)(nil).Transactional))
+}
+
+// MockqueueRepository is a mock of queueRepository interface.
+type MockqueueRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockqueueRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockqueueRepositoryMockRecorder is the mock recorder for MockqueueRepository.
+type MockqueueRepositoryMockRecorder struct {
+	mock *MockqueueRepository
+}
+
+// NewMockqueueRepository creates a new mock instance.
+func NewMockqueueRepository(ctrl *gomock.Controller) *MockqueueRepository {
+	mock := &MockqueueRepository{ctrl: ctrl}
+	mock.recorder = &MockqueueRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockqueueRepository) EXPECT() *MockqueueRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Get mocks base method.
+func (m *MockqueueRepository) Get(ctx context.Context, name string) (*domain.Queue, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, name)
+	ret0, _ := ret[0].(*domain.Queue)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockqueueRepositoryMockRecorder) Get(ctx, name any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetTaskNack", reflect.TypeOf((*MockidempotencyKeyCache)(nil).SetTaskNack), key)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockqueueRepository)(nil).Get), ctx, name)
 }
 
 // MocktaskRepository is a mock of taskRepository interface.
@@ -10,6 +10,7 @@ import (
 	"github.com/art-es/queue-service/internal/app/repository"
 	"github.com/art-es/queue-service/internal/infra/log"
 	"github.com/art-es/queue-service/internal/infra/log/logimpl"
+	"github.com/art-es/queue-service/internal/infra/metrics/metricsimpl"
 	"github.com/art-es/queue-service/internal/infra/ops"
 	"github.com/art-es/queue-service/internal/infra/trx"
 	"github.com/stretchr/testify/assert"
@@ -21,12 +22,20 @@ func TestService_Ack(t *testing.T) {
 	var (
 		ctx            = context.Background()
 		taskID         = "testTaskID"
+		leaseToken     = "testLeaseToken"
 		idempotencyKey = "testIdempotencyKey"
+
+		processingTask = &domain.Task{
+			ID:         taskID,
+			Status:     domain.TaskStatusProcessing,
+			LeaseToken: leaseToken,
+		}
 	)
 
 	type testDeps struct {
 		mockIdempotencyKeyCache *MockidempotencyKeyCache
 		mockTaskRepository      *MocktaskRepository
+		logbuf                  log.Buffer
 		service                 *Service
 	}
 
@@ -37,52 +46,264 @@ func TestService_Ack(t *testing.T) {
 		{
 			name: "ack task",
 			run: func(t *testing.T, d testDeps) {
+				d.mockTaskRepository.EXPECT().
+					GetProcessingWithID(gomock.Any(), gomock.Eq(taskID)).
+					Do(func(ctx context.Context, _ string) {
+						assert.True(t, trx.Exists(ctx), "transaction exists")
+					}).
+					Return(processingTask, nil)
+
 				d.mockTaskRepository.EXPECT().
 					Complete(gomock.Any(), gomock.Eq(taskID)).
+					Do(func(ctx context.Context, _ string) {
+						assert.True(t, trx.Exists(ctx), "transaction exists")
+					}).
 					Return(nil)
 
-				err := d.service.Ack(ctx, taskID, nil)
+				err := d.service.Ack(ctx, &AckRequest{
+					TaskID:     taskID,
+					LeaseToken: leaseToken,
+				})
 				assert.NoError(t, err)
+				assert.Empty(t, d.logbuf.Logs())
 			},
 		},
 		{
 			name: "ack task with idempotency key",
 			run: func(t *testing.T, d testDeps) {
 				d.mockIdempotencyKeyCache.EXPECT().
-					HasTaskAck(gomock.Eq(idempotencyKey)).
-					Return(false)
+					HasTaskAck(gomock.Any(), gomock.Eq(idempotencyKey)).
+					Return(false, nil)
+
+				d.mockIdempotencyKeyCache.EXPECT().
+					Transactional().
+					Return(true)
+
+				d.mockTaskRepository.EXPECT().
+					GetProcessingWithID(gomock.Any(), gomock.Eq(taskID)).
+					Return(processingTask, nil)
 
 				d.mockTaskRepository.EXPECT().
 					Complete(gomock.Any(), gomock.Eq(taskID)).
 					Return(nil)
 
 				d.mockIdempotencyKeyCache.EXPECT().
-					SetTaskAck(gomock.Eq(idempotencyKey))
+					SetTaskAck(gomock.Any(), gomock.Eq(idempotencyKey)).
+					Do(func(ctx context.Context, _ string) {
+						assert.True(t, trx.Exists(ctx), "transaction exists")
+					}).
+					Return(nil)
 
-				err := d.service.Ack(ctx, taskID, ops.Pointer(idempotencyKey))
+				err := d.service.Ack(ctx, &AckRequest{
+					TaskID:         taskID,
+					LeaseToken:     leaseToken,
+					IdempotencyKey: ops.Pointer(idempotencyKey),
+				})
 				assert.NoError(t, err)
+				assert.Empty(t, d.logbuf.Logs())
 			},
 		},
 		{
 			name: "idempotency key exists in cache",
 			run: func(t *testing.T, d testDeps) {
 				d.mockIdempotencyKeyCache.EXPECT().
-					HasTaskAck(gomock.Eq(idempotencyKey)).
+					HasTaskAck(gomock.Any(), gomock.Eq(idempotencyKey)).
+					Return(true, nil)
+
+				err := d.service.Ack(ctx, &AckRequest{
+					TaskID:         taskID,
+					LeaseToken:     leaseToken,
+					IdempotencyKey: ops.Pointer(idempotencyKey),
+				})
+				assert.NoError(t, err)
+				assert.Empty(t, d.logbuf.Logs())
+			},
+		},
+		{
+			name: "idempotency key set after commit",
+			run: func(t *testing.T, d testDeps) {
+				d.mockIdempotencyKeyCache.EXPECT().
+					HasTaskAck(gomock.Any(), gomock.Eq(idempotencyKey)).
+					Return(false, nil)
+
+				d.mockIdempotencyKeyCache.EXPECT().
+					Transactional().
+					Return(false)
+
+				gomock.InOrder(
+					d.mockTaskRepository.EXPECT().
+						GetProcessingWithID(gomock.Any(), gomock.Eq(taskID)).
+						Return(processingTask, nil),
+					d.mockTaskRepository.EXPECT().
+						Complete(gomock.Any(), gomock.Eq(taskID)).
+						Return(nil),
+					d.mockIdempotencyKeyCache.EXPECT().
+						SetTaskAck(gomock.Any(), gomock.Eq(idempotencyKey)).
+						Do(func(ctx context.Context, _ string) {
+							assert.False(t, trx.Exists(ctx), "transaction is committed")
+						}).
+						Return(nil),
+				)
+
+				err := d.service.Ack(ctx, &AckRequest{
+					TaskID:         taskID,
+					LeaseToken:     leaseToken,
+					IdempotencyKey: ops.Pointer(idempotencyKey),
+				})
+				assert.NoError(t, err)
+				assert.Empty(t, d.logbuf.Logs())
+			},
+		},
+		{
+			name: "idempotency key is not set after rollback",
+			run: func(t *testing.T, d testDeps) {
+				d.mockIdempotencyKeyCache.EXPECT().
+					HasTaskAck(gomock.Any(), gomock.Eq(idempotencyKey)).
+					Return(false, nil)
+
+				d.mockIdempotencyKeyCache.EXPECT().
+					Transactional().
+					Return(false)
+
+				d.mockTaskRepository.EXPECT().
+					GetProcessingWithID(gomock.Any(), gomock.Eq(taskID)).
+					Return(processingTask, nil)
+
+				d.mockTaskRepository.EXPECT().
+					Complete(gomock.Any(), gomock.Eq(taskID)).
+					Return(errors.New("test error"))
+
+				err := d.service.Ack(ctx, &AckRequest{
+					TaskID:         taskID,
+					LeaseToken:     leaseToken,
+					IdempotencyKey: ops.Pointer(idempotencyKey),
+				})
+				assert.EqualError(t, err, "complete task: test error")
+				assert.Empty(t, d.logbuf.Logs())
+			},
+		},
+		{
+			name: "idempotency key stored by a concurrent ack",
+			run: func(t *testing.T, d testDeps) {
+				d.mockIdempotencyKeyCache.EXPECT().
+					HasTaskAck(gomock.Any(), gomock.Eq(idempotencyKey)).
+					Return(false, nil)
+
+				d.mockIdempotencyKeyCache.EXPECT().
+					Transactional().
 					Return(true)
 
-				err := d.service.Ack(ctx, taskID, ops.Pointer(idempotencyKey))
+				d.mockTaskRepository.EXPECT().
+					GetProcessingWithID(gomock.Any(), gomock.Eq(taskID)).
+					Return(processingTask, nil)
+
+				d.mockTaskRepository.EXPECT().
+					Complete(gomock.Any(), gomock.Eq(taskID)).
+					Return(nil)
+
+				d.mockIdempotencyKeyCache.EXPECT().
+					SetTaskAck(gomock.Any(), gomock.Eq(idempotencyKey)).
+					Return(repository.ErrAlreadyExists)
+
+				err := d.service.Ack(ctx, &AckRequest{
+					TaskID:         taskID,
+					LeaseToken:     leaseToken,
+					IdempotencyKey: ops.Pointer(idempotencyKey),
+				})
 				assert.NoError(t, err)
+				assert.Empty(t, d.logbuf.Logs())
+			},
+		},
+		{
+			name: "stale lease token",
+			run: func(t *testing.T, d testDeps) {
+				d.mockTaskRepository.EXPECT().
+					GetProcessingWithID(gomock.Any(), gomock.Eq(taskID)).
+					Return(&domain.Task{
+						ID:         taskID,
+						Status:     domain.TaskStatusProcessing,
+						LeaseToken: "newLeaseToken",
+					}, nil)
+
+				err := d.service.Ack(ctx, &AckRequest{
+					TaskID:     taskID,
+					LeaseToken: leaseToken,
+				})
+				assert.ErrorIs(t, err, domain.ErrLeaseLost)
+				assert.Empty(t, d.logbuf.Logs())
+			},
+		},
+		{
+			name: "lock expired",
+			run: func(t *testing.T, d testDeps) {
+				d.mockTaskRepository.EXPECT().
+					GetProcessingWithID(gomock.Any(), gomock.Eq(taskID)).
+					Return(nil, repository.ErrNotFound)
+
+				err := d.service.Ack(ctx, &AckRequest{
+					TaskID:     taskID,
+					LeaseToken: leaseToken,
+				})
+				assert.ErrorIs(t, err, domain.ErrLeaseLost)
+				assert.Empty(t, d.logbuf.Logs())
+			},
+		},
+		{
+			name: "ack task twice",
+			run: func(t *testing.T, d testDeps) {
+				gomock.InOrder(
+					d.mockTaskRepository.EXPECT().
+						GetProcessingWithID(gomock.Any(), gomock.Eq(taskID)).
+						Return(processingTask, nil),
+					d.mockTaskRepository.EXPECT().
+						Complete(gomock.Any(), gomock.Eq(taskID)).
+						Return(nil),
+					d.mockTaskRepository.EXPECT().
+						GetProcessingWithID(gomock.Any(), gomock.Eq(taskID)).
+						Return(nil, repository.ErrNotFound),
+				)
+
+				req := &AckRequest{
+					TaskID:     taskID,
+					LeaseToken: leaseToken,
+				}
+				assert.NoError(t, d.service.Ack(ctx, req))
+				assert.ErrorIs(t, d.service.Ack(ctx, req), domain.ErrLeaseLost)
+				assert.Empty(t, d.logbuf.Logs())
+			},
+		},
+		{
+			name: "get processing task error",
+			run: func(t *testing.T, d testDeps) {
+				d.mockTaskRepository.EXPECT().
+					GetProcessingWithID(gomock.Any(), gomock.Eq(taskID)).
+					Return(nil, errors.New("test error"))
+
+				err := d.service.Ack(ctx, &AckRequest{
+					TaskID:     taskID,
+					LeaseToken: leaseToken,
+				})
+				assert.EqualError(t, err, "get processing task: test error")
+				assert.Empty(t, d.logbuf.Logs())
 			},
 		},
 		{
 			name: "complete task error",
 			run: func(t *testing.T, d testDeps) {
+				d.mockTaskRepository.EXPECT().
+					GetProcessingWithID(gomock.Any(), gomock.Eq(taskID)).
+					Return(processingTask, nil)
+
 				d.mockTaskRepository.EXPECT().
 					Complete(gomock.Any(), gomock.Eq(taskID)).
 					Return(errors.New("test error"))
 
-				err := d.service.Ack(ctx, taskID, nil)
+				err := d.service.Ack(ctx, &AckRequest{
+					TaskID:     taskID,
+					LeaseToken: leaseToken,
+				})
 				assert.EqualError(t, err, "complete task: test error")
+				assert.Empty(t, d.logbuf.Logs())
 			},
 		},
 	} {
@@ -92,12 +313,13 @@ func TestService_Ack(t *testing.T) {
 
 			mockIdempotencyKeyCache := NewMockidempotencyKeyCache(mc)
 			mockTaskRepository := NewMocktaskRepository(mc)
-			logger, _ := logimpl.NewTestLogger()
+			logger, logbuf := logimpl.NewTestLogger()
 
 			tc.run(t, testDeps{
 				mockIdempotencyKeyCache: mockIdempotencyKeyCache,
 				mockTaskRepository:      mockTaskRepository,
-				service:                 NewService(nil, mockIdempotencyKeyCache, mockTaskRepository, logger),
+				logbuf:                  logbuf,
+				service:                 NewService(nil, nil, mockIdempotencyKeyCache, nil, mockTaskRepository, metricsimpl.NewRegistry(), logger),
 			})
 		})
 	}
@@ -107,12 +329,23 @@ func TestService_Nack(t *testing.T) {
 	var (
 		ctx            = context.Background()
 		taskID         = "testTaskID"
+		queueName      = "testQueueName"
+		leaseToken     = "testLeaseToken"
 		idempotencyKey = "testIdempotencyKey"
+		maxAttempts    = 3
+
+		queue = &domain.Queue{
+			Name:        queueName,
+			Backoff:     domain.Backoff{Policy: domain.BackoffPolicyExponential, Initial: time.Minute},
+			MaxAttempts: maxAttempts,
+		}
 	)
 
 	type testDeps struct {
 		mockClock               *Mockclock
+		mockRandom              *Mockrandom
 		mockIdempotencyKeyCache *MockidempotencyKeyCache
+		mockQueueRepository     *MockqueueRepository
 		mockTaskRepository      *MocktaskRepository
 		logbuf                  log.Buffer
 		service                 *Service
@@ -129,12 +362,15 @@ func TestService_Nack(t *testing.T) {
 
 				expTaskFromRepo := &domain.Task{
 					ID:          taskID,
+					QueueName:   queueName,
 					Status:      domain.TaskStatusProcessing,
 					LockedUntil: ops.Pointer(getTime(t, "2006-01-02 15:08:08")),
+					LeaseToken:  leaseToken,
 				}
 
 				expTaskAfterTransition := &domain.Task{
 					ID:               taskID,
+					QueueName:        queueName,
 					Status:           domain.TaskStatusFailed,
 					LockedUntil:      ops.Pointer(getTime(t, "2006-01-02 15:06:05")),
 					LastFailDuration: ops.Pointer(time.Minute),
@@ -151,6 +387,13 @@ func TestService_Nack(t *testing.T) {
 					}).
 					Return(expTaskFromRepo, nil)
 
+				d.mockQueueRepository.EXPECT().
+					Get(gomock.Any(), gomock.Eq(queueName)).
+					Do(func(ctx context.Context, _ string) {
+						assert.True(t, trx.Exists(ctx), "transaction exists")
+					}).
+					Return(queue, nil)
+
 				d.mockTaskRepository.EXPECT().
 					Save(gomock.Any(), gomock.Eq(expTaskAfterTransition)).
 					Do(func(ctx context.Context, _ *domain.Task) {
@@ -158,7 +401,10 @@ func TestService_Nack(t *testing.T) {
 					}).
 					Return(nil)
 
-				err := d.service.Nack(ctx, taskID, nil)
+				err := d.service.Nack(ctx, &NackRequest{
+					TaskID:     taskID,
+					LeaseToken: leaseToken,
+				})
 				assert.NoError(t, err)
 				assert.Empty(t, d.logbuf.Logs())
 			},
@@ -170,13 +416,16 @@ func TestService_Nack(t *testing.T) {
 
 				expTaskFromRepo := &domain.Task{
 					ID:               taskID,
+					QueueName:        queueName,
 					Status:           domain.TaskStatusProcessing,
 					LockedUntil:      ops.Pointer(getTime(t, "2006-01-02 15:08:08")),
 					LastFailDuration: ops.Pointer(4 * time.Minute),
+					LeaseToken:       leaseToken,
 				}
 
 				expTaskAfterTransition := &domain.Task{
 					ID:               taskID,
+					QueueName:        queueName,
 					Status:           domain.TaskStatusFailed,
 					LockedUntil:      ops.Pointer(getTime(t, "2006-01-02 15:13:05")),
 					LastFailDuration: ops.Pointer(8 * time.Minute),
@@ -193,6 +442,13 @@ func TestService_Nack(t *testing.T) {
 					}).
 					Return(expTaskFromRepo, nil)
 
+				d.mockQueueRepository.EXPECT().
+					Get(gomock.Any(), gomock.Eq(queueName)).
+					Do(func(ctx context.Context, _ string) {
+						assert.True(t, trx.Exists(ctx), "transaction exists")
+					}).
+					Return(queue, nil)
+
 				d.mockTaskRepository.EXPECT().
 					Save(gomock.Any(), gomock.Eq(expTaskAfterTransition)).
 					Do(func(ctx context.Context, _ *domain.Task) {
@@ -200,7 +456,161 @@ func TestService_Nack(t *testing.T) {
 					}).
 					Return(nil)
 
-				err := d.service.Nack(ctx, taskID, nil)
+				err := d.service.Nack(ctx, &NackRequest{
+					TaskID:     taskID,
+					LeaseToken: leaseToken,
+				})
+				assert.NoError(t, err)
+				assert.Empty(t, d.logbuf.Logs())
+			},
+		},
+		{
+			name: "nack task with jittered backoff",
+			run: func(t *testing.T, d testDeps) {
+				now := getTime(t, "2006-01-02 15:05:05")
+
+				jitterQueue := &domain.Queue{
+					Name:        queueName,
+					Backoff:     domain.Backoff{Policy: domain.BackoffPolicyDecorrelatedJitter, Initial: time.Minute},
+					MaxAttempts: maxAttempts,
+				}
+
+				expTaskFromRepo := &domain.Task{
+					ID:               taskID,
+					QueueName:        queueName,
+					Status:           domain.TaskStatusProcessing,
+					LockedUntil:      ops.Pointer(getTime(t, "2006-01-02 15:08:08")),
+					LastFailDuration: ops.Pointer(2 * time.Minute),
+					LeaseToken:       leaseToken,
+				}
+
+				expTaskAfterTransition := &domain.Task{
+					ID:               taskID,
+					QueueName:        queueName,
+					Status:           domain.TaskStatusFailed,
+					LockedUntil:      ops.Pointer(getTime(t, "2006-01-02 15:08:05")),
+					LastFailDuration: ops.Pointer(3 * time.Minute),
+				}
+
+				d.mockClock.EXPECT().
+					Now().
+					Return(now)
+
+				d.mockTaskRepository.EXPECT().
+					GetProcessingWithID(gomock.Any(), gomock.Eq(taskID)).
+					Return(expTaskFromRepo, nil)
+
+				d.mockQueueRepository.EXPECT().
+					Get(gomock.Any(), gomock.Eq(queueName)).
+					Return(jitterQueue, nil)
+
+				d.mockRandom.EXPECT().
+					Int64N(gomock.Eq(int64(5 * time.Minute))).
+					Return(int64(2 * time.Minute))
+
+				d.mockTaskRepository.EXPECT().
+					Save(gomock.Any(), gomock.Eq(expTaskAfterTransition)).
+					Return(nil)
+
+				err := d.service.Nack(ctx, &NackRequest{
+					TaskID:     taskID,
+					LeaseToken: leaseToken,
+				})
+				assert.NoError(t, err)
+				assert.Empty(t, d.logbuf.Logs())
+			},
+		},
+		{
+			name: "nack task with retry after",
+			run: func(t *testing.T, d testDeps) {
+				now := getTime(t, "2006-01-02 15:05:05")
+
+				expTaskFromRepo := &domain.Task{
+					ID:               taskID,
+					QueueName:        queueName,
+					Status:           domain.TaskStatusProcessing,
+					LockedUntil:      ops.Pointer(getTime(t, "2006-01-02 15:08:08")),
+					LastFailDuration: ops.Pointer(4 * time.Minute),
+					LeaseToken:       leaseToken,
+				}
+
+				expTaskAfterTransition := &domain.Task{
+					ID:               taskID,
+					QueueName:        queueName,
+					Status:           domain.TaskStatusFailed,
+					LockedUntil:      ops.Pointer(getTime(t, "2006-01-02 15:05:35")),
+					LastFailDuration: ops.Pointer(30 * time.Second),
+				}
+
+				d.mockClock.EXPECT().
+					Now().
+					Return(now)
+
+				d.mockTaskRepository.EXPECT().
+					GetProcessingWithID(gomock.Any(), gomock.Eq(taskID)).
+					Return(expTaskFromRepo, nil)
+
+				d.mockQueueRepository.EXPECT().
+					Get(gomock.Any(), gomock.Eq(queueName)).
+					Return(queue, nil)
+
+				d.mockTaskRepository.EXPECT().
+					Save(gomock.Any(), gomock.Eq(expTaskAfterTransition)).
+					Return(nil)
+
+				err := d.service.Nack(ctx, &NackRequest{
+					TaskID:     taskID,
+					LeaseToken: leaseToken,
+					RetryAfter: ops.Pointer(30 * time.Second),
+				})
+				assert.NoError(t, err)
+				assert.Empty(t, d.logbuf.Logs())
+			},
+		},
+		{
+			name: "nack task with exhausted attempts",
+			run: func(t *testing.T, d testDeps) {
+				expTaskFromRepo := &domain.Task{
+					ID:               taskID,
+					QueueName:        queueName,
+					Status:           domain.TaskStatusProcessing,
+					LockedUntil:      ops.Pointer(getTime(t, "2006-01-02 15:08:08")),
+					LastFailDuration: ops.Pointer(4 * time.Minute),
+					LeaseToken:       leaseToken,
+					Attempts:         maxAttempts,
+				}
+
+				expTaskAfterTransition := &domain.Task{
+					ID:               taskID,
+					QueueName:        queueName,
+					Status:           domain.TaskStatusDead,
+					LastFailDuration: ops.Pointer(4 * time.Minute),
+					Attempts:         maxAttempts,
+				}
+
+				d.mockClock.EXPECT().
+					Now().
+					Return(getTime(t, "2006-01-02 15:05:05"))
+
+				d.mockTaskRepository.EXPECT().
+					GetProcessingWithID(gomock.Any(), gomock.Eq(taskID)).
+					Return(expTaskFromRepo, nil)
+
+				d.mockQueueRepository.EXPECT().
+					Get(gomock.Any(), gomock.Eq(queueName)).
+					Do(func(ctx context.Context, _ string) {
+						assert.True(t, trx.Exists(ctx), "transaction exists")
+					}).
+					Return(queue, nil)
+
+				d.mockTaskRepository.EXPECT().
+					Save(gomock.Any(), gomock.Eq(expTaskAfterTransition)).
+					Return(nil)
+
+				err := d.service.Nack(ctx, &NackRequest{
+					TaskID:     taskID,
+					LeaseToken: leaseToken,
+				})
 				assert.NoError(t, err)
 				assert.Empty(t, d.logbuf.Logs())
 			},
@@ -212,25 +622,32 @@ func TestService_Nack(t *testing.T) {
 
 				expTaskFromRepo := &domain.Task{
 					ID:          taskID,
+					QueueName:   queueName,
 					Status:      domain.TaskStatusProcessing,
 					LockedUntil: ops.Pointer(getTime(t, "2006-01-02 15:08:08")),
+					LeaseToken:  leaseToken,
 				}
 
 				expTaskAfterTransition := &domain.Task{
 					ID:               taskID,
+					QueueName:        queueName,
 					Status:           domain.TaskStatusFailed,
 					LockedUntil:      ops.Pointer(getTime(t, "2006-01-02 15:06:05")),
 					LastFailDuration: ops.Pointer(time.Minute),
 				}
 
 				d.mockIdempotencyKeyCache.EXPECT().
-					HasTaskNack(gomock.Eq(idempotencyKey)).
-					Return(false)
+					HasTaskNack(gomock.Any(), gomock.Eq(idempotencyKey)).
+					Return(false, nil)
 
 				d.mockClock.EXPECT().
 					Now().
 					Return(now)
 
+				d.mockIdempotencyKeyCache.EXPECT().
+					Transactional().
+					Return(true)
+
 				d.mockTaskRepository.EXPECT().
 					GetProcessingWithID(gomock.Any(), gomock.Eq(taskID)).
 					Do(func(ctx context.Context, _ string) {
@@ -238,6 +655,13 @@ func TestService_Nack(t *testing.T) {
 					}).
 					Return(expTaskFromRepo, nil)
 
+				d.mockQueueRepository.EXPECT().
+					Get(gomock.Any(), gomock.Eq(queueName)).
+					Do(func(ctx context.Context, _ string) {
+						assert.True(t, trx.Exists(ctx), "transaction exists")
+					}).
+					Return(queue, nil)
+
 				d.mockTaskRepository.EXPECT().
 					Save(gomock.Any(), gomock.Eq(expTaskAfterTransition)).
 					Do(func(ctx context.Context, _ *domain.Task) {
@@ -246,9 +670,17 @@ func TestService_Nack(t *testing.T) {
 					Return(nil)
 
 				d.mockIdempotencyKeyCache.EXPECT().
-					SetTaskNack(gomock.Eq(idempotencyKey))
+					SetTaskNack(gomock.Any(), gomock.Eq(idempotencyKey)).
+					Do(func(ctx context.Context, _ string) {
+						assert.True(t, trx.Exists(ctx), "transaction exists")
+					}).
+					Return(nil)
 
-				err := d.service.Nack(ctx, taskID, ops.Pointer(idempotencyKey))
+				err := d.service.Nack(ctx, &NackRequest{
+					TaskID:         taskID,
+					LeaseToken:     leaseToken,
+					IdempotencyKey: ops.Pointer(idempotencyKey),
+				})
 				assert.NoError(t, err)
 				assert.Empty(t, d.logbuf.Logs())
 			},
@@ -257,10 +689,14 @@ func TestService_Nack(t *testing.T) {
 			name: "idempotency key exists in cache",
 			run: func(t *testing.T, d testDeps) {
 				d.mockIdempotencyKeyCache.EXPECT().
-					HasTaskNack(gomock.Eq(idempotencyKey)).
-					Return(true)
-
-				err := d.service.Nack(ctx, taskID, ops.Pointer(idempotencyKey))
+					HasTaskNack(gomock.Any(), gomock.Eq(idempotencyKey)).
+					Return(true, nil)
+
+				err := d.service.Nack(ctx, &NackRequest{
+					TaskID:         taskID,
+					LeaseToken:     leaseToken,
+					IdempotencyKey: ops.Pointer(idempotencyKey),
+				})
 				assert.NoError(t, err)
 				assert.Empty(t, d.logbuf.Logs())
 			},
@@ -272,12 +708,15 @@ func TestService_Nack(t *testing.T) {
 
 				expTaskFromRepo := &domain.Task{
 					ID:          taskID,
+					QueueName:   queueName,
 					Status:      domain.TaskStatusProcessing,
 					LockedUntil: ops.Pointer(getTime(t, "2006-01-02 15:08:08")),
+					LeaseToken:  leaseToken,
 				}
 
 				expTaskAfterTransition := &domain.Task{
 					ID:               taskID,
+					QueueName:        queueName,
 					Status:           domain.TaskStatusFailed,
 					LockedUntil:      ops.Pointer(getTime(t, "2006-01-02 15:06:05")),
 					LastFailDuration: ops.Pointer(time.Minute),
@@ -294,6 +733,13 @@ func TestService_Nack(t *testing.T) {
 					}).
 					Return(expTaskFromRepo, nil)
 
+				d.mockQueueRepository.EXPECT().
+					Get(gomock.Any(), gomock.Eq(queueName)).
+					Do(func(ctx context.Context, _ string) {
+						assert.True(t, trx.Exists(ctx), "transaction exists")
+					}).
+					Return(queue, nil)
+
 				d.mockTaskRepository.EXPECT().
 					Save(gomock.Any(), gomock.Eq(expTaskAfterTransition)).
 					Do(func(ctx context.Context, _ *domain.Task) {
@@ -305,7 +751,10 @@ func TestService_Nack(t *testing.T) {
 					}).
 					Return(nil)
 
-				err := d.service.Nack(ctx, taskID, nil)
+				err := d.service.Nack(ctx, &NackRequest{
+					TaskID:     taskID,
+					LeaseToken: leaseToken,
+				})
 				assert.EqualError(t, err, "commit trx: test error")
 				assert.Empty(t, d.logbuf.Logs())
 			},
@@ -317,12 +766,15 @@ func TestService_Nack(t *testing.T) {
 
 				expTaskFromRepo := &domain.Task{
 					ID:          taskID,
+					QueueName:   queueName,
 					Status:      domain.TaskStatusProcessing,
 					LockedUntil: ops.Pointer(getTime(t, "2006-01-02 15:08:08")),
+					LeaseToken:  leaseToken,
 				}
 
 				expTaskAfterTransition := &domain.Task{
 					ID:               taskID,
+					QueueName:        queueName,
 					Status:           domain.TaskStatusFailed,
 					LockedUntil:      ops.Pointer(getTime(t, "2006-01-02 15:06:05")),
 					LastFailDuration: ops.Pointer(time.Minute),
@@ -339,6 +791,13 @@ func TestService_Nack(t *testing.T) {
 					}).
 					Return(expTaskFromRepo, nil)
 
+				d.mockQueueRepository.EXPECT().
+					Get(gomock.Any(), gomock.Eq(queueName)).
+					Do(func(ctx context.Context, _ string) {
+						assert.True(t, trx.Exists(ctx), "transaction exists")
+					}).
+					Return(queue, nil)
+
 				d.mockTaskRepository.EXPECT().
 					Save(gomock.Any(), gomock.Eq(expTaskAfterTransition)).
 					Do(func(ctx context.Context, _ *domain.Task) {
@@ -346,7 +805,10 @@ func TestService_Nack(t *testing.T) {
 					}).
 					Return(errors.New("test error"))
 
-				err := d.service.Nack(ctx, taskID, nil)
+				err := d.service.Nack(ctx, &NackRequest{
+					TaskID:     taskID,
+					LeaseToken: leaseToken,
+				})
 				assert.EqualError(t, err, "save task: test error")
 				assert.Empty(t, d.logbuf.Logs())
 			},
@@ -358,12 +820,15 @@ func TestService_Nack(t *testing.T) {
 
 				expTaskFromRepo := &domain.Task{
 					ID:          taskID,
+					QueueName:   queueName,
 					Status:      domain.TaskStatusProcessing,
 					LockedUntil: ops.Pointer(getTime(t, "2006-01-02 15:08:08")),
+					LeaseToken:  leaseToken,
 				}
 
 				expTaskAfterTransition := &domain.Task{
 					ID:               taskID,
+					QueueName:        queueName,
 					Status:           domain.TaskStatusFailed,
 					LockedUntil:      ops.Pointer(getTime(t, "2006-01-02 15:06:05")),
 					LastFailDuration: ops.Pointer(time.Minute),
@@ -380,6 +845,13 @@ func TestService_Nack(t *testing.T) {
 					}).
 					Return(expTaskFromRepo, nil)
 
+				d.mockQueueRepository.EXPECT().
+					Get(gomock.Any(), gomock.Eq(queueName)).
+					Do(func(ctx context.Context, _ string) {
+						assert.True(t, trx.Exists(ctx), "transaction exists")
+					}).
+					Return(queue, nil)
+
 				d.mockTaskRepository.EXPECT().
 					Save(gomock.Any(), gomock.Eq(expTaskAfterTransition)).
 					Do(func(ctx context.Context, _ *domain.Task) {
@@ -391,7 +863,10 @@ func TestService_Nack(t *testing.T) {
 					}).
 					Return(errors.New("test save task error"))
 
-				err := d.service.Nack(ctx, taskID, nil)
+				err := d.service.Nack(ctx, &NackRequest{
+					TaskID:     taskID,
+					LeaseToken: leaseToken,
+				})
 				assert.EqualError(t, err, "save task: test save task error")
 
 				logs := d.logbuf.Logs()
@@ -406,6 +881,30 @@ func TestService_Nack(t *testing.T) {
 				}`, logs[0])
 			},
 		},
+		{
+			name: "stale lease token",
+			run: func(t *testing.T, d testDeps) {
+				d.mockClock.EXPECT().
+					Now().
+					Return(getTime(t, "2006-01-02 15:05:05"))
+
+				d.mockTaskRepository.EXPECT().
+					GetProcessingWithID(gomock.Any(), gomock.Eq(taskID)).
+					Return(&domain.Task{
+						ID:          taskID,
+						Status:      domain.TaskStatusProcessing,
+						LockedUntil: ops.Pointer(getTime(t, "2006-01-02 15:08:08")),
+						LeaseToken:  "newLeaseToken",
+					}, nil)
+
+				err := d.service.Nack(ctx, &NackRequest{
+					TaskID:     taskID,
+					LeaseToken: leaseToken,
+				})
+				assert.ErrorIs(t, err, domain.ErrLeaseLost)
+				assert.Empty(t, d.logbuf.Logs())
+			},
+		},
 		{
 			name: "get processing task error",
 			run: func(t *testing.T, d testDeps) {
@@ -420,13 +919,16 @@ func TestService_Nack(t *testing.T) {
 					}).
 					Return(nil, errors.New("test error"))
 
-				err := d.service.Nack(ctx, taskID, nil)
+				err := d.service.Nack(ctx, &NackRequest{
+					TaskID:     taskID,
+					LeaseToken: leaseToken,
+				})
 				assert.EqualError(t, err, "get processing task: test error")
 				assert.Empty(t, d.logbuf.Logs())
 			},
 		},
 		{
-			name: "task not found",
+			name: "lock expired",
 			run: func(t *testing.T, d testDeps) {
 				d.mockClock.EXPECT().
 					Now().
@@ -439,8 +941,49 @@ func TestService_Nack(t *testing.T) {
 					}).
 					Return(nil, repository.ErrNotFound)
 
-				err := d.service.Nack(ctx, taskID, nil)
-				assert.NoError(t, err)
+				err := d.service.Nack(ctx, &NackRequest{
+					TaskID:     taskID,
+					LeaseToken: leaseToken,
+				})
+				assert.ErrorIs(t, err, domain.ErrLeaseLost)
+				assert.Empty(t, d.logbuf.Logs())
+			},
+		},
+		{
+			name: "nack task twice",
+			run: func(t *testing.T, d testDeps) {
+				d.mockClock.EXPECT().
+					Now().
+					Return(getTime(t, "2006-01-02 15:05:05")).
+					Times(2)
+
+				gomock.InOrder(
+					d.mockTaskRepository.EXPECT().
+						GetProcessingWithID(gomock.Any(), gomock.Eq(taskID)).
+						Return(&domain.Task{
+							ID:          taskID,
+							QueueName:   queueName,
+							Status:      domain.TaskStatusProcessing,
+							LockedUntil: ops.Pointer(getTime(t, "2006-01-02 15:08:08")),
+							LeaseToken:  leaseToken,
+						}, nil),
+					d.mockQueueRepository.EXPECT().
+						Get(gomock.Any(), gomock.Eq(queueName)).
+						Return(queue, nil),
+					d.mockTaskRepository.EXPECT().
+						Save(gomock.Any(), gomock.Any()).
+						Return(nil),
+					d.mockTaskRepository.EXPECT().
+						GetProcessingWithID(gomock.Any(), gomock.Eq(taskID)).
+						Return(nil, repository.ErrNotFound),
+				)
+
+				req := &NackRequest{
+					TaskID:     taskID,
+					LeaseToken: leaseToken,
+				}
+				assert.NoError(t, d.service.Nack(ctx, req))
+				assert.ErrorIs(t, d.service.Nack(ctx, req), domain.ErrLeaseLost)
 				assert.Empty(t, d.logbuf.Logs())
 			},
 		},
@@ -450,16 +993,208 @@ func TestService_Nack(t *testing.T) {
 			defer mc.Finish()
 
 			mockClock := NewMockclock(mc)
+			mockRandom := NewMockrandom(mc)
 			mockIdempotencyKeyCache := NewMockidempotencyKeyCache(mc)
+			mockQueueRepository := NewMockqueueRepository(mc)
 			mockTaskRepository := NewMocktaskRepository(mc)
 			logger, logbuf := logimpl.NewTestLogger()
 
 			tc.run(t, testDeps{
 				mockClock:               mockClock,
+				mockRandom:              mockRandom,
 				mockIdempotencyKeyCache: mockIdempotencyKeyCache,
+				mockQueueRepository:     mockQueueRepository,
 				mockTaskRepository:      mockTaskRepository,
 				logbuf:                  logbuf,
-				service:                 NewService(mockClock, mockIdempotencyKeyCache, mockTaskRepository, logger),
+				service:                 NewService(mockClock, mockRandom, mockIdempotencyKeyCache, mockQueueRepository, mockTaskRepository, metricsimpl.NewRegistry(), logger),
+			})
+		})
+	}
+}
+
+func TestService_Extend(t *testing.T) {
+	var (
+		ctx        = context.Background()
+		taskID     = "testTaskID"
+		queueName  = "testQueueName"
+		leaseToken = "testLeaseToken"
+	)
+
+	type testDeps struct {
+		mockClock           *Mockclock
+		mockQueueRepository *MockqueueRepository
+		mockTaskRepository  *MocktaskRepository
+		logbuf              log.Buffer
+		service             *Service
+	}
+
+	for _, tc := range []struct {
+		name string
+		run  func(t *testing.T, d testDeps)
+	}{
+		{
+			name: "extend task lock",
+			run: func(t *testing.T, d testDeps) {
+				expTaskAfterTransition := &domain.Task{
+					ID:          taskID,
+					QueueName:   queueName,
+					Status:      domain.TaskStatusProcessing,
+					LockedUntil: ops.Pointer(getTime(t, "2006-01-02 15:28:08")),
+					LeaseToken:  leaseToken,
+				}
+
+				d.mockClock.EXPECT().
+					Now().
+					Return(getTime(t, "2006-01-02 15:05:05"))
+
+				d.mockTaskRepository.EXPECT().
+					GetProcessingWithID(gomock.Any(), gomock.Eq(taskID)).
+					Do(func(ctx context.Context, _ string) {
+						assert.True(t, trx.Exists(ctx), "transaction exists")
+					}).
+					Return(&domain.Task{
+						ID:          taskID,
+						QueueName:   queueName,
+						Status:      domain.TaskStatusProcessing,
+						LockedUntil: ops.Pointer(getTime(t, "2006-01-02 15:08:08")),
+						LeaseToken:  leaseToken,
+					}, nil)
+
+				d.mockQueueRepository.EXPECT().
+					Get(gomock.Any(), gomock.Eq(queueName)).
+					Return(nil, repository.ErrNotFound)
+
+				d.mockTaskRepository.EXPECT().
+					Save(gomock.Any(), gomock.Eq(expTaskAfterTransition)).
+					Do(func(ctx context.Context, _ *domain.Task) {
+						assert.True(t, trx.Exists(ctx), "transaction exists")
+					}).
+					Return(nil)
+
+				task, err := d.service.Extend(ctx, &ExtendRequest{
+					TaskID:     taskID,
+					LeaseToken: leaseToken,
+					Duration:   20 * time.Minute,
+				})
+				assert.NoError(t, err)
+				assert.Equal(t, expTaskAfterTransition, task)
+				assert.Empty(t, d.logbuf.Logs())
+			},
+		},
+		{
+			name: "non-positive duration",
+			run: func(t *testing.T, d testDeps) {
+				for _, duration := range []time.Duration{0, -time.Minute} {
+					task, err := d.service.Extend(ctx, &ExtendRequest{
+						TaskID:     taskID,
+						LeaseToken: leaseToken,
+						Duration:   duration,
+					})
+					assert.ErrorIs(t, err, ErrInvalidDuration)
+					assert.Nil(t, task)
+				}
+				assert.Empty(t, d.logbuf.Logs())
+			},
+		},
+		{
+			name: "lease is lost",
+			run: func(t *testing.T, d testDeps) {
+				d.mockClock.EXPECT().
+					Now().
+					Return(getTime(t, "2006-01-02 15:05:05"))
+
+				d.mockTaskRepository.EXPECT().
+					GetProcessingWithID(gomock.Any(), gomock.Eq(taskID)).
+					Return(nil, repository.ErrNotFound)
+
+				task, err := d.service.Extend(ctx, &ExtendRequest{
+					TaskID:     taskID,
+					LeaseToken: leaseToken,
+					Duration:   20 * time.Minute,
+				})
+				assert.ErrorIs(t, err, domain.ErrLeaseLost)
+				assert.Nil(t, task)
+				assert.Empty(t, d.logbuf.Logs())
+			},
+		},
+		{
+			name: "stale lease token",
+			run: func(t *testing.T, d testDeps) {
+				d.mockClock.EXPECT().
+					Now().
+					Return(getTime(t, "2006-01-02 15:05:05"))
+
+				d.mockTaskRepository.EXPECT().
+					GetProcessingWithID(gomock.Any(), gomock.Eq(taskID)).
+					Return(&domain.Task{
+						ID:          taskID,
+						QueueName:   queueName,
+						Status:      domain.TaskStatusProcessing,
+						LockedUntil: ops.Pointer(getTime(t, "2006-01-02 15:08:08")),
+						LeaseToken:  "newLeaseToken",
+					}, nil)
+
+				task, err := d.service.Extend(ctx, &ExtendRequest{
+					TaskID:     taskID,
+					LeaseToken: leaseToken,
+					Duration:   20 * time.Minute,
+				})
+				assert.ErrorIs(t, err, domain.ErrLeaseLost)
+				assert.Nil(t, task)
+				assert.Empty(t, d.logbuf.Logs())
+			},
+		},
+		{
+			name: "save task error",
+			run: func(t *testing.T, d testDeps) {
+				d.mockClock.EXPECT().
+					Now().
+					Return(getTime(t, "2006-01-02 15:05:05"))
+
+				d.mockTaskRepository.EXPECT().
+					GetProcessingWithID(gomock.Any(), gomock.Eq(taskID)).
+					Return(&domain.Task{
+						ID:          taskID,
+						QueueName:   queueName,
+						Status:      domain.TaskStatusProcessing,
+						LockedUntil: ops.Pointer(getTime(t, "2006-01-02 15:08:08")),
+						LeaseToken:  leaseToken,
+					}, nil)
+
+				d.mockQueueRepository.EXPECT().
+					Get(gomock.Any(), gomock.Eq(queueName)).
+					Return(nil, repository.ErrNotFound)
+
+				d.mockTaskRepository.EXPECT().
+					Save(gomock.Any(), gomock.Any()).
+					Return(errors.New("test error"))
+
+				task, err := d.service.Extend(ctx, &ExtendRequest{
+					TaskID:     taskID,
+					LeaseToken: leaseToken,
+					Duration:   20 * time.Minute,
+				})
+				assert.EqualError(t, err, "save task: test error")
+				assert.Nil(t, task)
+				assert.Empty(t, d.logbuf.Logs())
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mc := gomock.NewController(t)
+			defer mc.Finish()
+
+			mockClock := NewMockclock(mc)
+			mockQueueRepository := NewMockqueueRepository(mc)
+			mockTaskRepository := NewMocktaskRepository(mc)
+			logger, logbuf := logimpl.NewTestLogger()
+
+			tc.run(t, testDeps{
+				mockClock:           mockClock,
+				mockQueueRepository: mockQueueRepository,
+				mockTaskRepository:  mockTaskRepository,
+				logbuf:              logbuf,
+				service:             NewService(mockClock, nil, nil, mockQueueRepository, mockTaskRepository, metricsimpl.NewRegistry(), logger),
 			})
 		})
 	}
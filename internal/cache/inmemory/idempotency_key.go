@@ -1,68 +1,175 @@
 package inmemory
 
 import (
+	"container/list"
+	"context"
 	"sync"
 	"time"
 
 	"github.com/art-es/queue-service/internal/app/domain"
+	"github.com/art-es/queue-service/internal/app/repository"
 )
 
-const cacheTTL = time.Minute
+const (
+	scopeQueuePush = "queue_push"
+	scopeTaskAck   = "task_ack"
+	scopeTaskNack  = "task_nack"
+)
+
+type clock interface {
+	Now() time.Time
+}
+
+// cacheKey scopes idempotency keys per operation and, for pushes, per queue.
+type cacheKey struct {
+	scope     string
+	queueName string
+	key       string
+}
 
+type cacheEntry struct {
+	key       cacheKey
+	record    *domain.PushRecord // Set for pushes only
+	expiresAt time.Time
+	lruElem   *list.Element
+	expElem   *list.Element
+}
+
+// IdempotencyKeyCache keeps idempotency keys for the TTL, but no more than maxEntries of them,
+// evicting the least recently used ones. It is safe for concurrent use.
 type IdempotencyKeyCache struct {
-	mapQueuePush sync.Map
-	mapTaskAck   sync.Map
-	mapTaskNack  sync.Map
+	clock      clock
+	ttl        time.Duration
+	maxEntries int
+
+	mu      sync.Mutex
+	entries map[cacheKey]*cacheEntry
+	lru     *list.List // Front is the most recently used
+	expiry  *list.List // Front expires first, all entries have the same TTL
 }
 
-func NewIdempotencyKeyCache() *IdempotencyKeyCache {
+func NewIdempotencyKeyCache(clock clock, ttl time.Duration, maxEntries int) *IdempotencyKeyCache {
 	return &IdempotencyKeyCache{
-		mapQueuePush: sync.Map{},
-		mapTaskAck:   sync.Map{},
-		mapTaskNack:  sync.Map{},
+		clock:      clock,
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    make(map[cacheKey]*cacheEntry),
+		lru:        list.New(),
+		expiry:     list.New(),
 	}
 }
 
-func (c *IdempotencyKeyCache) GetQueuePush(key string) (*domain.Task, bool) {
-	if v, ok := c.mapQueuePush.Load(key); ok {
-		return v.(*domain.Task), true
+func (c *IdempotencyKeyCache) GetQueuePush(_ context.Context, queueName, key string) (*domain.PushRecord, bool, error) {
+	entry, ok := c.get(cacheKey{scope: scopeQueuePush, queueName: queueName, key: key})
+	if !ok {
+		return nil, false, nil
 	}
-	return nil, false
+	return entry.record, true, nil
+}
+
+func (c *IdempotencyKeyCache) SetQueuePush(_ context.Context, queueName, key string, record *domain.PushRecord) error {
+	return c.set(cacheKey{scope: scopeQueuePush, queueName: queueName, key: key}, record)
 }
 
-func (c *IdempotencyKeyCache) SetQueuePush(key string, result *domain.Task) {
-	c.mapQueuePush.Store(key, result)
+func (c *IdempotencyKeyCache) HasTaskAck(_ context.Context, key string) (bool, error) {
+	_, ok := c.get(cacheKey{scope: scopeTaskAck, key: key})
+	return ok, nil
+}
 
-	go func() {
-		<-time.After(cacheTTL)
-		c.mapQueuePush.Delete(key)
-	}()
+func (c *IdempotencyKeyCache) SetTaskAck(_ context.Context, key string) error {
+	return c.set(cacheKey{scope: scopeTaskAck, key: key}, nil)
 }
 
-func (c *IdempotencyKeyCache) HasTaskAck(key string) bool {
-	_, ok := c.mapTaskAck.Load(key)
-	return ok
+func (c *IdempotencyKeyCache) HasTaskNack(_ context.Context, key string) (bool, error) {
+	_, ok := c.get(cacheKey{scope: scopeTaskNack, key: key})
+	return ok, nil
 }
 
-func (c *IdempotencyKeyCache) SetTaskAck(key string) {
-	c.mapTaskAck.Store(key, struct{}{})
+func (c *IdempotencyKeyCache) SetTaskNack(_ context.Context, key string) error {
+	return c.set(cacheKey{scope: scopeTaskNack, key: key}, nil)
+}
 
-	go func() {
-		<-time.After(cacheTTL)
-		c.mapTaskAck.Delete(key)
-	}()
+// Transactional reports that the keys are not rolled back with the trx of the operation,
+// so they have to be set only once it is committed.
+func (c *IdempotencyKeyCache) Transactional() bool {
+	return false
 }
 
-func (c *IdempotencyKeyCache) HasTaskNack(key string) bool {
-	_, ok := c.mapTaskNack.Load(key)
-	return ok
+// DeleteExpired deletes the expired entries. It is meant to be called periodically by a single janitor.
+func (c *IdempotencyKeyCache) DeleteExpired(_ context.Context) (int64, error) {
+	now := c.clock.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var count int64
+	for elem := c.expiry.Front(); elem != nil; elem = c.expiry.Front() {
+		entry := elem.Value.(*cacheEntry)
+		if entry.expiresAt.After(now) {
+			break
+		}
+
+		c.remove(entry)
+		count++
+	}
+
+	return count, nil
 }
 
-func (c *IdempotencyKeyCache) SetTaskNack(key string) {
-	c.mapTaskNack.Store(key, struct{}{})
+func (c *IdempotencyKeyCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.entries)
+}
+
+func (c *IdempotencyKeyCache) get(key cacheKey) (*cacheEntry, bool) {
+	now := c.clock.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || !entry.expiresAt.After(now) {
+		return nil, false
+	}
+
+	c.lru.MoveToFront(entry.lruElem)
+	return entry, true
+}
+
+// set stores the key or returns repository.ErrAlreadyExists if it is stored and not expired.
+func (c *IdempotencyKeyCache) set(key cacheKey, record *domain.PushRecord) error {
+	now := c.clock.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if entry, ok := c.entries[key]; ok {
+		if entry.expiresAt.After(now) {
+			return repository.ErrAlreadyExists
+		}
+
+		c.remove(entry)
+	}
+
+	for len(c.entries) >= c.maxEntries && c.lru.Len() > 0 {
+		c.remove(c.lru.Back().Value.(*cacheEntry))
+	}
+
+	entry := &cacheEntry{
+		key:       key,
+		record:    record,
+		expiresAt: now.Add(c.ttl),
+	}
+	entry.lruElem = c.lru.PushFront(entry)
+	entry.expElem = c.expiry.PushBack(entry)
+	c.entries[key] = entry
+	return nil
+}
 
-	go func() {
-		<-time.After(cacheTTL)
-		c.mapTaskNack.Delete(key)
-	}()
+func (c *IdempotencyKeyCache) remove(entry *cacheEntry) {
+	c.lru.Remove(entry.lruElem)
+	c.expiry.Remove(entry.expElem)
+	delete(c.entries, entry.key)
 }
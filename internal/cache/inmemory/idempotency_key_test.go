@@ -0,0 +1,98 @@
+package inmemory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/art-es/queue-service/internal/app/domain"
+	"github.com/art-es/queue-service/internal/app/repository"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Add(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func TestIdempotencyKeyCache(t *testing.T) {
+	ctx := context.Background()
+	record := &domain.PushRecord{Fingerprint: "fingerprint", Task: &domain.Task{ID: "taskID"}}
+
+	t.Run("set and get keys", func(t *testing.T) {
+		cache := NewIdempotencyKeyCache(&fakeClock{now: time.Unix(0, 0)}, time.Minute, 10)
+
+		assert.NoError(t, cache.SetQueuePush(ctx, "queue", "key", record))
+		assert.NoError(t, cache.SetTaskAck(ctx, "key"))
+
+		got, ok, err := cache.GetQueuePush(ctx, "queue", "key")
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, record, got)
+
+		_, ok, _ = cache.GetQueuePush(ctx, "otherQueue", "key")
+		assert.False(t, ok, "keys are scoped per queue")
+
+		ok, _ = cache.HasTaskAck(ctx, "key")
+		assert.True(t, ok)
+
+		ok, _ = cache.HasTaskNack(ctx, "key")
+		assert.False(t, ok, "keys are scoped per operation")
+	})
+
+	t.Run("set existing key", func(t *testing.T) {
+		cache := NewIdempotencyKeyCache(&fakeClock{now: time.Unix(0, 0)}, time.Minute, 10)
+
+		assert.NoError(t, cache.SetTaskNack(ctx, "key"))
+		assert.ErrorIs(t, cache.SetTaskNack(ctx, "key"), repository.ErrAlreadyExists)
+	})
+
+	t.Run("keys expire after ttl", func(t *testing.T) {
+		clock := &fakeClock{now: time.Unix(0, 0)}
+		cache := NewIdempotencyKeyCache(clock, time.Minute, 10)
+
+		assert.NoError(t, cache.SetTaskAck(ctx, "key1"))
+		clock.Add(30 * time.Second)
+		assert.NoError(t, cache.SetTaskAck(ctx, "key2"))
+		clock.Add(30 * time.Second)
+
+		ok, _ := cache.HasTaskAck(ctx, "key1")
+		assert.False(t, ok, "expired key is missed before the janitor deletes it")
+
+		count, err := cache.DeleteExpired(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+		assert.Equal(t, 1, cache.Len())
+
+		assert.NoError(t, cache.SetTaskAck(ctx, "key1"), "expired key can be set again")
+	})
+
+	t.Run("least recently used keys are evicted", func(t *testing.T) {
+		cache := NewIdempotencyKeyCache(&fakeClock{now: time.Unix(0, 0)}, time.Minute, 2)
+
+		assert.NoError(t, cache.SetTaskAck(ctx, "key1"))
+		assert.NoError(t, cache.SetTaskAck(ctx, "key2"))
+
+		ok, _ := cache.HasTaskAck(ctx, "key1")
+		assert.True(t, ok)
+
+		assert.NoError(t, cache.SetTaskAck(ctx, "key3"))
+
+		ok, _ = cache.HasTaskAck(ctx, "key2")
+		assert.False(t, ok, "key2 is the least recently used")
+		ok, _ = cache.HasTaskAck(ctx, "key1")
+		assert.True(t, ok)
+		assert.Equal(t, 2, cache.Len())
+
+		count, _ := cache.DeleteExpired(ctx)
+		assert.Zero(t, count)
+	})
+}
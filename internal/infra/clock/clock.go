@@ -8,6 +8,10 @@ func (*Clock) Now() time.Time {
 	return time.Now()
 }
 
+func (*Clock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
 func NewClock() *Clock {
 	return &Clock{}
 }
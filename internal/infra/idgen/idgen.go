@@ -0,0 +1,13 @@
+package idgen
+
+import "github.com/google/uuid"
+
+type Generator struct{}
+
+func (*Generator) NewID() string {
+	return uuid.NewString()
+}
+
+func NewGenerator() *Generator {
+	return &Generator{}
+}
@@ -20,6 +20,11 @@ func ParseEnv(vars ...Env) error {
 			return fmt.Errorf("required env: %s", v.Name)
 		}
 
+		// Keep the target's default value
+		if len(strVal) == 0 {
+			continue
+		}
+
 		switch v.Target.(type) {
 		case *string:
 			*v.Target.(*string) = strVal
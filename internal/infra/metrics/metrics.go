@@ -0,0 +1,31 @@
+package metrics
+
+// DefaultBuckets are the histogram buckets for durations in seconds.
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Metrics registers metrics or gets the registered ones by name.
+// A metric must be registered with the same type and label names every time.
+type Metrics interface {
+	Counter(name, help string, labelNames ...string) Counter
+	Gauge(name, help string, labelNames ...string) Gauge
+	// GaugeFunc registers a gauge without labels whose value is got on every collection.
+	GaugeFunc(name, help string, fn func() float64)
+	// Histogram registers a histogram with ascending buckets, DefaultBuckets if nil.
+	Histogram(name, help string, buckets []float64, labelNames ...string) Histogram
+}
+
+// Counter only goes up. Label values must match the label names of the counter.
+type Counter interface {
+	Inc(labelValues ...string)
+	Add(delta float64, labelValues ...string)
+}
+
+type Gauge interface {
+	Inc(labelValues ...string)
+	Dec(labelValues ...string)
+	Set(value float64, labelValues ...string)
+}
+
+type Histogram interface {
+	Observe(value float64, labelValues ...string)
+}
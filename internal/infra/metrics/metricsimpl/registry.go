@@ -0,0 +1,261 @@
+package metricsimpl
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	core "github.com/art-es/queue-service/internal/infra/metrics"
+)
+
+const (
+	typeCounter   = "counter"
+	typeGauge     = "gauge"
+	typeHistogram = "histogram"
+
+	labelValuesSeparator = "\xff"
+)
+
+var (
+	_ core.Metrics   = (*Registry)(nil)
+	_ core.Counter   = (*family)(nil)
+	_ core.Gauge     = (*family)(nil)
+	_ core.Histogram = (*family)(nil)
+)
+
+// Registry keeps metrics in memory and writes them in the Prometheus text exposition format.
+// It is safe for concurrent use.
+type Registry struct {
+	mu       sync.Mutex
+	families map[string]*family
+}
+
+type family struct {
+	name       string
+	help       string
+	typ        string
+	labelNames []string
+	buckets    []float64
+	fn         func() float64
+
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+type series struct {
+	labelValues []string
+	value       float64  // Counter or gauge value, histogram sum
+	counts      []uint64 // Histogram counts per bucket, not cumulative
+	count       uint64   // Histogram count
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		families: make(map[string]*family),
+	}
+}
+
+func (r *Registry) Counter(name, help string, labelNames ...string) core.Counter {
+	return r.register(&family{name: name, help: help, typ: typeCounter, labelNames: labelNames})
+}
+
+func (r *Registry) Gauge(name, help string, labelNames ...string) core.Gauge {
+	return r.register(&family{name: name, help: help, typ: typeGauge, labelNames: labelNames})
+}
+
+func (r *Registry) GaugeFunc(name, help string, fn func() float64) {
+	r.register(&family{name: name, help: help, typ: typeGauge, fn: fn})
+}
+
+func (r *Registry) Histogram(name, help string, buckets []float64, labelNames ...string) core.Histogram {
+	if buckets == nil {
+		buckets = core.DefaultBuckets
+	}
+
+	return r.register(&family{name: name, help: help, typ: typeHistogram, labelNames: labelNames, buckets: buckets})
+}
+
+// register returns the registered family of the name. It panics if the family differs,
+// as the metric names are fixed in the code.
+func (r *Registry) register(f *family) *family {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if registered, ok := r.families[f.name]; ok {
+		if registered.typ != f.typ || !slices.Equal(registered.labelNames, f.labelNames) {
+			panic(fmt.Sprintf("metric %s is already registered with another type or labels", f.name))
+		}
+		return registered
+	}
+
+	f.series = make(map[string]*series)
+	r.families[f.name] = f
+	return f
+}
+
+// WriteText writes all the metrics ordered by name.
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	families := make([]*family, 0, len(r.families))
+	for _, f := range r.families {
+		families = append(families, f)
+	}
+	r.mu.Unlock()
+
+	sort.Slice(families, func(i, j int) bool {
+		return families[i].name < families[j].name
+	})
+
+	bw := bufio.NewWriter(w)
+	for _, f := range families {
+		f.write(bw)
+	}
+	return bw.Flush()
+}
+
+func (f *family) Inc(labelValues ...string) {
+	f.Add(1, labelValues...)
+}
+
+func (f *family) Dec(labelValues ...string) {
+	f.Add(-1, labelValues...)
+}
+
+func (f *family) Add(delta float64, labelValues ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.getSeries(labelValues).value += delta
+}
+
+func (f *family) Set(value float64, labelValues ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.getSeries(labelValues).value = value
+}
+
+func (f *family) Observe(value float64, labelValues ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	s := f.getSeries(labelValues)
+	s.value += value
+	s.count++
+
+	for i, bound := range f.buckets {
+		if value <= bound {
+			s.counts[i]++
+			return
+		}
+	}
+}
+
+func (f *family) getSeries(labelValues []string) *series {
+	if len(labelValues) != len(f.labelNames) {
+		panic(fmt.Sprintf("metric %s expects %d label values, got %d", f.name, len(f.labelNames), len(labelValues)))
+	}
+
+	key := strings.Join(labelValues, labelValuesSeparator)
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{
+			labelValues: slices.Clone(labelValues),
+			counts:      make([]uint64, len(f.buckets)),
+		}
+		f.series[key] = s
+	}
+	return s
+}
+
+func (f *family) write(w *bufio.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", f.name, f.typ)
+
+	if f.fn != nil {
+		fmt.Fprintf(w, "%s %s\n", f.name, formatValue(f.fn()))
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	keys := make([]string, 0, len(f.series))
+	for key := range f.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		s := f.series[key]
+		labels := formatLabels(f.labelNames, s.labelValues)
+
+		if f.typ != typeHistogram {
+			fmt.Fprintf(w, "%s%s %s\n", f.name, labels, formatValue(s.value))
+			continue
+		}
+
+		var cumulative uint64
+		for i, bound := range f.buckets {
+			cumulative += s.counts[i]
+			le := formatLabels(append(slices.Clone(f.labelNames), "le"), append(slices.Clone(s.labelValues), formatValue(bound)))
+			fmt.Fprintf(w, "%s_bucket%s %d\n", f.name, le, cumulative)
+		}
+		le := formatLabels(append(slices.Clone(f.labelNames), "le"), append(slices.Clone(s.labelValues), "+Inf"))
+		fmt.Fprintf(w, "%s_bucket%s %d\n", f.name, le, s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", f.name, labels, formatValue(s.value))
+		fmt.Fprintf(w, "%s_count%s %d\n", f.name, labels, s.count)
+	}
+}
+
+func formatLabels(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(name)
+		sb.WriteString(`="`)
+		sb.WriteString(escapeLabelValue(values[i]))
+		sb.WriteByte('"')
+	}
+	sb.WriteByte('}')
+	return sb.String()
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
+
+var (
+	helpReplacer       = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelValueReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string {
+	return helpReplacer.Replace(s)
+}
+
+func escapeLabelValue(s string) string {
+	return labelValueReplacer.Replace(s)
+}
@@ -0,0 +1,69 @@
+package metricsimpl
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry(t *testing.T) {
+	t.Run("write metrics", func(t *testing.T) {
+		registry := NewRegistry()
+
+		counter := registry.Counter("tasks_total", "Tasks count.", "queue")
+		counter.Inc("b")
+		counter.Add(2, "a")
+		registry.Counter("tasks_total", "Tasks count.", "queue").Inc("a")
+
+		gauge := registry.Gauge("connections", "Open connections.")
+		gauge.Inc()
+		gauge.Inc()
+		gauge.Dec()
+
+		registry.GaugeFunc("pool_size", "Pool size.", func() float64 { return 10 })
+
+		histogram := registry.Histogram("duration_seconds", "Duration.", []float64{0.1, 1}, "endpoint")
+		histogram.Observe(0.05, `GET /"x"`)
+		histogram.Observe(0.5, `GET /"x"`)
+		histogram.Observe(5, `GET /"x"`)
+
+		buf := &bytes.Buffer{}
+		assert.NoError(t, registry.WriteText(buf))
+		assert.Equal(t, `# HELP connections Open connections.
+# TYPE connections gauge
+connections 1
+# HELP duration_seconds Duration.
+# TYPE duration_seconds histogram
+duration_seconds_bucket{endpoint="GET /\"x\"",le="0.1"} 1
+duration_seconds_bucket{endpoint="GET /\"x\"",le="1"} 2
+duration_seconds_bucket{endpoint="GET /\"x\"",le="+Inf"} 3
+duration_seconds_sum{endpoint="GET /\"x\""} 5.55
+duration_seconds_count{endpoint="GET /\"x\""} 3
+# HELP pool_size Pool size.
+# TYPE pool_size gauge
+pool_size 10
+# HELP tasks_total Tasks count.
+# TYPE tasks_total counter
+tasks_total{queue="a"} 3
+tasks_total{queue="b"} 1
+`, buf.String())
+	})
+
+	t.Run("register with another type", func(t *testing.T) {
+		registry := NewRegistry()
+		registry.Counter("metric", "Metric.")
+
+		assert.Panics(t, func() {
+			registry.Gauge("metric", "Metric.")
+		})
+	})
+
+	t.Run("wrong label values count", func(t *testing.T) {
+		registry := NewRegistry()
+
+		assert.Panics(t, func() {
+			registry.Counter("metric", "Metric.", "queue").Inc()
+		})
+	})
+}
@@ -0,0 +1,145 @@
+package notify
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// maxDeadlines limits the pending notification times of a key,
+// the latest ones over it are dropped and left to the polling of the waiters.
+const maxDeadlines = 1024
+
+// Hub wakes up goroutines waiting on a key. It is safe for concurrent use.
+type Hub struct {
+	mu        sync.Mutex
+	signals   map[string]chan struct{}
+	deadlines map[string]*deadlines
+}
+
+// deadlines are the pending notification times of a key, a single timer is set to the earliest one.
+type deadlines struct {
+	timer *time.Timer
+	times []time.Time // Sorted and unique
+}
+
+func NewHub() *Hub {
+	return &Hub{
+		signals:   make(map[string]chan struct{}),
+		deadlines: make(map[string]*deadlines),
+	}
+}
+
+// Wait returns a channel that is closed on the next notification of the key.
+// Get the channel before checking the awaited condition, so a notification in between is not lost.
+func (h *Hub) Wait(key string) <-chan struct{} {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	signal, ok := h.signals[key]
+	if !ok {
+		signal = make(chan struct{})
+		h.signals[key] = signal
+	}
+	return signal
+}
+
+// Notify wakes up all the current waiters of the key.
+func (h *Hub) Notify(key string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.notify(key)
+}
+
+func (h *Hub) notify(key string) {
+	if signal, ok := h.signals[key]; ok {
+		close(signal)
+		delete(h.signals, key)
+	}
+}
+
+// NotifyAt notifies the key at the given time, or now if the time has passed.
+// The time is truncated to milliseconds, so the same time notified twice is timed once.
+func (h *Hub) NotifyAt(key string, at time.Time) {
+	at = at.Truncate(time.Millisecond)
+	if !at.After(time.Now()) {
+		h.Notify(key)
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	dl, ok := h.deadlines[key]
+	if !ok {
+		dl = &deadlines{}
+		h.deadlines[key] = dl
+	}
+
+	i, found := slices.BinarySearchFunc(dl.times, at, time.Time.Compare)
+	if found || i == maxDeadlines {
+		return
+	}
+
+	dl.times = slices.Insert(dl.times, i, at)
+	if len(dl.times) > maxDeadlines {
+		dl.times = dl.times[:maxDeadlines]
+	}
+
+	// The timer is reset only for a new earliest time
+	if i == 0 {
+		h.schedule(key, dl)
+	}
+}
+
+// schedule sets the timer of the key to its earliest time.
+func (h *Hub) schedule(key string, dl *deadlines) {
+	d := time.Until(dl.times[0])
+	if dl.timer == nil {
+		dl.timer = time.AfterFunc(d, func() { h.fire(key) })
+		return
+	}
+
+	dl.timer.Reset(d)
+}
+
+// fire notifies the key if any of its times has come and schedules the next one.
+func (h *Hub) fire(key string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	dl, ok := h.deadlines[key]
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	i := 0
+	for i < len(dl.times) && !dl.times[i].After(now) {
+		i++
+	}
+
+	if i > 0 {
+		dl.times = dl.times[i:]
+		h.notify(key)
+	}
+
+	if len(dl.times) == 0 {
+		delete(h.deadlines, key)
+		return
+	}
+
+	h.schedule(key, dl)
+}
+
+// NotifyAll wakes up the current waiters of every key.
+func (h *Hub) NotifyAll() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for key, signal := range h.signals {
+		close(signal)
+		delete(h.signals, key)
+	}
+}
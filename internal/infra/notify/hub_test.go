@@ -0,0 +1,128 @@
+package notify
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHub(t *testing.T) {
+	t.Run("notify wakes up waiters of the key", func(t *testing.T) {
+		hub := NewHub()
+		signal1 := hub.Wait("key")
+		signal2 := hub.Wait("key")
+		otherSignal := hub.Wait("otherKey")
+
+		hub.Notify("key")
+
+		assert.True(t, isClosed(signal1))
+		assert.True(t, isClosed(signal2))
+		assert.False(t, isClosed(otherSignal))
+	})
+
+	t.Run("wait after notify gets a new signal", func(t *testing.T) {
+		hub := NewHub()
+		hub.Wait("key")
+		hub.Notify("key")
+
+		assert.False(t, isClosed(hub.Wait("key")))
+	})
+
+	t.Run("notify without waiters", func(t *testing.T) {
+		hub := NewHub()
+		hub.Notify("key")
+
+		assert.False(t, isClosed(hub.Wait("key")))
+	})
+
+	t.Run("notify at passed time", func(t *testing.T) {
+		hub := NewHub()
+		signal := hub.Wait("key")
+
+		hub.NotifyAt("key", time.Now().Add(-time.Second))
+
+		assert.True(t, isClosed(signal))
+	})
+
+	t.Run("notify at future time", func(t *testing.T) {
+		hub := NewHub()
+		signal := hub.Wait("key")
+
+		hub.NotifyAt("key", time.Now().Add(10*time.Millisecond))
+		assert.False(t, isClosed(signal))
+
+		select {
+		case <-signal:
+		case <-time.After(time.Second):
+			t.Fatal("signal is not closed")
+		}
+	})
+
+	t.Run("notify at keeps one timer per key", func(t *testing.T) {
+		hub := NewHub()
+		at := time.Now().Add(time.Hour)
+
+		for i := range 2 * maxDeadlines {
+			hub.NotifyAt("key", at.Add(time.Duration(i%(maxDeadlines+10))*time.Millisecond))
+		}
+
+		require.Len(t, hub.deadlines, 1)
+		dl := hub.deadlines["key"]
+		assert.Len(t, dl.times, maxDeadlines)
+		assert.True(t, slices.IsSortedFunc(dl.times, time.Time.Compare))
+		assert.Equal(t, at.Truncate(time.Millisecond), dl.times[0])
+		assert.True(t, dl.timer.Stop(), "timer is pending")
+	})
+
+	t.Run("notify at an earlier time keeps the later ones", func(t *testing.T) {
+		hub := NewHub()
+		now := time.Now()
+		signal := hub.Wait("key")
+
+		hub.NotifyAt("key", now.Add(60*time.Millisecond))
+		hub.NotifyAt("key", now.Add(20*time.Millisecond))
+
+		select {
+		case <-signal:
+		case <-time.After(time.Second):
+			t.Fatal("signal is not closed at the earlier time")
+		}
+
+		signal = hub.Wait("key")
+		select {
+		case <-signal:
+		case <-time.After(time.Second):
+			t.Fatal("signal is not closed at the later time")
+		}
+
+		assert.Eventually(t, func() bool {
+			hub.mu.Lock()
+			defer hub.mu.Unlock()
+			return len(hub.deadlines) == 0
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("notify all wakes up waiters of every key", func(t *testing.T) {
+		hub := NewHub()
+		signal1 := hub.Wait("key")
+		signal2 := hub.Wait("otherKey")
+
+		hub.NotifyAll()
+
+		assert.True(t, isClosed(signal1))
+		assert.True(t, isClosed(signal2))
+		assert.False(t, isClosed(hub.Wait("key")))
+	})
+}
+
+func isClosed(signal <-chan struct{}) bool {
+	select {
+	case <-signal:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,14 @@
+package random
+
+import "math/rand/v2"
+
+// Random is safe for concurrent use.
+type Random struct{}
+
+func (*Random) Int64N(n int64) int64 {
+	return rand.Int64N(n)
+}
+
+func NewRandom() *Random {
+	return &Random{}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/art-es/queue-service/internal/infra/log"
+	"github.com/art-es/queue-service/internal/infra/metrics"
 	"github.com/art-es/queue-service/internal/infra/ops"
 	"github.com/art-es/queue-service/internal/infra/trx"
 )
@@ -34,8 +35,27 @@ func LogError(logger log.Logger, msg string, rbErr, opErr error) {
 	}
 }
 
-func DoOrLogError(logger log.Logger, logMsg string, ctx context.Context, fn func(ctx context.Context) error) error {
+// Count counts the trx outcome by the operation name.
+func Count(m metrics.Metrics, op string, opErr error) {
+	result := "commit"
+	if opErr != nil {
+		result = "rollback"
+	}
+
+	m.Counter("trx_total", "Transactions by operation and result.", "op", "result").Inc(op, result)
+}
+
+// DoOrLogError runs the trx, logs the rollback error and counts the outcome by op,
+// which also is the log message.
+func DoOrLogError(
+	logger log.Logger,
+	metrics metrics.Metrics,
+	op string,
+	ctx context.Context,
+	fn func(ctx context.Context) error,
+) error {
 	opErr, rbErr := Do(ctx, fn)
-	LogError(logger, logMsg, rbErr, opErr)
+	LogError(logger, op, rbErr, opErr)
+	Count(metrics, op, opErr)
 	return opErr
 }
@@ -38,6 +38,10 @@ func (c *connAdapter) QueryRow(ctx context.Context, query string, args ...any) R
 	return c.db.QueryRowContext(ctx, query, args...)
 }
 
+func (c *connAdapter) Ping(ctx context.Context) error {
+	return c.db.PingContext(ctx)
+}
+
 func (c *connAdapter) Close() error {
 	return c.db.Close()
 }
@@ -8,9 +8,10 @@ import (
 	_ "github.com/lib/pq"
 
 	"github.com/art-es/queue-service/internal/infra/log"
+	"github.com/art-es/queue-service/internal/infra/metrics"
 )
 
-func Connect(source string, logger log.Logger) (Conn, error) {
+func Connect(source string, metrics metrics.Metrics, logger log.Logger) (Conn, error) {
 	logger = logger.With("module", "internal/adapter/psql")
 
 	for range 30 {
@@ -35,12 +36,33 @@ func Connect(source string, logger log.Logger) (Conn, error) {
 			continue
 		}
 
+		registerPoolMetrics(db, metrics)
 		return newConnAdapter(db), nil
 	}
 
 	return nil, errors.New("reached max attempts to connect")
 }
 
+// registerPoolMetrics exposes the connection pool stats, they are got on every collection.
+func registerPoolMetrics(db *sql.DB, m metrics.Metrics) {
+	stat := func(fn func(s sql.DBStats) float64) func() float64 {
+		return func() float64 { return fn(db.Stats()) }
+	}
+
+	m.GaugeFunc("psql_pool_max_open_connections", "Maximum number of open connections.",
+		stat(func(s sql.DBStats) float64 { return float64(s.MaxOpenConnections) }))
+	m.GaugeFunc("psql_pool_open_connections", "Number of open connections.",
+		stat(func(s sql.DBStats) float64 { return float64(s.OpenConnections) }))
+	m.GaugeFunc("psql_pool_in_use_connections", "Number of connections in use.",
+		stat(func(s sql.DBStats) float64 { return float64(s.InUse) }))
+	m.GaugeFunc("psql_pool_idle_connections", "Number of idle connections.",
+		stat(func(s sql.DBStats) float64 { return float64(s.Idle) }))
+	m.GaugeFunc("psql_pool_wait_count", "Total number of connections waited for.",
+		stat(func(s sql.DBStats) float64 { return float64(s.WaitCount) }))
+	m.GaugeFunc("psql_pool_wait_duration_seconds", "Total time blocked waiting for a connection.",
+		stat(func(s sql.DBStats) float64 { return s.WaitDuration.Seconds() }))
+}
+
 func waitBetweenConnects() {
 	time.Sleep(time.Second)
 }
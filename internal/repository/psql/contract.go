@@ -7,6 +7,7 @@ import (
 
 type Conn interface {
 	Executer
+	Ping(ctx context.Context) error
 	Close() error
 	beginTx(ctx context.Context) (tx, error)
 }
@@ -0,0 +1,203 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/art-es/queue-service/internal/app/domain"
+	"github.com/art-es/queue-service/internal/app/repository"
+	"github.com/art-es/queue-service/internal/repository/psql"
+)
+
+const (
+	scopeQueuePush = "queue_push"
+	scopeTaskAck   = "task_ack"
+	scopeTaskNack  = "task_nack"
+)
+
+// pushResult is the stored task as it was returned by the push.
+type pushResult struct {
+	ID          string     `json:"id"`
+	QueueName   string     `json:"queue_name"`
+	Payload     string     `json:"payload"`
+	Status      string     `json:"status"`
+	CreatedAt   time.Time  `json:"created_at"`
+	LockedUntil *time.Time `json:"locked_until"`
+	Priority    int        `json:"priority"`
+}
+
+// Repository stores idempotency keys for the retention. Keys are written with the executor
+// of the context, so they are committed in the same transaction as the operation.
+type Repository struct {
+	execGetter psql.ExecGetter
+	retention  time.Duration
+}
+
+func NewRepository(execGetter psql.ExecGetter, retention time.Duration) *Repository {
+	return &Repository{
+		execGetter: execGetter,
+		retention:  retention,
+	}
+}
+
+// Transactional reports that the keys are stored in the trx of the operation.
+func (r *Repository) Transactional() bool {
+	return true
+}
+
+func (r *Repository) GetQueuePush(ctx context.Context, queueName, key string) (*domain.PushRecord, bool, error) {
+	var data []byte
+	var fingerprint sql.NullString
+	ok, err := r.get(ctx, scopeQueuePush, queueName, key, &data, &fingerprint)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+
+	var result pushResult
+	if err = json.Unmarshal(data, &result); err != nil {
+		return nil, false, fmt.Errorf("decode result: %w", err)
+	}
+
+	record := &domain.PushRecord{
+		Fingerprint: fingerprint.String,
+		Task: &domain.Task{
+			ID:          result.ID,
+			QueueName:   result.QueueName,
+			Payload:     result.Payload,
+			Status:      result.Status,
+			CreatedAt:   result.CreatedAt,
+			LockedUntil: result.LockedUntil,
+			Priority:    result.Priority,
+		},
+	}
+	return record, true, nil
+}
+
+func (r *Repository) SetQueuePush(ctx context.Context, queueName, key string, record *domain.PushRecord) error {
+	task := record.Task
+	data, err := json.Marshal(&pushResult{
+		ID:          task.ID,
+		QueueName:   task.QueueName,
+		Payload:     task.Payload,
+		Status:      task.Status,
+		CreatedAt:   task.CreatedAt,
+		LockedUntil: task.LockedUntil,
+		Priority:    task.Priority,
+	})
+	if err != nil {
+		return fmt.Errorf("encode result: %w", err)
+	}
+
+	return r.set(ctx, scopeQueuePush, queueName, key, string(data), record.Fingerprint)
+}
+
+func (r *Repository) HasTaskAck(ctx context.Context, key string) (bool, error) {
+	var data []byte
+	var fingerprint sql.NullString
+	return r.get(ctx, scopeTaskAck, "", key, &data, &fingerprint)
+}
+
+func (r *Repository) SetTaskAck(ctx context.Context, key string) error {
+	return r.set(ctx, scopeTaskAck, "", key, nil, nil)
+}
+
+func (r *Repository) HasTaskNack(ctx context.Context, key string) (bool, error) {
+	var data []byte
+	var fingerprint sql.NullString
+	return r.get(ctx, scopeTaskNack, "", key, &data, &fingerprint)
+}
+
+func (r *Repository) SetTaskNack(ctx context.Context, key string) error {
+	return r.set(ctx, scopeTaskNack, "", key, nil, nil)
+}
+
+// DeleteExpired deletes keys older than the retention.
+func (r *Repository) DeleteExpired(ctx context.Context) (int64, error) {
+	exec, err := r.execGetter.Get(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	query := `DELETE FROM idempotency_keys WHERE expires_at <= now()`
+
+	result, err := exec.Exec(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("execute sql query: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("get affected rows: %w", err)
+	}
+
+	return affected, nil
+}
+
+// get scans the stored result and fingerprint of the key. Keys of tasks have no queue name.
+func (r *Repository) get(
+	ctx context.Context,
+	scope, queueName, key string,
+	result *[]byte,
+	fingerprint *sql.NullString,
+) (bool, error) {
+	exec, err := r.execGetter.Get(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	query := `
+		SELECT result, fingerprint
+		FROM idempotency_keys
+		WHERE scope = $1 AND queue_name = $2 AND key = $3 AND expires_at > now()`
+
+	if err = exec.QueryRow(ctx, query, scope, queueName, key).Scan(result, fingerprint); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+
+		return false, fmt.Errorf("execute sql query: %w", err)
+	}
+
+	return true, nil
+}
+
+// set stores the key with the JSON result and fingerprint, if any, or returns repository.ErrAlreadyExists if it is stored and not expired.
+// A concurrent insert of the same key waits for the other transaction to finish.
+func (r *Repository) set(ctx context.Context, scope, queueName, key string, result, fingerprint any) error {
+	exec, err := r.execGetter.Get(ctx)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO idempotency_keys (scope, queue_name, key, result, fingerprint, expires_at)
+		VALUES ($1, $2, $3, $4, $5, now() + make_interval(secs => $6))
+		ON CONFLICT (scope, queue_name, key) DO UPDATE
+		SET
+			result = EXCLUDED.result,
+			fingerprint = EXCLUDED.fingerprint,
+			created_at = EXCLUDED.created_at,
+			expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at <= now()`
+	args := []any{scope, queueName, key, result, fingerprint, int64(r.retention.Seconds())}
+
+	res, err := exec.Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("execute sql query: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get affected rows: %w", err)
+	}
+
+	if affected == 0 {
+		return repository.ErrAlreadyExists
+	}
+
+	return nil
+}
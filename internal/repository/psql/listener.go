@@ -0,0 +1,162 @@
+package psql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/art-es/queue-service/internal/infra/log"
+)
+
+const (
+	channelTaskAvailable = "task_available"
+
+	listenerMinReconnectInterval = 100 * time.Millisecond
+	listenerMaxReconnectInterval = 10 * time.Second
+	listenerPingInterval         = 30 * time.Second
+
+	// While the listener is disconnected, notifications are lost,
+	// so all the local waiters are woken up to poll the tasks on their own.
+	listenerFallbackPollInterval = time.Second
+)
+
+type notifier interface {
+	NotifyAt(key string, at time.Time)
+	NotifyAll()
+}
+
+type taskAvailablePayload struct {
+	Queue         string `json:"queue"`
+	AvailableAtMs int64  `json:"available_at_ms"`
+}
+
+// Listener fans the task availability notifications of all the replicas out to the local waiters.
+type Listener struct {
+	listener *pq.Listener
+	notifier notifier
+	logger   log.Logger
+
+	mu        sync.Mutex
+	connected bool
+	changed   chan struct{}
+}
+
+func NewListener(source string, notifier notifier, logger log.Logger) (*Listener, error) {
+	l := &Listener{
+		notifier: notifier,
+		logger:   logger.With("module", "internal/adapter/psql/listener"),
+		changed:  make(chan struct{}, 1),
+	}
+
+	l.listener = pq.NewListener(source, listenerMinReconnectInterval, listenerMaxReconnectInterval, l.onEvent)
+	if err := l.listener.Listen(channelTaskAvailable); err != nil {
+		_ = l.listener.Close()
+		return nil, fmt.Errorf("listen %s: %w", channelTaskAvailable, err)
+	}
+
+	l.setConnected(true)
+	return l, nil
+}
+
+// Run dispatches the notifications until ctx is done.
+func (l *Listener) Run(ctx context.Context) {
+	ping := time.NewTicker(listenerPingInterval)
+	defer ping.Stop()
+
+	var poll *time.Ticker
+	var pollC <-chan time.Time
+	defer func() {
+		if poll != nil {
+			poll.Stop()
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case n := <-l.listener.NotificationChannel():
+			if n == nil {
+				// Sent after a reconnect, notifications might have been lost in between
+				l.notifier.NotifyAll()
+				continue
+			}
+			l.handle(n)
+		case <-l.changed:
+			if l.isConnected() {
+				if poll != nil {
+					poll.Stop()
+					poll, pollC = nil, nil
+				}
+				l.notifier.NotifyAll()
+			} else if poll == nil {
+				poll = time.NewTicker(listenerFallbackPollInterval)
+				pollC = poll.C
+			}
+		case <-pollC:
+			l.notifier.NotifyAll()
+		case <-ping.C:
+			if err := l.listener.Ping(); err != nil {
+				l.logger.Log(log.LevelWarning).
+					With("message", "ping error").
+					With("error", err.Error()).
+					Write()
+			}
+		}
+	}
+}
+
+func (l *Listener) Close() error {
+	return l.listener.Close()
+}
+
+func (l *Listener) handle(n *pq.Notification) {
+	var payload taskAvailablePayload
+	if err := json.Unmarshal([]byte(n.Extra), &payload); err != nil {
+		l.logger.Log(log.LevelError).
+			With("message", "notification payload decode error").
+			With("error", err.Error()).
+			With("payload", n.Extra).
+			Write()
+		return
+	}
+
+	l.notifier.NotifyAt(payload.Queue, time.UnixMilli(payload.AvailableAtMs))
+}
+
+func (l *Listener) onEvent(event pq.ListenerEventType, err error) {
+	switch event {
+	case pq.ListenerEventConnected, pq.ListenerEventReconnected:
+		l.setConnected(true)
+	case pq.ListenerEventDisconnected, pq.ListenerEventConnectionAttemptFailed:
+		if err != nil {
+			l.logger.Log(log.LevelWarning).
+				With("message", "listener connection error").
+				With("error", err.Error()).
+				Write()
+		}
+		l.setConnected(false)
+	}
+}
+
+func (l *Listener) setConnected(connected bool) {
+	l.mu.Lock()
+	l.connected = connected
+	l.mu.Unlock()
+
+	select {
+	case l.changed <- struct{}{}:
+	default:
+	}
+}
+
+func (l *Listener) isConnected() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.connected
+}
@@ -0,0 +1,43 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/art-es/queue-service/internal/app/repository"
+	"github.com/art-es/queue-service/internal/repository/psql"
+)
+
+// ExpectedVersion is the version of the latest migration in db/migrations, the code relies on its schema.
+const ExpectedVersion = 20260320090000
+
+type Repository struct {
+	execGetter psql.ExecGetter
+}
+
+func NewRepository(execGetter psql.ExecGetter) *Repository {
+	return &Repository{execGetter: execGetter}
+}
+
+// GetVersion returns the applied migration version kept by the migrate tool.
+// A dirty version has failed to apply and needs a manual fix.
+func (r *Repository) GetVersion(ctx context.Context) (version int64, dirty bool, err error) {
+	exec, err := r.execGetter.Get(ctx)
+	if err != nil {
+		return 0, false, err
+	}
+
+	query := `SELECT version, dirty FROM schema_migrations LIMIT 1`
+
+	if err = exec.QueryRow(ctx, query).Scan(&version, &dirty); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, false, repository.ErrNotFound
+		}
+
+		return 0, false, fmt.Errorf("execute sql query: %w", err)
+	}
+
+	return version, dirty, nil
+}
@@ -0,0 +1,28 @@
+package migration
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpectedVersion(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("..", "..", "..", "..", "db", "migrations", "*.up.sql"))
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+
+	var latest int64
+	for _, file := range files {
+		version, _, _ := strings.Cut(filepath.Base(file), "_")
+		v, err := strconv.ParseInt(version, 10, 64)
+		require.NoError(t, err, file)
+
+		latest = max(latest, v)
+	}
+
+	assert.Equal(t, latest, int64(ExpectedVersion), "ExpectedVersion is to be bumped with a new migration")
+}
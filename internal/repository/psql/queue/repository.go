@@ -0,0 +1,147 @@
+package queue
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/art-es/queue-service/internal/app/domain"
+	"github.com/art-es/queue-service/internal/app/repository"
+	"github.com/art-es/queue-service/internal/repository/psql"
+)
+
+type Repository struct {
+	execGetter psql.ExecGetter
+}
+
+func NewRepository(execGetter psql.ExecGetter) *Repository {
+	return &Repository{execGetter: execGetter}
+}
+
+func (r *Repository) Get(ctx context.Context, name string) (*domain.Queue, error) {
+	exec, err := r.execGetter.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT
+			name, visibility_timeout, max_visibility_timeout, backoff_policy, backoff_initial, backoff_max,
+			max_attempts, max_payload_size, retention, disconnect_counts_attempt, created_at, updated_at
+		FROM queues
+		WHERE name = $1`
+
+	queue := &domain.Queue{}
+	var visibilityTimeout, maxVisibilityTimeout, backoffInitial, backoffMax, retention int64
+	scanDest := []any{
+		&queue.Name,
+		&visibilityTimeout,
+		&maxVisibilityTimeout,
+		&queue.Backoff.Policy,
+		&backoffInitial,
+		&backoffMax,
+		&queue.MaxAttempts,
+		&queue.MaxPayloadSize,
+		&retention,
+		&queue.DisconnectCountsAttempt,
+		&queue.CreatedAt,
+		&queue.UpdatedAt,
+	}
+
+	if err = exec.QueryRow(ctx, query, name).Scan(scanDest...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+
+		return nil, fmt.Errorf("execute sql query: %w", err)
+	}
+
+	queue.VisibilityTimeout = fromSQLDuration(visibilityTimeout)
+	queue.MaxVisibilityTimeout = fromSQLDuration(maxVisibilityTimeout)
+	queue.Backoff.Initial = fromSQLDuration(backoffInitial)
+	queue.Backoff.Max = fromSQLDuration(backoffMax)
+	queue.Retention = fromSQLDuration(retention)
+	return queue, nil
+}
+
+func (r *Repository) Save(ctx context.Context, queue *domain.Queue) error {
+	exec, err := r.execGetter.Get(ctx)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO queues (
+			name, visibility_timeout, max_visibility_timeout, backoff_policy, backoff_initial, backoff_max,
+			max_attempts, max_payload_size, retention, disconnect_counts_attempt
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (name) DO UPDATE
+		SET
+			visibility_timeout = EXCLUDED.visibility_timeout,
+			max_visibility_timeout = EXCLUDED.max_visibility_timeout,
+			backoff_policy = EXCLUDED.backoff_policy,
+			backoff_initial = EXCLUDED.backoff_initial,
+			backoff_max = EXCLUDED.backoff_max,
+			max_attempts = EXCLUDED.max_attempts,
+			max_payload_size = EXCLUDED.max_payload_size,
+			retention = EXCLUDED.retention,
+			disconnect_counts_attempt = EXCLUDED.disconnect_counts_attempt,
+			updated_at = now()
+		RETURNING created_at, updated_at`
+	args := []any{
+		queue.Name,
+		toSQLDuration(queue.VisibilityTimeout),
+		toSQLDuration(queue.MaxVisibilityTimeout),
+		queue.Backoff.Policy,
+		toSQLDuration(queue.Backoff.Initial),
+		toSQLDuration(queue.Backoff.Max),
+		queue.MaxAttempts,
+		queue.MaxPayloadSize,
+		toSQLDuration(queue.Retention),
+		queue.DisconnectCountsAttempt,
+	}
+
+	if err = exec.QueryRow(ctx, query, args...).Scan(&queue.CreatedAt, &queue.UpdatedAt); err != nil {
+		return fmt.Errorf("execute sql query: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Repository) Delete(ctx context.Context, name string) error {
+	exec, err := r.execGetter.Get(ctx)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		DELETE FROM queues
+		WHERE name = $1`
+
+	result, err := exec.Exec(ctx, query, name)
+	if err != nil {
+		return fmt.Errorf("execute sql query: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get affected rows: %w", err)
+	}
+
+	if affected == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
+
+func toSQLDuration(in time.Duration) int64 {
+	return int64(in.Seconds())
+}
+
+func fromSQLDuration(in int64) time.Duration {
+	return time.Second * time.Duration(in)
+}
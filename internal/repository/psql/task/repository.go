@@ -5,8 +5,11 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/lib/pq"
+
 	"github.com/art-es/queue-service/internal/app/domain"
 	"github.com/art-es/queue-service/internal/app/repository"
 	"github.com/art-es/queue-service/internal/infra/ops"
@@ -21,27 +24,29 @@ func NewRepository(execGetter psql.ExecGetter) *Repository {
 	return &Repository{execGetter: execGetter}
 }
 
-func (r *Repository) GetFirstPending(ctx context.Context, queueName string) (*domain.Task, error) {
+// GetPending locks up to limit tasks available for delivery, in delivery order.
+func (r *Repository) GetPending(ctx context.Context, queueName string, limit int) ([]*domain.Task, error) {
 	exec, err := r.execGetter.Get(ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	query := `
-		SELECT id, queue_name, payload, status, created_at, locked_until, last_fail_duration
+		SELECT id, queue_name, payload, status, created_at, locked_until, last_fail_duration, lease_token, attempts, priority
 		FROM tasks
 		WHERE 
 			queue_name = $1 
+			AND status <> 'dead'
 			AND (
-				status = 'pending'
+				(status = 'pending' AND (locked_until IS NULL OR locked_until <= now()))
 				OR (status = 'processing' AND locked_until <= now())
 				OR (status = 'failed' AND locked_until <= now())
 			)
-		ORDER BY created_at ASC
-		LIMIT 1
+		ORDER BY priority DESC, created_at ASC
+		LIMIT $2
 		FOR UPDATE SKIP LOCKED`
 
-	return getTask(exec, ctx, query, []any{queueName})
+	return getTasks(exec, ctx, query, []any{queueName, limit})
 }
 
 func (r *Repository) GetProcessingWithID(ctx context.Context, id string) (*domain.Task, error) {
@@ -51,7 +56,7 @@ func (r *Repository) GetProcessingWithID(ctx context.Context, id string) (*domai
 	}
 
 	query := `
-		SELECT id, queue_name, payload, status, created_at, locked_until, last_fail_duration
+		SELECT id, queue_name, payload, status, created_at, locked_until, last_fail_duration, lease_token, attempts, priority
 		FROM tasks
 		WHERE 
 			id = $1 
@@ -62,6 +67,75 @@ func (r *Repository) GetProcessingWithID(ctx context.Context, id string) (*domai
 	return getTask(exec, ctx, query, []any{id})
 }
 
+func (r *Repository) GetDead(ctx context.Context, queueName string, limit int) ([]*domain.Task, error) {
+	exec, err := r.execGetter.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT id, queue_name, payload, status, created_at, locked_until, last_fail_duration, lease_token, attempts, priority
+		FROM tasks
+		WHERE
+			queue_name = $1
+			AND status = 'dead'
+		ORDER BY created_at ASC
+		LIMIT $2`
+
+	return getTasks(exec, ctx, query, []any{queueName, limit})
+}
+
+func (r *Repository) RedriveDead(ctx context.Context, queueName string) (int64, error) {
+	exec, err := r.execGetter.Get(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	query := `
+		UPDATE tasks
+		SET status = 'pending', locked_until = NULL, last_fail_duration = NULL, lease_token = NULL, attempts = 0
+		WHERE
+			queue_name = $1
+			AND status = 'dead'`
+
+	return execAffected(exec, ctx, query, []any{queueName})
+}
+
+func (r *Repository) DeleteDead(ctx context.Context, queueName string) (int64, error) {
+	exec, err := r.execGetter.Get(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	query := `
+		DELETE FROM tasks
+		WHERE
+			queue_name = $1
+			AND status = 'dead'`
+
+	return execAffected(exec, ctx, query, []any{queueName})
+}
+
+// DeleteExpired deletes tasks older than the retention of their queue.
+// Queues without stored settings or with zero retention keep tasks forever.
+func (r *Repository) DeleteExpired(ctx context.Context) (int64, error) {
+	exec, err := r.execGetter.Get(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	query := `
+		DELETE FROM tasks
+		USING queues
+		WHERE
+			tasks.queue_name = queues.name
+			AND queues.retention > 0
+			AND tasks.created_at < now() - make_interval(secs => queues.retention)
+			AND (tasks.status <> 'processing' OR tasks.locked_until <= now())`
+
+	return execAffected(exec, ctx, query, nil)
+}
+
 func (r *Repository) Complete(ctx context.Context, id string) error {
 	exec, err := r.execGetter.Get(ctx)
 	if err != nil {
@@ -98,10 +172,10 @@ func (r *Repository) insert(ctx context.Context, task *domain.Task) error {
 	}
 
 	query := `
-		INSERT INTO tasks (queue_name, payload, status)
-		VALUES ($1, $2, $3)
+		INSERT INTO tasks (queue_name, payload, status, locked_until, priority)
+		VALUES ($1, $2, $3, $4, $5)
 		RETURNING id, created_at`
-	args := []any{task.QueueName, task.Payload, task.Status}
+	args := []any{task.QueueName, task.Payload, task.Status, task.LockedUntil, task.Priority}
 
 	if err = exec.QueryRow(ctx, query, args...).Scan(&task.ID, &task.CreatedAt); err != nil {
 		return fmt.Errorf("execute sql query: %w", err)
@@ -110,6 +184,55 @@ func (r *Repository) insert(ctx context.Context, task *domain.Task) error {
 	return nil
 }
 
+// InsertBatch inserts all the tasks with a single statement. Tasks must have their IDs set.
+func (r *Repository) InsertBatch(ctx context.Context, tasks []*domain.Task) error {
+	exec, err := r.execGetter.Get(ctx)
+	if err != nil {
+		return err
+	}
+
+	const columns = 6
+	values := make([]string, 0, len(tasks))
+	args := make([]any, 0, len(tasks)*columns)
+	taskByID := make(map[string]*domain.Task, len(tasks))
+
+	for i, task := range tasks {
+		n := i * columns
+		values = append(values, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d)", n+1, n+2, n+3, n+4, n+5, n+6))
+		args = append(args, task.ID, task.QueueName, task.Payload, task.Status, task.LockedUntil, task.Priority)
+		taskByID[task.ID] = task
+	}
+
+	query := `
+		INSERT INTO tasks (id, queue_name, payload, status, locked_until, priority)
+		VALUES ` + strings.Join(values, ", ") + `
+		RETURNING id, created_at`
+
+	rows, err := exec.Query(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("execute sql query: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		var createdAt time.Time
+		if err = rows.Scan(&id, &createdAt); err != nil {
+			return fmt.Errorf("scan row: %w", err)
+		}
+
+		if task, ok := taskByID[id]; ok {
+			task.CreatedAt = createdAt
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("iterate rows: %w", err)
+	}
+
+	return nil
+}
+
 func (r *Repository) update(ctx context.Context, task *domain.Task) error {
 	exec, err := r.execGetter.Get(ctx)
 	if err != nil {
@@ -118,9 +241,16 @@ func (r *Repository) update(ctx context.Context, task *domain.Task) error {
 
 	query := `
 		UPDATE tasks
-		SET status = $2, locked_until = $3, last_fail_duration = $4
+		SET status = $2, locked_until = $3, last_fail_duration = $4, lease_token = $5, attempts = $6
 		WHERE id = $1`
-	args := []any{task.ID, task.Status, task.LockedUntil, toSQLDuration(task.LastFailDuration)}
+	args := []any{
+		task.ID,
+		task.Status,
+		task.LockedUntil,
+		toSQLDuration(task.LastFailDuration),
+		ops.PointerOrNil(task.LeaseToken),
+		task.Attempts,
+	}
 
 	if _, err = exec.Exec(ctx, query, args...); err != nil {
 		return fmt.Errorf("execute sql query: %w", err)
@@ -129,14 +259,142 @@ func (r *Repository) update(ctx context.Context, task *domain.Task) error {
 	return nil
 }
 
+// UpdateBatch updates all the tasks with a single statement.
+func (r *Repository) UpdateBatch(ctx context.Context, tasks []*domain.Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	exec, err := r.execGetter.Get(ctx)
+	if err != nil {
+		return err
+	}
+
+	const columns = 6
+	values := make([]string, 0, len(tasks))
+	args := make([]any, 0, len(tasks)*columns)
+
+	for i, task := range tasks {
+		n := i * columns
+		values = append(values, fmt.Sprintf(
+			"($%d::uuid, $%d::text, $%d::timestamptz, $%d::int, $%d::uuid, $%d::int)",
+			n+1, n+2, n+3, n+4, n+5, n+6,
+		))
+		args = append(args,
+			task.ID,
+			task.Status,
+			task.LockedUntil,
+			toSQLDuration(task.LastFailDuration),
+			ops.PointerOrNil(task.LeaseToken),
+			task.Attempts,
+		)
+	}
+
+	query := `
+		UPDATE tasks
+		SET
+			status = v.status,
+			locked_until = v.locked_until,
+			last_fail_duration = v.last_fail_duration,
+			lease_token = v.lease_token,
+			attempts = v.attempts
+		FROM (VALUES ` + strings.Join(values, ", ") + `)
+			AS v(id, status, locked_until, last_fail_duration, lease_token, attempts)
+		WHERE tasks.id = v.id`
+
+	if _, err = exec.Exec(ctx, query, args...); err != nil {
+		return fmt.Errorf("execute sql query: %w", err)
+	}
+
+	return nil
+}
+
+// ReleaseBatch returns the processing tasks to pending. Their deliveries are undone as if they have never
+// been delivered, unless countAttempts is set. Tasks whose lease has changed since they have been got are skipped.
+func (r *Repository) ReleaseBatch(ctx context.Context, tasks []*domain.Task, countAttempts bool) (int64, error) {
+	if len(tasks) == 0 {
+		return 0, nil
+	}
+
+	exec, err := r.execGetter.Get(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	ids := make([]string, 0, len(tasks))
+	leaseTokens := make([]string, 0, len(tasks))
+	for _, task := range tasks {
+		ids = append(ids, task.ID)
+		leaseTokens = append(leaseTokens, task.LeaseToken)
+	}
+
+	query := `
+		UPDATE tasks
+		SET
+			status = 'pending',
+			locked_until = NULL,
+			lease_token = NULL,
+			attempts = CASE WHEN $3 THEN tasks.attempts ELSE greatest(tasks.attempts - 1, 0) END
+		FROM unnest($1::uuid[], $2::uuid[]) AS v(id, lease_token)
+		WHERE
+			tasks.id = v.id
+			AND tasks.lease_token = v.lease_token
+			AND tasks.status = 'processing'`
+
+	return execAffected(exec, ctx, query, []any{pq.Array(ids), pq.Array(leaseTokens), countAttempts})
+}
+
 func getTask(
 	exec psql.Executer,
 	ctx context.Context,
 	query string,
 	args []any,
 ) (*domain.Task, error) {
+	task, err := scanTask(exec.QueryRow(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+
+		return nil, fmt.Errorf("execute sql query: %w", err)
+	}
+
+	return task, nil
+}
+
+func getTasks(
+	exec psql.Executer,
+	ctx context.Context,
+	query string,
+	args []any,
+) ([]*domain.Task, error) {
+	rows, err := exec.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("execute sql query: %w", err)
+	}
+	defer rows.Close()
+
+	var tasks []*domain.Task
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan row: %w", err)
+		}
+
+		tasks = append(tasks, task)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate rows: %w", err)
+	}
+
+	return tasks, nil
+}
+
+func scanTask(row interface{ Scan(...any) error }) (*domain.Task, error) {
 	task := &domain.Task{}
 	lastFailDuration := sql.NullInt64{}
+	leaseToken := sql.NullString{}
 	scanDest := []any{
 		&task.ID,
 		&task.QueueName,
@@ -145,20 +403,39 @@ func getTask(
 		&task.CreatedAt,
 		&task.LockedUntil,
 		&lastFailDuration,
+		&leaseToken,
+		&task.Attempts,
+		&task.Priority,
 	}
 
-	if err := exec.QueryRow(ctx, query, args...).Scan(scanDest...); err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return nil, repository.ErrNotFound
-		}
-
-		return nil, fmt.Errorf("execute sql query: %w", err)
+	if err := row.Scan(scanDest...); err != nil {
+		return nil, err
 	}
 
 	task.LastFailDuration = fromSQLDuration(lastFailDuration)
+	task.LeaseToken = leaseToken.String
 	return task, nil
 }
 
+func execAffected(
+	exec psql.Executer,
+	ctx context.Context,
+	query string,
+	args []any,
+) (int64, error) {
+	result, err := exec.Exec(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("execute sql query: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("get affected rows: %w", err)
+	}
+
+	return affected, nil
+}
+
 func toSQLDuration(in *time.Duration) *int64 {
 	if in != nil {
 		sec := in.Seconds()
@@ -0,0 +1,180 @@
+package queue
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/art-es/queue-service/internal/app/domain"
+	"github.com/art-es/queue-service/internal/infra/ops"
+)
+
+const statsColumns = `
+	count(*) FILTER (WHERE status = 'pending') AS pending,
+	count(*) FILTER (WHERE status = 'processing') AS processing,
+	count(*) FILTER (WHERE status = 'failed') AS failed,
+	count(*) FILTER (WHERE status = 'dead') AS dead,
+	extract(epoch FROM now() - min(created_at) FILTER (WHERE status = 'pending'))::bigint AS oldest_pending_age,
+	min(locked_until) FILTER (WHERE status = 'failed' AND locked_until > now()) AS next_failed_unlock_at`
+
+func (r *Repository) GetStats(ctx context.Context, queueName string) (*domain.QueueStats, error) {
+	exec, err := r.execGetter.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT $1::text, ` + statsColumns + `
+		FROM tasks
+		WHERE queue_name = $1`
+
+	stats, err := scanStats(exec.QueryRow(ctx, query, queueName))
+	if err != nil {
+		return nil, fmt.Errorf("execute sql query: %w", err)
+	}
+
+	return stats, nil
+}
+
+// ListStats returns the stats of all the queues having settings or tasks ordered by the queue name.
+func (r *Repository) ListStats(ctx context.Context) ([]*domain.QueueStats, error) {
+	exec, err := r.execGetter.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		WITH stats AS (
+			SELECT queue_name, ` + statsColumns + `
+			FROM tasks
+			GROUP BY queue_name
+		)
+		SELECT
+			coalesce(stats.queue_name, queues.name) AS name,
+			coalesce(stats.pending, 0),
+			coalesce(stats.processing, 0),
+			coalesce(stats.failed, 0),
+			coalesce(stats.dead, 0),
+			stats.oldest_pending_age,
+			stats.next_failed_unlock_at
+		FROM stats
+		FULL JOIN queues ON queues.name = stats.queue_name
+		ORDER BY name`
+
+	rows, err := exec.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("execute sql query: %w", err)
+	}
+	defer rows.Close()
+
+	var list []*domain.QueueStats
+	for rows.Next() {
+		stats, err := scanStats(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan row: %w", err)
+		}
+
+		list = append(list, stats)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate rows: %w", err)
+	}
+
+	return list, nil
+}
+
+// GetThroughput returns the throughput of the queue for every period, in the order of the periods.
+// Counters are kept per minute, so a period also covers the beginning of its first minute.
+func (r *Repository) GetThroughput(
+	ctx context.Context,
+	queueName string,
+	periods []time.Duration,
+) ([]*domain.QueueThroughput, error) {
+	exec, err := r.execGetter.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT
+			p.secs,
+			coalesce(sum(t.pushed), 0),
+			coalesce(sum(t.acked), 0),
+			coalesce(sum(t.failed), 0)
+		FROM unnest($2::bigint[]) WITH ORDINALITY AS p(secs, n)
+		LEFT JOIN queue_throughput t
+			ON t.queue_name = $1
+			AND t.minute >= date_trunc('minute', now() - make_interval(secs => p.secs))
+		GROUP BY p.secs, p.n
+		ORDER BY p.n`
+
+	secs := make([]int64, 0, len(periods))
+	for _, period := range periods {
+		secs = append(secs, int64(period.Seconds()))
+	}
+
+	rows, err := exec.Query(ctx, query, queueName, pq.Array(secs))
+	if err != nil {
+		return nil, fmt.Errorf("execute sql query: %w", err)
+	}
+	defer rows.Close()
+
+	var list []*domain.QueueThroughput
+	for rows.Next() {
+		var periodSecs int64
+		throughput := &domain.QueueThroughput{}
+		if err = rows.Scan(&periodSecs, &throughput.Pushed, &throughput.Acked, &throughput.Failed); err != nil {
+			return nil, fmt.Errorf("scan row: %w", err)
+		}
+
+		throughput.Period = time.Duration(periodSecs) * time.Second
+		list = append(list, throughput)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate rows: %w", err)
+	}
+
+	return list, nil
+}
+
+// DeleteStaleThroughput deletes the throughput counters older than the period.
+func (r *Repository) DeleteStaleThroughput(ctx context.Context, period time.Duration) (int64, error) {
+	exec, err := r.execGetter.Get(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	query := `
+		DELETE FROM queue_throughput
+		WHERE minute < date_trunc('minute', now() - make_interval(secs => $1))`
+
+	return execAffected(exec, ctx, query, []any{int64(period.Seconds())})
+}
+
+func scanStats(row interface{ Scan(...any) error }) (*domain.QueueStats, error) {
+	stats := &domain.QueueStats{}
+	oldestPendingAge := sql.NullInt64{}
+	scanDest := []any{
+		&stats.QueueName,
+		&stats.Pending,
+		&stats.Processing,
+		&stats.Failed,
+		&stats.Dead,
+		&oldestPendingAge,
+		&stats.NextFailedUnlockAt,
+	}
+
+	if err := row.Scan(scanDest...); err != nil {
+		return nil, err
+	}
+
+	if oldestPendingAge.Valid {
+		stats.OldestPendingAge = ops.Pointer(time.Duration(oldestPendingAge.Int64) * time.Second)
+	}
+	return stats, nil
+}
@@ -3,16 +3,23 @@ package adapter
 import (
 	"context"
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/art-es/queue-service/internal/infra/metrics"
 	transport "github.com/art-es/queue-service/internal/transport/http"
 )
 
 type MuxRouter struct {
 	Mux *http.ServeMux
+
+	requestDuration metrics.Histogram
 }
 
 type muxHandler struct {
-	handler transport.Handler
+	pattern         string
+	handler         transport.Handler
+	requestDuration metrics.Histogram
 }
 
 type muxContext struct {
@@ -21,22 +28,53 @@ type muxContext struct {
 	r *http.Request
 }
 
-func NewMuxRouter() *MuxRouter {
+// statusWriter keeps the response status for the request metrics.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func NewMuxRouter(metrics metrics.Metrics) *MuxRouter {
 	return &MuxRouter{
 		Mux: http.NewServeMux(),
+		requestDuration: metrics.Histogram(
+			"http_request_duration_seconds",
+			"HTTP request duration by endpoint and status.",
+			nil,
+			"endpoint", "status",
+		),
 	}
 }
 
 func (r *MuxRouter) Register(pattern string, handler transport.Handler) {
-	r.Mux.Handle(pattern, &muxHandler{handler: handler})
+	r.Mux.Handle(pattern, &muxHandler{
+		pattern:         pattern,
+		handler:         handler,
+		requestDuration: r.requestDuration,
+	})
 }
 
 func (h *muxHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+
 	h.handler.Handle(&muxContext{
 		Context: r.Context(),
 		r:       r,
-		w:       w,
+		w:       sw,
 	})
+
+	h.requestDuration.Observe(time.Since(start).Seconds(), h.pattern, strconv.Itoa(sw.status))
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
 }
 
 func (c *muxContext) Request() *http.Request              { return c.r }
@@ -1,15 +1,41 @@
 package endpoints
 
 import (
+	"github.com/art-es/queue-service/internal/transport/http/endpoints/healthz"
+	"github.com/art-es/queue-service/internal/transport/http/endpoints/metrics"
+	"github.com/art-es/queue-service/internal/transport/http/endpoints/readyz"
+	"github.com/art-es/queue-service/internal/transport/http/endpoints/v1_queues_dead"
+	"github.com/art-es/queue-service/internal/transport/http/endpoints/v1_queues_dead_purge"
+	"github.com/art-es/queue-service/internal/transport/http/endpoints/v1_queues_dead_redrive"
+	"github.com/art-es/queue-service/internal/transport/http/endpoints/v1_queues_delete"
+	"github.com/art-es/queue-service/internal/transport/http/endpoints/v1_queues_get"
+	"github.com/art-es/queue-service/internal/transport/http/endpoints/v1_queues_list"
 	"github.com/art-es/queue-service/internal/transport/http/endpoints/v1_queues_pop"
 	"github.com/art-es/queue-service/internal/transport/http/endpoints/v1_queues_push"
+	"github.com/art-es/queue-service/internal/transport/http/endpoints/v1_queues_push_batch"
+	"github.com/art-es/queue-service/internal/transport/http/endpoints/v1_queues_put"
+	"github.com/art-es/queue-service/internal/transport/http/endpoints/v1_queues_stats"
 	"github.com/art-es/queue-service/internal/transport/http/endpoints/v1_tasks_ack"
+	"github.com/art-es/queue-service/internal/transport/http/endpoints/v1_tasks_extend"
 	"github.com/art-es/queue-service/internal/transport/http/endpoints/v1_tasks_nack"
 )
 
 var (
-	RegisterV1QueuesPop  = v1_queues_pop.Register
-	RegisterV1QueuesPush = v1_queues_push.Register
-	RegisterV1TasksAck   = v1_tasks_ack.Register
-	RegisterV1TasksNack  = v1_tasks_nack.Register
+	RegisterHealthz             = healthz.Register
+	RegisterMetrics             = metrics.Register
+	RegisterReadyz              = readyz.Register
+	RegisterV1QueuesDead        = v1_queues_dead.Register
+	RegisterV1QueuesDeadPurge   = v1_queues_dead_purge.Register
+	RegisterV1QueuesDeadRedrive = v1_queues_dead_redrive.Register
+	RegisterV1QueuesDelete      = v1_queues_delete.Register
+	RegisterV1QueuesGet         = v1_queues_get.Register
+	RegisterV1QueuesList        = v1_queues_list.Register
+	RegisterV1QueuesPop         = v1_queues_pop.Register
+	RegisterV1QueuesPush        = v1_queues_push.Register
+	RegisterV1QueuesPushBatch   = v1_queues_push_batch.Register
+	RegisterV1QueuesPut         = v1_queues_put.Register
+	RegisterV1QueuesStats       = v1_queues_stats.Register
+	RegisterV1TasksAck          = v1_tasks_ack.Register
+	RegisterV1TasksExtend       = v1_tasks_extend.Register
+	RegisterV1TasksNack         = v1_tasks_nack.Register
 )
@@ -0,0 +1,9 @@
+package healthz
+
+import (
+	transport "github.com/art-es/queue-service/internal/transport/http"
+)
+
+func Register(router transport.Router) {
+	router.Register("GET /healthz", newHandler())
+}
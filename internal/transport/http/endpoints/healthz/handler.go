@@ -0,0 +1,18 @@
+package healthz
+
+import (
+	"net/http"
+
+	transport "github.com/art-es/queue-service/internal/transport/http"
+)
+
+// handler reports the process is alive, it does not depend on anything the process can't fix by itself.
+type handler struct{}
+
+func newHandler() *handler {
+	return &handler{}
+}
+
+func (h *handler) Handle(ctx transport.Context) {
+	transport.WriteEmpty(ctx, http.StatusOK)
+}
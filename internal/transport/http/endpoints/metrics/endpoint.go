@@ -0,0 +1,15 @@
+package metrics
+
+import (
+	"io"
+
+	transport "github.com/art-es/queue-service/internal/transport/http"
+)
+
+type registry interface {
+	WriteText(w io.Writer) error
+}
+
+func Register(router transport.Router, registry registry) {
+	router.Register("GET /metrics", newHandler(registry))
+}
@@ -0,0 +1,27 @@
+package metrics
+
+import (
+	"net/http"
+
+	transport "github.com/art-es/queue-service/internal/transport/http"
+)
+
+// contentType is the Prometheus text exposition format.
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+type handler struct {
+	registry registry
+}
+
+func newHandler(registry registry) *handler {
+	return &handler{
+		registry: registry,
+	}
+}
+
+func (h *handler) Handle(ctx transport.Context) {
+	w := ctx.ResponseWriter()
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	_ = h.registry.WriteText(w)
+}
@@ -0,0 +1,16 @@
+package readyz
+
+import (
+	"context"
+
+	"github.com/art-es/queue-service/internal/infra/log"
+	transport "github.com/art-es/queue-service/internal/transport/http"
+)
+
+type healthService interface {
+	Ready(ctx context.Context) error
+}
+
+func Register(router transport.Router, healthService healthService, logger log.Logger) {
+	router.Register("GET /readyz", newHandler(healthService, logger))
+}
@@ -0,0 +1,57 @@
+package readyz
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/art-es/queue-service/internal/app/services/health"
+	"github.com/art-es/queue-service/internal/infra/log"
+	transport "github.com/art-es/queue-service/internal/transport/http"
+)
+
+const (
+	messageShuttingDown        = "Shutting down"
+	messageMigrationsBehind    = "Migrations are behind"
+	messageDatabaseUnavailable = "Database is unavailable"
+)
+
+type handler struct {
+	healthService healthService
+	logger        log.Logger
+}
+
+func newHandler(healthService healthService, logger log.Logger) *handler {
+	logger = logger.With("module", "internal/transport/http/endpoints/readyz")
+
+	return &handler{
+		healthService: healthService,
+		logger:        logger,
+	}
+}
+
+func (h *handler) Handle(ctx transport.Context) {
+	err := h.healthService.Ready(ctx)
+	if err == nil {
+		transport.WriteEmpty(ctx, http.StatusOK)
+		return
+	}
+
+	switch {
+	case errors.Is(err, health.ErrShuttingDown):
+		transport.WriteServiceUnavailable(ctx, messageShuttingDown)
+	case errors.Is(err, health.ErrMigrationsBehind):
+		h.logger.Log(log.LevelWarning).
+			With("message", "not ready").
+			With("error", err.Error()).
+			Write()
+
+		transport.WriteServiceUnavailable(ctx, messageMigrationsBehind)
+	default:
+		h.logger.Log(log.LevelWarning).
+			With("message", "not ready").
+			With("error", err.Error()).
+			Write()
+
+		transport.WriteServiceUnavailable(ctx, messageDatabaseUnavailable)
+	}
+}
@@ -0,0 +1,17 @@
+package v1_queues_dead
+
+import (
+	"context"
+
+	"github.com/art-es/queue-service/internal/app/domain"
+	"github.com/art-es/queue-service/internal/infra/log"
+	transport "github.com/art-es/queue-service/internal/transport/http"
+)
+
+type queueService interface {
+	ListDead(ctx context.Context, queueName string, limit int) ([]*domain.Task, error)
+}
+
+func Register(router transport.Router, queueService queueService, logger log.Logger) {
+	router.Register("GET /v1/queues/{queueName}/dead", newHandler(queueService, logger))
+}
@@ -0,0 +1,106 @@
+package v1_queues_dead
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/art-es/queue-service/internal/infra/log"
+	transport "github.com/art-es/queue-service/internal/transport/http"
+)
+
+const (
+	defaultLimit = 100
+	maxLimit     = 1000
+)
+
+type responseBody struct {
+	Tasks []*responseBodyTask `json:"tasks"`
+}
+
+type responseBodyTask struct {
+	ID        string `json:"id"`
+	Payload   string `json:"payload"`
+	CreatedAt string `json:"created_at"`
+	Attempts  int    `json:"attempts"`
+}
+
+type handler struct {
+	queueService queueService
+	logger       log.Logger
+}
+
+func newHandler(queueService queueService, logger log.Logger) *handler {
+	logger = logger.With("module", "internal/transport/http/endpoints/v1_queues_dead")
+
+	return &handler{
+		queueService: queueService,
+		logger:       logger,
+	}
+}
+
+func (h *handler) Handle(ctx transport.Context) {
+	queueName := ctx.Request().PathValue("queueName")
+
+	if len(queueName) == 0 {
+		transport.WriteBadRequestFields(ctx, transport.CommonResponseBodyField{
+			Name:   "queueName",
+			Reason: transport.ReasonEmpty,
+		})
+		return
+	}
+
+	limit := defaultLimit
+	if rawLimit := ctx.Request().URL.Query().Get("limit"); rawLimit != "" {
+		var err error
+		if limit, err = strconv.Atoi(rawLimit); err != nil {
+			transport.WriteBadRequestFields(ctx, transport.CommonResponseBodyField{
+				Name:   "limit",
+				Reason: transport.ReasonInvalid,
+			})
+			return
+		}
+
+		if limit < 1 {
+			transport.WriteBadRequestFields(ctx, transport.CommonResponseBodyField{
+				Name:   "limit",
+				Reason: transport.ReasonTooSmall,
+			})
+			return
+		}
+
+		if limit > maxLimit {
+			transport.WriteBadRequestFields(ctx, transport.CommonResponseBodyField{
+				Name:   "limit",
+				Reason: transport.ReasonTooLarge,
+			})
+			return
+		}
+	}
+
+	tasks, err := h.queueService.ListDead(ctx, queueName, limit)
+	if err != nil {
+		h.logger.Log(log.LevelError).
+			With("message", "queue service error").
+			With("error", err.Error()).
+			With("queue_name", queueName).
+			Write()
+
+		transport.WriteInternalError(ctx)
+		return
+	}
+
+	rb := &responseBody{
+		Tasks: make([]*responseBodyTask, 0, len(tasks)),
+	}
+	for _, task := range tasks {
+		rb.Tasks = append(rb.Tasks, &responseBodyTask{
+			ID:        task.ID,
+			Payload:   task.Payload,
+			CreatedAt: task.CreatedAt.Format(time.DateTime),
+			Attempts:  task.Attempts,
+		})
+	}
+
+	transport.Write(ctx, http.StatusOK, rb)
+}
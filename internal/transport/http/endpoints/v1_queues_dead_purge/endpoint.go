@@ -0,0 +1,16 @@
+package v1_queues_dead_purge
+
+import (
+	"context"
+
+	"github.com/art-es/queue-service/internal/infra/log"
+	transport "github.com/art-es/queue-service/internal/transport/http"
+)
+
+type queueService interface {
+	PurgeDead(ctx context.Context, queueName string) (int64, error)
+}
+
+func Register(router transport.Router, queueService queueService, logger log.Logger) {
+	router.Register("DELETE /v1/queues/{queueName}/dead", newHandler(queueService, logger))
+}
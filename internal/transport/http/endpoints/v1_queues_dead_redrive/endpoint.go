@@ -0,0 +1,16 @@
+package v1_queues_dead_redrive
+
+import (
+	"context"
+
+	"github.com/art-es/queue-service/internal/infra/log"
+	transport "github.com/art-es/queue-service/internal/transport/http"
+)
+
+type queueService interface {
+	RedriveDead(ctx context.Context, queueName string) (int64, error)
+}
+
+func Register(router transport.Router, queueService queueService, logger log.Logger) {
+	router.Register("POST /v1/queues/{queueName}/dead/redrive", newHandler(queueService, logger))
+}
@@ -0,0 +1,54 @@
+package v1_queues_dead_redrive
+
+import (
+	"net/http"
+
+	"github.com/art-es/queue-service/internal/infra/log"
+	transport "github.com/art-es/queue-service/internal/transport/http"
+)
+
+type responseBody struct {
+	Count int64 `json:"count"`
+}
+
+type handler struct {
+	queueService queueService
+	logger       log.Logger
+}
+
+func newHandler(queueService queueService, logger log.Logger) *handler {
+	logger = logger.With("module", "internal/transport/http/endpoints/v1_queues_dead_redrive")
+
+	return &handler{
+		queueService: queueService,
+		logger:       logger,
+	}
+}
+
+func (h *handler) Handle(ctx transport.Context) {
+	queueName := ctx.Request().PathValue("queueName")
+
+	if len(queueName) == 0 {
+		transport.WriteBadRequestFields(ctx, transport.CommonResponseBodyField{
+			Name:   "queueName",
+			Reason: transport.ReasonEmpty,
+		})
+		return
+	}
+
+	count, err := h.queueService.RedriveDead(ctx, queueName)
+	if err != nil {
+		h.logger.Log(log.LevelError).
+			With("message", "queue service error").
+			With("error", err.Error()).
+			With("queue_name", queueName).
+			Write()
+
+		transport.WriteInternalError(ctx)
+		return
+	}
+
+	transport.Write(ctx, http.StatusOK, &responseBody{
+		Count: count,
+	})
+}
@@ -0,0 +1,16 @@
+package v1_queues_delete
+
+import (
+	"context"
+
+	"github.com/art-es/queue-service/internal/infra/log"
+	transport "github.com/art-es/queue-service/internal/transport/http"
+)
+
+type queueService interface {
+	DeleteQueue(ctx context.Context, name string) error
+}
+
+func Register(router transport.Router, queueService queueService, logger log.Logger) {
+	router.Register("DELETE /v1/queues/{queueName}", newHandler(queueService, logger))
+}
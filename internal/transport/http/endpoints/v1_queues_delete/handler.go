@@ -0,0 +1,58 @@
+package v1_queues_delete
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/art-es/queue-service/internal/app/services/queue"
+	"github.com/art-es/queue-service/internal/infra/log"
+	transport "github.com/art-es/queue-service/internal/transport/http"
+)
+
+const (
+	messageQueueNotFound = "Queue is not found"
+)
+
+type handler struct {
+	queueService queueService
+	logger       log.Logger
+}
+
+func newHandler(queueService queueService, logger log.Logger) *handler {
+	logger = logger.With("module", "internal/transport/http/endpoints/v1_queues_delete")
+
+	return &handler{
+		queueService: queueService,
+		logger:       logger,
+	}
+}
+
+func (h *handler) Handle(ctx transport.Context) {
+	queueName := ctx.Request().PathValue("queueName")
+
+	if len(queueName) == 0 {
+		transport.WriteBadRequestFields(ctx, transport.CommonResponseBodyField{
+			Name:   "queueName",
+			Reason: transport.ReasonEmpty,
+		})
+		return
+	}
+
+	if err := h.queueService.DeleteQueue(ctx, queueName); err != nil {
+		if errors.Is(err, queue.ErrQueueNotFound) {
+			transport.WriteNotFound(ctx, messageQueueNotFound)
+			return
+		}
+
+		h.logger.Log(log.LevelError).
+			With("message", "queue service error").
+			With("error", err.Error()).
+			With("queue_name", queueName).
+			Write()
+
+		transport.WriteInternalError(ctx)
+		return
+	}
+
+	transport.WriteEmpty(ctx, http.StatusNoContent)
+}
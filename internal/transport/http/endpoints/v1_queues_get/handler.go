@@ -0,0 +1,105 @@
+package v1_queues_get
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/art-es/queue-service/internal/app/domain"
+	"github.com/art-es/queue-service/internal/app/services/queue"
+	"github.com/art-es/queue-service/internal/infra/log"
+	transport "github.com/art-es/queue-service/internal/transport/http"
+)
+
+const (
+	messageQueueNotFound = "Queue is not found"
+)
+
+type responseBody struct {
+	Queue *responseBodyQueue `json:"queue"`
+}
+
+type responseBodyQueue struct {
+	Name                        string                    `json:"name"`
+	VisibilityTimeoutSeconds    int                       `json:"visibility_timeout_seconds"`
+	MaxVisibilityTimeoutSeconds int                       `json:"max_visibility_timeout_seconds"`
+	Backoff                     *responseBodyQueueBackoff `json:"backoff"`
+	MaxAttempts                 int                       `json:"max_attempts"`
+	MaxPayloadSize              int                       `json:"max_payload_size"`
+	RetentionSeconds            int                       `json:"retention_seconds"`
+	DisconnectCountsAttempt     bool                      `json:"disconnect_counts_attempt"`
+	CreatedAt                   string                    `json:"created_at"`
+	UpdatedAt                   string                    `json:"updated_at"`
+}
+
+type responseBodyQueueBackoff struct {
+	Policy         string `json:"policy"`
+	InitialSeconds int    `json:"initial_seconds"`
+	MaxSeconds     int    `json:"max_seconds"`
+}
+
+type handler struct {
+	queueService queueService
+	logger       log.Logger
+}
+
+func newHandler(queueService queueService, logger log.Logger) *handler {
+	logger = logger.With("module", "internal/transport/http/endpoints/v1_queues_get")
+
+	return &handler{
+		queueService: queueService,
+		logger:       logger,
+	}
+}
+
+func (h *handler) Handle(ctx transport.Context) {
+	queueName := ctx.Request().PathValue("queueName")
+
+	if len(queueName) == 0 {
+		transport.WriteBadRequestFields(ctx, transport.CommonResponseBodyField{
+			Name:   "queueName",
+			Reason: transport.ReasonEmpty,
+		})
+		return
+	}
+
+	q, err := h.queueService.GetQueue(ctx, queueName)
+	if err != nil {
+		if errors.Is(err, queue.ErrQueueNotFound) {
+			transport.WriteNotFound(ctx, messageQueueNotFound)
+			return
+		}
+
+		h.logger.Log(log.LevelError).
+			With("message", "queue service error").
+			With("error", err.Error()).
+			With("queue_name", queueName).
+			Write()
+
+		transport.WriteInternalError(ctx)
+		return
+	}
+
+	transport.Write(ctx, http.StatusOK, &responseBody{
+		Queue: toResponseBodyQueue(q),
+	})
+}
+
+func toResponseBodyQueue(q *domain.Queue) *responseBodyQueue {
+	return &responseBodyQueue{
+		Name:                        q.Name,
+		VisibilityTimeoutSeconds:    int(q.VisibilityTimeout.Seconds()),
+		MaxVisibilityTimeoutSeconds: int(q.MaxVisibilityTimeout.Seconds()),
+		Backoff: &responseBodyQueueBackoff{
+			Policy:         q.Backoff.Policy,
+			InitialSeconds: int(q.Backoff.Initial.Seconds()),
+			MaxSeconds:     int(q.Backoff.Max.Seconds()),
+		},
+		MaxAttempts:             q.MaxAttempts,
+		MaxPayloadSize:          q.MaxPayloadSize,
+		RetentionSeconds:        int(q.Retention.Seconds()),
+		DisconnectCountsAttempt: q.DisconnectCountsAttempt,
+		CreatedAt:               q.CreatedAt.Format(time.DateTime),
+		UpdatedAt:               q.UpdatedAt.Format(time.DateTime),
+	}
+}
@@ -0,0 +1,81 @@
+package v1_queues_list
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/art-es/queue-service/internal/app/domain"
+	"github.com/art-es/queue-service/internal/infra/log"
+	"github.com/art-es/queue-service/internal/infra/ops"
+	transport "github.com/art-es/queue-service/internal/transport/http"
+)
+
+type responseBody struct {
+	Queues []*responseBodyQueue `json:"queues"`
+}
+
+type responseBodyQueue struct {
+	Name                    string  `json:"name"`
+	Pending                 int64   `json:"pending"`
+	Processing              int64   `json:"processing"`
+	Failed                  int64   `json:"failed"`
+	Dead                    int64   `json:"dead"`
+	OldestPendingAgeSeconds *int64  `json:"oldest_pending_age_seconds"`
+	NextFailedUnlockAt      *string `json:"next_failed_unlock_at"`
+}
+
+type handler struct {
+	queueService queueService
+	logger       log.Logger
+}
+
+func newHandler(queueService queueService, logger log.Logger) *handler {
+	logger = logger.With("module", "internal/transport/http/endpoints/v1_queues_list")
+
+	return &handler{
+		queueService: queueService,
+		logger:       logger,
+	}
+}
+
+func (h *handler) Handle(ctx transport.Context) {
+	list, err := h.queueService.ListQueueStats(ctx)
+	if err != nil {
+		h.logger.Log(log.LevelError).
+			With("message", "queue service error").
+			With("error", err.Error()).
+			Write()
+
+		transport.WriteInternalError(ctx)
+		return
+	}
+
+	rb := &responseBody{
+		Queues: make([]*responseBodyQueue, 0, len(list)),
+	}
+	for _, stats := range list {
+		rb.Queues = append(rb.Queues, toResponseBodyQueue(stats))
+	}
+
+	transport.Write(ctx, http.StatusOK, rb)
+}
+
+func toResponseBodyQueue(stats *domain.QueueStats) *responseBodyQueue {
+	rb := &responseBodyQueue{
+		Name:       stats.QueueName,
+		Pending:    stats.Pending,
+		Processing: stats.Processing,
+		Failed:     stats.Failed,
+		Dead:       stats.Dead,
+	}
+
+	if stats.OldestPendingAge != nil {
+		rb.OldestPendingAgeSeconds = ops.Pointer(int64(stats.OldestPendingAge.Seconds()))
+	}
+
+	if stats.NextFailedUnlockAt != nil {
+		rb.NextFailedUnlockAt = ops.Pointer(stats.NextFailedUnlockAt.Format(time.DateTime))
+	}
+
+	return rb
+}
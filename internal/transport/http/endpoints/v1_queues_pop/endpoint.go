@@ -2,6 +2,7 @@ package v1_queues_pop
 
 import (
 	"context"
+	"time"
 
 	"github.com/art-es/queue-service/internal/app/domain"
 	"github.com/art-es/queue-service/internal/infra/log"
@@ -9,7 +10,9 @@ import (
 )
 
 type queueService interface {
-	Pop(ctx context.Context, queueName string) (*domain.Task, error)
+	Pop(ctx context.Context, queueName string, max int) ([]*domain.Task, error)
+	PopWait(ctx context.Context, queueName string, max int, wait time.Duration) ([]*domain.Task, error)
+	Release(ctx context.Context, tasks []*domain.Task) error
 }
 
 func Register(router transport.Router, queueService queueService, logger log.Logger) {
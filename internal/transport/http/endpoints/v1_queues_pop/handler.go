@@ -2,20 +2,32 @@ package v1_queues_pop
 
 import (
 	"net/http"
+	"strconv"
 	"time"
 
+	"github.com/art-es/queue-service/internal/app/domain"
 	"github.com/art-es/queue-service/internal/infra/log"
 	transport "github.com/art-es/queue-service/internal/transport/http"
 )
 
+const (
+	defaultMax     = 1
+	maxMax         = 100
+	maxWaitSeconds = 20
+)
+
 type responseBody struct {
-	Task *responseBodyTask `json:"task"`
+	Task  *responseBodyTask   `json:"task"` // First of tasks, kept for single task clients
+	Tasks []*responseBodyTask `json:"tasks"`
 }
 
 type responseBodyTask struct {
-	ID        string `json:"id"`
-	Payload   string `json:"payload"`
-	CreatedAt string `json:"created_at"`
+	ID         string `json:"id"`
+	Payload    string `json:"payload"`
+	CreatedAt  string `json:"created_at"`
+	LeaseToken string `json:"lease_token"`
+	Attempts   int    `json:"attempts"`
+	Priority   int    `json:"priority"`
 }
 
 type handler struct {
@@ -43,7 +55,58 @@ func (h *handler) Handle(ctx transport.Context) {
 		return
 	}
 
-	task, err := h.queueService.Pop(ctx, queueName)
+	max := defaultMax
+	if rawMax := ctx.Request().URL.Query().Get("max"); rawMax != "" {
+		var err error
+		if max, err = strconv.Atoi(rawMax); err != nil {
+			transport.WriteBadRequestFields(ctx, transport.CommonResponseBodyField{
+				Name:   "max",
+				Reason: transport.ReasonInvalid,
+			})
+			return
+		}
+
+		if max < 1 {
+			transport.WriteBadRequestFields(ctx, transport.CommonResponseBodyField{
+				Name:   "max",
+				Reason: transport.ReasonTooSmall,
+			})
+			return
+		}
+
+		if max > maxMax {
+			transport.WriteBadRequestFields(ctx, transport.CommonResponseBodyField{
+				Name:   "max",
+				Reason: transport.ReasonTooLarge,
+			})
+			return
+		}
+	}
+
+	wait := 0
+	if rawWait := ctx.Request().URL.Query().Get("wait_seconds"); rawWait != "" {
+		var err error
+		if wait, err = strconv.Atoi(rawWait); err != nil {
+			transport.WriteBadRequestFields(ctx, transport.CommonResponseBodyField{
+				Name:   "wait_seconds",
+				Reason: transport.ReasonInvalid,
+			})
+			return
+		}
+
+		if wait < 0 {
+			transport.WriteBadRequestFields(ctx, transport.CommonResponseBodyField{
+				Name:   "wait_seconds",
+				Reason: transport.ReasonTooSmall,
+			})
+			return
+		}
+
+		// Capped instead of rejected, so clients may ask for the longest wait they can afford
+		wait = min(wait, maxWaitSeconds)
+	}
+
+	tasks, err := h.pop(ctx, queueName, max, time.Duration(wait)*time.Second)
 	if err != nil {
 		h.logger.Log(log.LevelError).
 			With("message", "queue service error").
@@ -55,16 +118,52 @@ func (h *handler) Handle(ctx transport.Context) {
 		return
 	}
 
-	if task == nil {
+	if len(tasks) == 0 {
 		transport.WriteEmpty(ctx, http.StatusNoContent)
 		return
 	}
 
-	transport.Write(ctx, http.StatusOK, &responseBody{
-		Task: &responseBodyTask{
-			ID:        task.ID,
-			Payload:   task.Payload,
-			CreatedAt: task.CreatedAt.Format(time.DateTime),
-		},
-	})
+	rb := &responseBody{
+		Tasks: make([]*responseBodyTask, 0, len(tasks)),
+	}
+	for _, task := range tasks {
+		rb.Tasks = append(rb.Tasks, toResponseBodyTask(task))
+	}
+	rb.Task = rb.Tasks[0]
+
+	// The tasks are already processing, if the client does not get them they are released
+	// instead of being locked for the visibility timeout.
+	if err = transport.WriteFlush(ctx, http.StatusOK, rb); err != nil {
+		h.logger.Log(log.LevelWarning).
+			With("message", "write response error, releasing tasks").
+			With("error", err.Error()).
+			With("queue_name", queueName).
+			Write()
+
+		if err = h.queueService.Release(ctx, tasks); err != nil {
+			h.logger.Log(log.LevelError).
+				With("message", "queue service release error").
+				With("error", err.Error()).
+				With("queue_name", queueName).
+				Write()
+		}
+	}
+}
+
+func (h *handler) pop(ctx transport.Context, queueName string, max int, wait time.Duration) ([]*domain.Task, error) {
+	if wait == 0 {
+		return h.queueService.Pop(ctx, queueName, max)
+	}
+	return h.queueService.PopWait(ctx, queueName, max, wait)
+}
+
+func toResponseBodyTask(task *domain.Task) *responseBodyTask {
+	return &responseBodyTask{
+		ID:         task.ID,
+		Payload:    task.Payload,
+		CreatedAt:  task.CreatedAt.Format(time.DateTime),
+		LeaseToken: task.LeaseToken,
+		Attempts:   task.Attempts,
+		Priority:   task.Priority,
+	}
 }
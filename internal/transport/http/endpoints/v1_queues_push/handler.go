@@ -2,20 +2,22 @@ package v1_queues_push
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"time"
 
+	"github.com/art-es/queue-service/internal/app/domain"
 	"github.com/art-es/queue-service/internal/app/services/queue"
 	"github.com/art-es/queue-service/internal/infra/log"
 	transport "github.com/art-es/queue-service/internal/transport/http"
 )
 
 const (
-	maxPayloadSize = 1024 * 4
+	messageIdempotencyKeyReused = "Idempotency key is already used for a different request"
 )
 
 type requestBody struct {
-	Payload string `json:"payload"`
+	transport.PushFields
 }
 
 type responseBody struct {
@@ -50,6 +52,19 @@ func (h *handler) Handle(ctx transport.Context) {
 
 	task, err := h.queueService.Push(ctx, req)
 	if err != nil {
+		if errors.Is(err, domain.ErrPayloadTooLarge) {
+			transport.WriteBadRequestFields(ctx, transport.CommonResponseBodyField{
+				Name:   "payload",
+				Reason: transport.ReasonTooLarge,
+			})
+			return
+		}
+
+		if errors.Is(err, domain.ErrIdempotencyKeyReused) {
+			transport.WriteUnprocessableEntity(ctx, messageIdempotencyKeyReused)
+			return
+		}
+
 		h.logger.Log(log.LevelError).
 			With("message", "queue service error").
 			With("error", err.Error()).
@@ -86,19 +101,9 @@ func parseRequest(ctx transport.Context) *queue.PushRequest {
 		return nil
 	}
 
-	if rb.Payload == "" {
-		transport.WriteBadRequestFields(ctx, transport.CommonResponseBodyField{
-			Name:   "payload",
-			Reason: transport.ReasonEmpty,
-		})
-		return nil
-	}
-
-	if len(rb.Payload) > maxPayloadSize {
-		transport.WriteBadRequestFields(ctx, transport.CommonResponseBodyField{
-			Name:   "payload",
-			Reason: transport.ReasonTooLarge,
-		})
+	schedule, fields := transport.CheckPushFields("", &rb.PushFields)
+	if len(fields) > 0 {
+		transport.WriteBadRequestFields(ctx, fields...)
 		return nil
 	}
 
@@ -106,5 +111,8 @@ func parseRequest(ctx transport.Context) *queue.PushRequest {
 		IdempotencyKey: transport.GetIdempotencyKey(ctx),
 		QueueName:      queueName,
 		Payload:        rb.Payload,
+		Delay:          schedule.Delay,
+		NotBefore:      schedule.NotBefore,
+		Priority:       rb.Priority,
 	}
 }
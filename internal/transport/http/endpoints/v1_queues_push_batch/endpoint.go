@@ -0,0 +1,19 @@
+//go:generate mockgen -source=endpoint.go -destination=endpoint_mock_test.go -package=$GOPACKAGE
+
+package v1_queues_push_batch
+
+import (
+	"context"
+
+	"github.com/art-es/queue-service/internal/app/services/queue"
+	"github.com/art-es/queue-service/internal/infra/log"
+	transport "github.com/art-es/queue-service/internal/transport/http"
+)
+
+type queueService interface {
+	PushBatch(ctx context.Context, req *queue.PushBatchRequest) ([]*queue.PushBatchResult, error)
+}
+
+func Register(router transport.Router, queueService queueService, logger log.Logger) {
+	router.Register("POST /v1/queues/{queueName}/push-batch", newHandler(queueService, logger))
+}
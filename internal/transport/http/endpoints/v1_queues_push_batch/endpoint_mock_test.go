@@ -0,0 +1,57 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: endpoint.go
+//
+// Generated by this command:
+//
+//	mockgen -source=endpoint.go -destination=endpoint_mock_test.go -package=v1_queues_push_batch
+//
+
+// Package v1_queues_push_batch is a generated GoMock package.
+package v1_queues_push_batch
+
+import (
+	context "context"
+	reflect "reflect"
+
+	queue "github.com/art-es/queue-service/internal/app/services/queue"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockqueueService is a mock of queueService interface.
+type MockqueueService struct {
+	ctrl     *gomock.Controller
+	recorder *MockqueueServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockqueueServiceMockRecorder is the mock recorder for MockqueueService.
+type MockqueueServiceMockRecorder struct {
+	mock *MockqueueService
+}
+
+// NewMockqueueService creates a new mock instance.
+func NewMockqueueService(ctrl *gomock.Controller) *MockqueueService {
+	mock := &MockqueueService{ctrl: ctrl}
+	mock.recorder = &MockqueueServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockqueueService) EXPECT() *MockqueueServiceMockRecorder {
+	return m.recorder
+}
+
+// PushBatch mocks base method.
+func (m *MockqueueService) PushBatch(ctx context.Context, req *queue.PushBatchRequest) ([]*queue.PushBatchResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PushBatch", ctx, req)
+	ret0, _ := ret[0].([]*queue.PushBatchResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PushBatch indicates an expected call of PushBatch.
+func (mr *MockqueueServiceMockRecorder) PushBatch(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PushBatch", reflect.TypeOf((*MockqueueService)(nil).PushBatch), ctx, req)
+}
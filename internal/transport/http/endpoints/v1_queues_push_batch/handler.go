@@ -0,0 +1,218 @@
+package v1_queues_push_batch
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/art-es/queue-service/internal/app/domain"
+	"github.com/art-es/queue-service/internal/app/services/queue"
+	"github.com/art-es/queue-service/internal/infra/log"
+	transport "github.com/art-es/queue-service/internal/transport/http"
+)
+
+const (
+	messageIdempotencyKeyReused = "Idempotency key is already used for a different request"
+	messageBodyTooLarge         = "Request body is too large"
+
+	maxItems    = 1000
+	maxBodySize = 1024 * 1024 * 16 // All the items together, a batch of max size payloads is to be split
+)
+
+type requestBody struct {
+	Items []*requestBodyItem `json:"items"`
+}
+
+type requestBodyItem struct {
+	transport.PushFields
+	IdempotencyKey *string `json:"idempotency_key"`
+}
+
+type responseBody struct {
+	Items []*responseBodyItem `json:"items"`
+}
+
+// responseBodyItem has either task or error set.
+type responseBodyItem struct {
+	Task  *responseBodyTask             `json:"task,omitempty"`
+	Error *transport.CommonResponseBody `json:"error,omitempty"`
+}
+
+type responseBodyTask struct {
+	ID        string `json:"id"`
+	Payload   string `json:"payload"`
+	CreatedAt string `json:"created_at"`
+}
+
+type handler struct {
+	queueService queueService
+	logger       log.Logger
+}
+
+func newHandler(queueService queueService, logger log.Logger) *handler {
+	logger = logger.With("module", "internal/transport/http/endpoints/v1_queues_push_batch")
+
+	return &handler{
+		queueService: queueService,
+		logger:       logger,
+	}
+}
+
+func (h *handler) Handle(ctx transport.Context) {
+	req := parseRequest(ctx)
+	if req == nil {
+		return
+	}
+
+	results, err := h.queueService.PushBatch(ctx, req)
+	if err != nil {
+		h.logger.Log(log.LevelError).
+			With("message", "queue service error").
+			With("error", err.Error()).
+			With("queue_name", req.QueueName).
+			Write()
+
+		transport.WriteInternalError(ctx)
+		return
+	}
+
+	rb := &responseBody{
+		Items: make([]*responseBodyItem, 0, len(results)),
+	}
+	for _, result := range results {
+		rb.Items = append(rb.Items, h.toResponseBodyItem(req.QueueName, result))
+	}
+
+	transport.Write(ctx, http.StatusOK, rb)
+}
+
+func (h *handler) toResponseBodyItem(queueName string, result *queue.PushBatchResult) *responseBodyItem {
+	if result.Err == nil {
+		return &responseBodyItem{
+			Task: &responseBodyTask{
+				ID:        result.Task.ID,
+				Payload:   result.Task.Payload,
+				CreatedAt: result.Task.CreatedAt.Format(time.DateTime),
+			},
+		}
+	}
+
+	if errors.Is(result.Err, domain.ErrPayloadTooLarge) {
+		return &responseBodyItem{
+			Error: &transport.CommonResponseBody{
+				Fields: []transport.CommonResponseBodyField{{
+					Name:   "payload",
+					Reason: transport.ReasonTooLarge,
+				}},
+			},
+		}
+	}
+
+	if errors.Is(result.Err, domain.ErrIdempotencyKeyReused) {
+		return &responseBodyItem{
+			Error: &transport.CommonResponseBody{
+				Message: messageIdempotencyKeyReused,
+			},
+		}
+	}
+
+	h.logger.Log(log.LevelError).
+		With("message", "queue service item error").
+		With("error", result.Err.Error()).
+		With("queue_name", queueName).
+		Write()
+
+	return &responseBodyItem{
+		Error: &transport.CommonResponseBody{
+			Message: "Internal error",
+		},
+	}
+}
+
+func parseRequest(ctx transport.Context) *queue.PushBatchRequest {
+	queueName := ctx.Request().PathValue("queueName")
+
+	if len(queueName) == 0 {
+		transport.WriteBadRequestFields(ctx, transport.CommonResponseBodyField{
+			Name:   "queueName",
+			Reason: transport.ReasonEmpty,
+		})
+		return nil
+	}
+
+	var rb requestBody
+	body := http.MaxBytesReader(ctx.ResponseWriter(), ctx.Request().Body, maxBodySize)
+	if err := json.NewDecoder(body).Decode(&rb); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			transport.WriteBadRequest(ctx, messageBodyTooLarge)
+			return nil
+		}
+
+		transport.WriteInvalidRequestBody(ctx)
+		return nil
+	}
+
+	if len(rb.Items) == 0 {
+		transport.WriteBadRequestFields(ctx, transport.CommonResponseBodyField{
+			Name:   "items",
+			Reason: transport.ReasonEmpty,
+		})
+		return nil
+	}
+
+	if len(rb.Items) > maxItems {
+		transport.WriteBadRequestFields(ctx, transport.CommonResponseBodyField{
+			Name:   "items",
+			Reason: transport.ReasonTooLarge,
+		})
+		return nil
+	}
+
+	req := &queue.PushBatchRequest{
+		QueueName: queueName,
+		Items:     make([]*queue.PushBatchItem, 0, len(rb.Items)),
+	}
+
+	var fields []transport.CommonResponseBodyField
+	for i, rbItem := range rb.Items {
+		item, itemFields := parseItem("items["+strconv.Itoa(i)+"].", rbItem)
+		fields = append(fields, itemFields...)
+		req.Items = append(req.Items, item)
+	}
+
+	if len(fields) > 0 {
+		transport.WriteBadRequestFields(ctx, fields...)
+		return nil
+	}
+
+	return req
+}
+
+func parseItem(prefix string, rb *requestBodyItem) (*queue.PushBatchItem, []transport.CommonResponseBodyField) {
+	if rb == nil {
+		return nil, []transport.CommonResponseBodyField{{
+			Name:   prefix + "payload",
+			Reason: transport.ReasonEmpty,
+		}}
+	}
+
+	schedule, fields := transport.CheckPushFields(prefix, &rb.PushFields)
+
+	if rb.IdempotencyKey != nil && *rb.IdempotencyKey == "" {
+		fields = append(fields, transport.CommonResponseBodyField{
+			Name:   prefix + "idempotency_key",
+			Reason: transport.ReasonEmpty,
+		})
+	}
+
+	return &queue.PushBatchItem{
+		Payload:        rb.Payload,
+		IdempotencyKey: rb.IdempotencyKey,
+		Delay:          schedule.Delay,
+		NotBefore:      schedule.NotBefore,
+		Priority:       rb.Priority,
+	}, fields
+}
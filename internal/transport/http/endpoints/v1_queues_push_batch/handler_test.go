@@ -0,0 +1,108 @@
+package v1_queues_push_batch
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/art-es/queue-service/internal/app/domain"
+	"github.com/art-es/queue-service/internal/app/services/queue"
+	"github.com/art-es/queue-service/internal/infra/log/logimpl"
+	"github.com/art-es/queue-service/internal/infra/metrics/metricsimpl"
+	"github.com/art-es/queue-service/internal/infra/ops"
+	"github.com/art-es/queue-service/internal/transport/http/adapter"
+	"github.com/stretchr/testify/assert"
+	gomock "go.uber.org/mock/gomock"
+)
+
+func TestHandler_Handle(t *testing.T) {
+	const queueName = "testQueueName"
+
+	type testDeps struct {
+		mockQueueService *MockqueueService
+		push             func(body string) *httptest.ResponseRecorder
+	}
+
+	for _, tc := range []struct {
+		name string
+		run  func(t *testing.T, d testDeps)
+	}{
+		{
+			name: "push batch",
+			run: func(t *testing.T, d testDeps) {
+				createdAt := time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)
+
+				d.mockQueueService.EXPECT().
+					PushBatch(gomock.Any(), gomock.Eq(&queue.PushBatchRequest{
+						QueueName: queueName,
+						Items: []*queue.PushBatchItem{{
+							Payload:        "testPayload",
+							IdempotencyKey: ops.Pointer("testKey"),
+							Delay:          ops.Pointer(time.Minute),
+							Priority:       1,
+						}},
+					})).
+					Return([]*queue.PushBatchResult{{
+						Task: &domain.Task{ID: "testTaskID", Payload: "testPayload", CreatedAt: createdAt},
+					}}, nil)
+
+				rec := d.push(`{"items":[{"payload":"testPayload","idempotency_key":"testKey","delay_seconds":60,"priority":1}]}`)
+				assert.Equal(t, http.StatusOK, rec.Code)
+				assert.JSONEq(t, `{"items":[{"task":{"id":"testTaskID","payload":"testPayload","created_at":"2006-01-02 15:04:05"}}]}`, rec.Body.String())
+			},
+		},
+		{
+			name: "invalid items",
+			run: func(t *testing.T, d testDeps) {
+				rec := d.push(`{"items":[{"payload":"testPayload"},{"payload":"","priority":1001,"idempotency_key":""}]}`)
+				assert.Equal(t, http.StatusBadRequest, rec.Code)
+				assert.JSONEq(t, `{"fields":[
+					{"name":"items[1].payload","reason":"EMPTY"},
+					{"name":"items[1].priority","reason":"TOO_LARGE"},
+					{"name":"items[1].idempotency_key","reason":"EMPTY"}
+				]}`, rec.Body.String())
+			},
+		},
+		{
+			name: "body too large",
+			run: func(t *testing.T, d testDeps) {
+				payload := strings.Repeat("a", 1024*1024)
+				item := `{"payload":"` + payload + `"}`
+				items := strings.Repeat(item+",", maxBodySize/len(item)) + item
+
+				rec := d.push(`{"items":[` + items + `]}`)
+				assert.Equal(t, http.StatusBadRequest, rec.Code)
+				assert.JSONEq(t, `{"message":"Request body is too large"}`, rec.Body.String())
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mc := gomock.NewController(t)
+			defer mc.Finish()
+
+			mockQueueService := NewMockqueueService(mc)
+			logger, _ := logimpl.NewTestLogger()
+
+			router := adapter.NewMuxRouter(metricsimpl.NewRegistry())
+			Register(router, mockQueueService, logger)
+
+			tc.run(t, testDeps{
+				mockQueueService: mockQueueService,
+				push: func(body string) *httptest.ResponseRecorder {
+					req := httptest.NewRequestWithContext(
+						context.Background(),
+						http.MethodPost,
+						"/v1/queues/"+queueName+"/push-batch",
+						strings.NewReader(body),
+					)
+					rec := httptest.NewRecorder()
+					router.Mux.ServeHTTP(rec, req)
+					return rec
+				},
+			})
+		})
+	}
+}
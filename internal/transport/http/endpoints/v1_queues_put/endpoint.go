@@ -0,0 +1,17 @@
+package v1_queues_put
+
+import (
+	"context"
+
+	"github.com/art-es/queue-service/internal/app/domain"
+	"github.com/art-es/queue-service/internal/infra/log"
+	transport "github.com/art-es/queue-service/internal/transport/http"
+)
+
+type queueService interface {
+	SaveQueue(ctx context.Context, queue *domain.Queue) error
+}
+
+func Register(router transport.Router, queueService queueService, logger log.Logger) {
+	router.Register("PUT /v1/queues/{queueName}", newHandler(queueService, logger))
+}
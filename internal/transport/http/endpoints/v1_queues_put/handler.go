@@ -0,0 +1,235 @@
+package v1_queues_put
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/art-es/queue-service/internal/app/domain"
+	"github.com/art-es/queue-service/internal/infra/log"
+	transport "github.com/art-es/queue-service/internal/transport/http"
+)
+
+const (
+	maxTimeoutSeconds   = 60 * 60 * 12
+	maxBackoffSeconds   = 60 * 60 * 24 * 7
+	maxRetentionSeconds = 60 * 60 * 24 * 365
+	maxMaxAttempts      = 1000
+	maxMaxPayloadSize   = 1024 * 1024
+)
+
+// requestBody fields are optional, omitted fields take the default values.
+type requestBody struct {
+	VisibilityTimeoutSeconds    *int                `json:"visibility_timeout_seconds"`
+	MaxVisibilityTimeoutSeconds *int                `json:"max_visibility_timeout_seconds"`
+	Backoff                     *requestBodyBackoff `json:"backoff"`
+	MaxAttempts                 *int                `json:"max_attempts"`
+	MaxPayloadSize              *int                `json:"max_payload_size"`
+	RetentionSeconds            *int                `json:"retention_seconds"`
+	DisconnectCountsAttempt     *bool               `json:"disconnect_counts_attempt"`
+}
+
+type requestBodyBackoff struct {
+	Policy         *string `json:"policy"`
+	InitialSeconds *int    `json:"initial_seconds"`
+	MaxSeconds     *int    `json:"max_seconds"`
+}
+
+type responseBody struct {
+	Queue *responseBodyQueue `json:"queue"`
+}
+
+type responseBodyQueue struct {
+	Name                        string                    `json:"name"`
+	VisibilityTimeoutSeconds    int                       `json:"visibility_timeout_seconds"`
+	MaxVisibilityTimeoutSeconds int                       `json:"max_visibility_timeout_seconds"`
+	Backoff                     *responseBodyQueueBackoff `json:"backoff"`
+	MaxAttempts                 int                       `json:"max_attempts"`
+	MaxPayloadSize              int                       `json:"max_payload_size"`
+	RetentionSeconds            int                       `json:"retention_seconds"`
+	DisconnectCountsAttempt     bool                      `json:"disconnect_counts_attempt"`
+	CreatedAt                   string                    `json:"created_at"`
+	UpdatedAt                   string                    `json:"updated_at"`
+}
+
+type responseBodyQueueBackoff struct {
+	Policy         string `json:"policy"`
+	InitialSeconds int    `json:"initial_seconds"`
+	MaxSeconds     int    `json:"max_seconds"`
+}
+
+type handler struct {
+	queueService queueService
+	logger       log.Logger
+}
+
+func newHandler(queueService queueService, logger log.Logger) *handler {
+	logger = logger.With("module", "internal/transport/http/endpoints/v1_queues_put")
+
+	return &handler{
+		queueService: queueService,
+		logger:       logger,
+	}
+}
+
+func (h *handler) Handle(ctx transport.Context) {
+	queue := parseRequest(ctx)
+	if queue == nil {
+		return
+	}
+
+	if err := h.queueService.SaveQueue(ctx, queue); err != nil {
+		h.logger.Log(log.LevelError).
+			With("message", "queue service error").
+			With("error", err.Error()).
+			With("queue_name", queue.Name).
+			Write()
+
+		transport.WriteInternalError(ctx)
+		return
+	}
+
+	transport.Write(ctx, http.StatusOK, &responseBody{
+		Queue: &responseBodyQueue{
+			Name:                        queue.Name,
+			VisibilityTimeoutSeconds:    int(queue.VisibilityTimeout.Seconds()),
+			MaxVisibilityTimeoutSeconds: int(queue.MaxVisibilityTimeout.Seconds()),
+			Backoff: &responseBodyQueueBackoff{
+				Policy:         queue.Backoff.Policy,
+				InitialSeconds: int(queue.Backoff.Initial.Seconds()),
+				MaxSeconds:     int(queue.Backoff.Max.Seconds()),
+			},
+			MaxAttempts:             queue.MaxAttempts,
+			MaxPayloadSize:          queue.MaxPayloadSize,
+			RetentionSeconds:        int(queue.Retention.Seconds()),
+			DisconnectCountsAttempt: queue.DisconnectCountsAttempt,
+			CreatedAt:               queue.CreatedAt.Format(time.DateTime),
+			UpdatedAt:               queue.UpdatedAt.Format(time.DateTime),
+		},
+	})
+}
+
+func parseRequest(ctx transport.Context) *domain.Queue {
+	queueName := ctx.Request().PathValue("queueName")
+
+	if len(queueName) == 0 {
+		transport.WriteBadRequestFields(ctx, transport.CommonResponseBodyField{
+			Name:   "queueName",
+			Reason: transport.ReasonEmpty,
+		})
+		return nil
+	}
+
+	var rb requestBody
+	if err := json.NewDecoder(ctx.Request().Body).Decode(&rb); err != nil {
+		transport.WriteInvalidRequestBody(ctx)
+		return nil
+	}
+
+	queue := domain.NewQueue(queueName)
+	var fields []transport.CommonResponseBodyField
+
+	if rb.VisibilityTimeoutSeconds != nil {
+		fields = checkRange(fields, "visibility_timeout_seconds", *rb.VisibilityTimeoutSeconds, 1, maxTimeoutSeconds)
+		queue.VisibilityTimeout = seconds(*rb.VisibilityTimeoutSeconds)
+	}
+
+	if rb.MaxVisibilityTimeoutSeconds != nil {
+		fields = checkRange(fields, "max_visibility_timeout_seconds", *rb.MaxVisibilityTimeoutSeconds, 1, maxTimeoutSeconds)
+		queue.MaxVisibilityTimeout = seconds(*rb.MaxVisibilityTimeoutSeconds)
+	}
+
+	if queue.MaxVisibilityTimeout < queue.VisibilityTimeout {
+		fields = append(fields, transport.CommonResponseBodyField{
+			Name:    "max_visibility_timeout_seconds",
+			Reason:  transport.ReasonTooSmall,
+			Message: "must not be less than visibility_timeout_seconds",
+		})
+	}
+
+	if rb.Backoff != nil {
+		if rb.Backoff.Policy != nil {
+			if !domain.IsBackoffPolicy(*rb.Backoff.Policy) {
+				fields = append(fields, transport.CommonResponseBodyField{
+					Name:   "backoff.policy",
+					Reason: transport.ReasonInvalid,
+				})
+			}
+
+			queue.Backoff.Policy = *rb.Backoff.Policy
+		}
+
+		if rb.Backoff.InitialSeconds != nil {
+			fields = checkRange(fields, "backoff.initial_seconds", *rb.Backoff.InitialSeconds, 1, maxBackoffSeconds)
+			queue.Backoff.Initial = seconds(*rb.Backoff.InitialSeconds)
+		}
+
+		if rb.Backoff.MaxSeconds != nil {
+			fields = checkRange(fields, "backoff.max_seconds", *rb.Backoff.MaxSeconds, 0, maxBackoffSeconds)
+			queue.Backoff.Max = seconds(*rb.Backoff.MaxSeconds)
+		} else if queue.Backoff.Initial > queue.Backoff.Max {
+			// The default cap must not reject a greater initial delay
+			queue.Backoff.Max = queue.Backoff.Initial
+		}
+
+		if queue.Backoff.Max > 0 && queue.Backoff.Max < queue.Backoff.Initial {
+			fields = append(fields, transport.CommonResponseBodyField{
+				Name:    "backoff.max_seconds",
+				Reason:  transport.ReasonTooSmall,
+				Message: "must not be less than backoff.initial_seconds",
+			})
+		}
+	}
+
+	if rb.MaxAttempts != nil {
+		fields = checkRange(fields, "max_attempts", *rb.MaxAttempts, 0, maxMaxAttempts)
+		queue.MaxAttempts = *rb.MaxAttempts
+	}
+
+	if rb.MaxPayloadSize != nil {
+		fields = checkRange(fields, "max_payload_size", *rb.MaxPayloadSize, 1, maxMaxPayloadSize)
+		queue.MaxPayloadSize = *rb.MaxPayloadSize
+	}
+
+	if rb.RetentionSeconds != nil {
+		fields = checkRange(fields, "retention_seconds", *rb.RetentionSeconds, 0, maxRetentionSeconds)
+		queue.Retention = seconds(*rb.RetentionSeconds)
+	}
+
+	if rb.DisconnectCountsAttempt != nil {
+		queue.DisconnectCountsAttempt = *rb.DisconnectCountsAttempt
+	}
+
+	if len(fields) > 0 {
+		transport.WriteBadRequestFields(ctx, fields...)
+		return nil
+	}
+
+	return queue
+}
+
+func checkRange(
+	fields []transport.CommonResponseBodyField,
+	name string,
+	value, min, max int,
+) []transport.CommonResponseBodyField {
+	if value < min {
+		return append(fields, transport.CommonResponseBodyField{
+			Name:   name,
+			Reason: transport.ReasonTooSmall,
+		})
+	}
+
+	if value > max {
+		return append(fields, transport.CommonResponseBodyField{
+			Name:   name,
+			Reason: transport.ReasonTooLarge,
+		})
+	}
+
+	return fields
+}
+
+func seconds(in int) time.Duration {
+	return time.Duration(in) * time.Second
+}
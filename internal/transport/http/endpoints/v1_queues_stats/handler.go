@@ -0,0 +1,105 @@
+package v1_queues_stats
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/art-es/queue-service/internal/app/domain"
+	"github.com/art-es/queue-service/internal/infra/log"
+	"github.com/art-es/queue-service/internal/infra/ops"
+	transport "github.com/art-es/queue-service/internal/transport/http"
+)
+
+type responseBody struct {
+	Stats *responseBodyStats `json:"stats"`
+}
+
+type responseBodyStats struct {
+	QueueName               string                    `json:"queue_name"`
+	Pending                 int64                     `json:"pending"`
+	Processing              int64                     `json:"processing"`
+	Failed                  int64                     `json:"failed"`
+	Dead                    int64                     `json:"dead"`
+	OldestPendingAgeSeconds *int64                    `json:"oldest_pending_age_seconds"`
+	NextFailedUnlockAt      *string                   `json:"next_failed_unlock_at"`
+	Throughput              []*responseBodyThroughput `json:"throughput"`
+}
+
+type responseBodyThroughput struct {
+	PeriodSeconds int64 `json:"period_seconds"`
+	Pushed        int64 `json:"pushed"`
+	Acked         int64 `json:"acked"`
+	Failed        int64 `json:"failed"`
+}
+
+type handler struct {
+	queueService queueService
+	logger       log.Logger
+}
+
+func newHandler(queueService queueService, logger log.Logger) *handler {
+	logger = logger.With("module", "internal/transport/http/endpoints/v1_queues_stats")
+
+	return &handler{
+		queueService: queueService,
+		logger:       logger,
+	}
+}
+
+func (h *handler) Handle(ctx transport.Context) {
+	queueName := ctx.Request().PathValue("queueName")
+
+	if len(queueName) == 0 {
+		transport.WriteBadRequestFields(ctx, transport.CommonResponseBodyField{
+			Name:   "queueName",
+			Reason: transport.ReasonEmpty,
+		})
+		return
+	}
+
+	stats, err := h.queueService.GetQueueStats(ctx, queueName)
+	if err != nil {
+		h.logger.Log(log.LevelError).
+			With("message", "queue service error").
+			With("error", err.Error()).
+			With("queue_name", queueName).
+			Write()
+
+		transport.WriteInternalError(ctx)
+		return
+	}
+
+	transport.Write(ctx, http.StatusOK, &responseBody{
+		Stats: toResponseBodyStats(stats),
+	})
+}
+
+func toResponseBodyStats(stats *domain.QueueStats) *responseBodyStats {
+	rb := &responseBodyStats{
+		QueueName:  stats.QueueName,
+		Pending:    stats.Pending,
+		Processing: stats.Processing,
+		Failed:     stats.Failed,
+		Dead:       stats.Dead,
+		Throughput: make([]*responseBodyThroughput, 0, len(stats.Throughput)),
+	}
+
+	if stats.OldestPendingAge != nil {
+		rb.OldestPendingAgeSeconds = ops.Pointer(int64(stats.OldestPendingAge.Seconds()))
+	}
+
+	if stats.NextFailedUnlockAt != nil {
+		rb.NextFailedUnlockAt = ops.Pointer(stats.NextFailedUnlockAt.Format(time.DateTime))
+	}
+
+	for _, throughput := range stats.Throughput {
+		rb.Throughput = append(rb.Throughput, &responseBodyThroughput{
+			PeriodSeconds: int64(throughput.Period.Seconds()),
+			Pushed:        throughput.Pushed,
+			Acked:         throughput.Acked,
+			Failed:        throughput.Failed,
+		})
+	}
+
+	return rb
+}
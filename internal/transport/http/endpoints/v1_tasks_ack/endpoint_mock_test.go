@@ -0,0 +1,56 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: endpoint.go
+//
+// Generated by this command:
+//
+//	mockgen -source=endpoint.go -destination=endpoint_mock_test.go -package=v1_tasks_ack
+//
+
+// Package v1_tasks_ack is a generated GoMock package.
+package v1_tasks_ack
+
+import (
+	context "context"
+	reflect "reflect"
+
+	task "github.com/art-es/queue-service/internal/app/services/task"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MocktaskService is a mock of taskService interface.
+type MocktaskService struct {
+	ctrl     *gomock.Controller
+	recorder *MocktaskServiceMockRecorder
+	isgomock struct{}
+}
+
+// MocktaskServiceMockRecorder is the mock recorder for MocktaskService.
+type MocktaskServiceMockRecorder struct {
+	mock *MocktaskService
+}
+
+// NewMocktaskService creates a new mock instance.
+func NewMocktaskService(ctrl *gomock.Controller) *MocktaskService {
+	mock := &MocktaskService{ctrl: ctrl}
+	mock.recorder = &MocktaskServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MocktaskService) EXPECT() *MocktaskServiceMockRecorder {
+	return m.recorder
+}
+
+// Ack mocks base method.
+func (m *MocktaskService) Ack(ctx context.Context, req *task.AckRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ack", ctx, req)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Ack indicates an expected call of Ack.
+func (mr *MocktaskServiceMockRecorder) Ack(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ack", reflect.TypeOf((*MocktaskService)(nil).Ack), ctx, req)
+}
@@ -1,14 +1,26 @@
 package v1_tasks_ack
 
 import (
+	"encoding/json"
+	"errors"
 	"net/http"
 
 	"github.com/google/uuid"
 
+	"github.com/art-es/queue-service/internal/app/domain"
+	"github.com/art-es/queue-service/internal/app/services/task"
 	"github.com/art-es/queue-service/internal/infra/log"
 	transport "github.com/art-es/queue-service/internal/transport/http"
 )
 
+const (
+	messageLeaseLost = "Task lease is lost"
+)
+
+type requestBody struct {
+	LeaseToken string `json:"lease_token"`
+}
+
 type handler struct {
 	taskService taskService
 	logger      log.Logger
@@ -24,6 +36,31 @@ func newHandler(taskService taskService, logger log.Logger) *handler {
 }
 
 func (h *handler) Handle(ctx transport.Context) {
+	req := parseRequest(ctx)
+	if req == nil {
+		return
+	}
+
+	if err := h.taskService.Ack(ctx, req); err != nil {
+		if errors.Is(err, domain.ErrLeaseLost) {
+			transport.WriteConflict(ctx, messageLeaseLost)
+			return
+		}
+
+		h.logger.Log(log.LevelError).
+			With("message", "task service error").
+			With("error", err.Error()).
+			With("task_id", req.TaskID).
+			Write()
+
+		transport.WriteInternalError(ctx)
+		return
+	}
+
+	transport.WriteEmpty(ctx, http.StatusNoContent)
+}
+
+func parseRequest(ctx transport.Context) *task.AckRequest {
 	taskID := ctx.Request().PathValue("taskId")
 
 	if len(taskID) == 0 {
@@ -31,7 +68,7 @@ func (h *handler) Handle(ctx transport.Context) {
 			Name:   "taskId",
 			Reason: transport.ReasonEmpty,
 		})
-		return
+		return nil
 	}
 
 	if err := uuid.Validate(taskID); err != nil {
@@ -40,19 +77,35 @@ func (h *handler) Handle(ctx transport.Context) {
 			Reason:  transport.ReasonInvalid,
 			Message: err.Error(),
 		})
-		return
+		return nil
 	}
 
-	if err := h.taskService.Ack(ctx, taskID, transport.GetIdempotencyKey(ctx)); err != nil {
-		h.logger.Log(log.LevelError).
-			With("message", "task service error").
-			With("error", err.Error()).
-			With("task_id", taskID).
-			Write()
+	var rb requestBody
+	if err := json.NewDecoder(ctx.Request().Body).Decode(&rb); err != nil {
+		transport.WriteInvalidRequestBody(ctx)
+		return nil
+	}
 
-		transport.WriteInternalError(ctx)
-		return
+	if rb.LeaseToken == "" {
+		transport.WriteBadRequestFields(ctx, transport.CommonResponseBodyField{
+			Name:   "lease_token",
+			Reason: transport.ReasonEmpty,
+		})
+		return nil
 	}
 
-	transport.WriteEmpty(ctx, http.StatusNoContent)
+	if err := uuid.Validate(rb.LeaseToken); err != nil {
+		transport.WriteBadRequestFields(ctx, transport.CommonResponseBodyField{
+			Name:    "lease_token",
+			Reason:  transport.ReasonInvalid,
+			Message: err.Error(),
+		})
+		return nil
+	}
+
+	return &task.AckRequest{
+		TaskID:         taskID,
+		LeaseToken:     rb.LeaseToken,
+		IdempotencyKey: transport.GetIdempotencyKey(ctx),
+	}
 }
@@ -0,0 +1,20 @@
+//go:generate mockgen -source=endpoint.go -destination=endpoint_mock_test.go -package=$GOPACKAGE
+
+package v1_tasks_extend
+
+import (
+	"context"
+
+	"github.com/art-es/queue-service/internal/app/domain"
+	"github.com/art-es/queue-service/internal/app/services/task"
+	"github.com/art-es/queue-service/internal/infra/log"
+	transport "github.com/art-es/queue-service/internal/transport/http"
+)
+
+type taskService interface {
+	Extend(ctx context.Context, req *task.ExtendRequest) (*domain.Task, error)
+}
+
+func Register(router transport.Router, taskService taskService, logger log.Logger) {
+	router.Register("POST /v1/tasks/{taskId}/extend", newHandler(taskService, logger))
+}
@@ -0,0 +1,58 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: endpoint.go
+//
+// Generated by this command:
+//
+//	mockgen -source=endpoint.go -destination=endpoint_mock_test.go -package=v1_tasks_extend
+//
+
+// Package v1_tasks_extend is a generated GoMock package.
+package v1_tasks_extend
+
+import (
+	context "context"
+	reflect "reflect"
+
+	domain "github.com/art-es/queue-service/internal/app/domain"
+	task "github.com/art-es/queue-service/internal/app/services/task"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MocktaskService is a mock of taskService interface.
+type MocktaskService struct {
+	ctrl     *gomock.Controller
+	recorder *MocktaskServiceMockRecorder
+	isgomock struct{}
+}
+
+// MocktaskServiceMockRecorder is the mock recorder for MocktaskService.
+type MocktaskServiceMockRecorder struct {
+	mock *MocktaskService
+}
+
+// NewMocktaskService creates a new mock instance.
+func NewMocktaskService(ctrl *gomock.Controller) *MocktaskService {
+	mock := &MocktaskService{ctrl: ctrl}
+	mock.recorder = &MocktaskServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MocktaskService) EXPECT() *MocktaskServiceMockRecorder {
+	return m.recorder
+}
+
+// Extend mocks base method.
+func (m *MocktaskService) Extend(ctx context.Context, req *task.ExtendRequest) (*domain.Task, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Extend", ctx, req)
+	ret0, _ := ret[0].(*domain.Task)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Extend indicates an expected call of Extend.
+func (mr *MocktaskServiceMockRecorder) Extend(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Extend", reflect.TypeOf((*MocktaskService)(nil).Extend), ctx, req)
+}
@@ -0,0 +1,151 @@
+package v1_tasks_extend
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/art-es/queue-service/internal/app/domain"
+	"github.com/art-es/queue-service/internal/app/services/task"
+	"github.com/art-es/queue-service/internal/infra/log"
+	transport "github.com/art-es/queue-service/internal/transport/http"
+)
+
+const (
+	messageLeaseLost = "Task lease is lost"
+	// The lock is capped by the queue's max visibility timeout, which is 12 hours at most
+	maxDurationSeconds = 60 * 60 * 12
+)
+
+type requestBody struct {
+	LeaseToken      string `json:"lease_token"`
+	DurationSeconds int    `json:"duration_seconds"`
+}
+
+type responseBody struct {
+	Task *responseBodyTask `json:"task"`
+}
+
+type responseBodyTask struct {
+	ID          string `json:"id"`
+	LockedUntil string `json:"locked_until"`
+}
+
+type handler struct {
+	taskService taskService
+	logger      log.Logger
+}
+
+func newHandler(taskService taskService, logger log.Logger) *handler {
+	logger = logger.With("module", "internal/transport/http/endpoints/v1_tasks_extend")
+
+	return &handler{
+		taskService: taskService,
+		logger:      logger,
+	}
+}
+
+func (h *handler) Handle(ctx transport.Context) {
+	req := parseRequest(ctx)
+	if req == nil {
+		return
+	}
+
+	extended, err := h.taskService.Extend(ctx, req)
+	if err != nil {
+		if errors.Is(err, domain.ErrLeaseLost) {
+			transport.WriteConflict(ctx, messageLeaseLost)
+			return
+		}
+
+		if errors.Is(err, task.ErrInvalidDuration) {
+			transport.WriteBadRequest(ctx, err.Error())
+			return
+		}
+
+		h.logger.Log(log.LevelError).
+			With("message", "task service error").
+			With("error", err.Error()).
+			With("task_id", req.TaskID).
+			Write()
+
+		transport.WriteInternalError(ctx)
+		return
+	}
+
+	transport.Write(ctx, http.StatusOK, &responseBody{
+		Task: &responseBodyTask{
+			ID:          extended.ID,
+			LockedUntil: extended.LockedUntil.Format(time.DateTime),
+		},
+	})
+}
+
+func parseRequest(ctx transport.Context) *task.ExtendRequest {
+	taskID := ctx.Request().PathValue("taskId")
+
+	if len(taskID) == 0 {
+		transport.WriteBadRequestFields(ctx, transport.CommonResponseBodyField{
+			Name:   "taskId",
+			Reason: transport.ReasonEmpty,
+		})
+		return nil
+	}
+
+	if err := uuid.Validate(taskID); err != nil {
+		transport.WriteBadRequestFields(ctx, transport.CommonResponseBodyField{
+			Name:    "taskId",
+			Reason:  transport.ReasonInvalid,
+			Message: err.Error(),
+		})
+		return nil
+	}
+
+	var rb requestBody
+	if err := json.NewDecoder(ctx.Request().Body).Decode(&rb); err != nil {
+		transport.WriteInvalidRequestBody(ctx)
+		return nil
+	}
+
+	if rb.LeaseToken == "" {
+		transport.WriteBadRequestFields(ctx, transport.CommonResponseBodyField{
+			Name:   "lease_token",
+			Reason: transport.ReasonEmpty,
+		})
+		return nil
+	}
+
+	if err := uuid.Validate(rb.LeaseToken); err != nil {
+		transport.WriteBadRequestFields(ctx, transport.CommonResponseBodyField{
+			Name:    "lease_token",
+			Reason:  transport.ReasonInvalid,
+			Message: err.Error(),
+		})
+		return nil
+	}
+
+	if rb.DurationSeconds <= 0 {
+		transport.WriteBadRequestFields(ctx, transport.CommonResponseBodyField{
+			Name:   "duration_seconds",
+			Reason: transport.ReasonTooSmall,
+		})
+		return nil
+	}
+
+	if rb.DurationSeconds > maxDurationSeconds {
+		transport.WriteBadRequestFields(ctx, transport.CommonResponseBodyField{
+			Name:   "duration_seconds",
+			Reason: transport.ReasonTooLarge,
+		})
+		return nil
+	}
+
+	return &task.ExtendRequest{
+		TaskID:     taskID,
+		LeaseToken: rb.LeaseToken,
+		Duration:   time.Duration(rb.DurationSeconds) * time.Second,
+	}
+}
@@ -0,0 +1,103 @@
+package v1_tasks_extend
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/art-es/queue-service/internal/app/domain"
+	"github.com/art-es/queue-service/internal/app/services/task"
+	"github.com/art-es/queue-service/internal/infra/log/logimpl"
+	"github.com/art-es/queue-service/internal/infra/metrics/metricsimpl"
+	"github.com/art-es/queue-service/internal/infra/ops"
+	"github.com/art-es/queue-service/internal/transport/http/adapter"
+	"github.com/stretchr/testify/assert"
+	gomock "go.uber.org/mock/gomock"
+)
+
+func TestHandler_Handle(t *testing.T) {
+	const (
+		taskID     = "0b0a6a3e-2f5c-4b8e-9d7a-3c1e5f7a9b2d"
+		leaseToken = "5d3c1b7e-8a2f-4e6d-b9c0-1f2e3d4c5b6a"
+	)
+
+	type testDeps struct {
+		mockTaskService *MocktaskService
+		extend          func(durationSeconds int) *httptest.ResponseRecorder
+	}
+
+	for _, tc := range []struct {
+		name string
+		run  func(t *testing.T, d testDeps)
+	}{
+		{
+			name: "extend task",
+			run: func(t *testing.T, d testDeps) {
+				d.mockTaskService.EXPECT().
+					Extend(gomock.Any(), gomock.Eq(&task.ExtendRequest{
+						TaskID:     taskID,
+						LeaseToken: leaseToken,
+						Duration:   maxDurationSeconds * time.Second,
+					})).
+					Return(&domain.Task{
+						ID:          taskID,
+						LockedUntil: ops.Pointer(time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)),
+					}, nil)
+
+				rec := d.extend(maxDurationSeconds)
+				assert.Equal(t, http.StatusOK, rec.Code)
+				assert.JSONEq(t, `{"task":{"id":"`+taskID+`","locked_until":"2006-01-02 15:04:05"}}`, rec.Body.String())
+			},
+		},
+		{
+			name: "duration too large",
+			run: func(t *testing.T, d testDeps) {
+				rec := d.extend(maxDurationSeconds + 1)
+				assert.Equal(t, http.StatusBadRequest, rec.Code)
+				assert.JSONEq(t, `{"fields":[{"name":"duration_seconds","reason":"TOO_LARGE"}]}`, rec.Body.String())
+			},
+		},
+		{
+			name: "invalid duration",
+			run: func(t *testing.T, d testDeps) {
+				d.mockTaskService.EXPECT().
+					Extend(gomock.Any(), gomock.Any()).
+					Return(nil, task.ErrInvalidDuration)
+
+				rec := d.extend(1)
+				assert.Equal(t, http.StatusBadRequest, rec.Code)
+				assert.JSONEq(t, `{"message":"duration must be positive"}`, rec.Body.String())
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mc := gomock.NewController(t)
+			defer mc.Finish()
+
+			mockTaskService := NewMocktaskService(mc)
+			logger, _ := logimpl.NewTestLogger()
+
+			router := adapter.NewMuxRouter(metricsimpl.NewRegistry())
+			Register(router, mockTaskService, logger)
+
+			tc.run(t, testDeps{
+				mockTaskService: mockTaskService,
+				extend: func(durationSeconds int) *httptest.ResponseRecorder {
+					req := httptest.NewRequestWithContext(
+						context.Background(),
+						http.MethodPost,
+						"/v1/tasks/"+taskID+"/extend",
+						strings.NewReader(`{"lease_token":"`+leaseToken+`","duration_seconds":`+strconv.Itoa(durationSeconds)+`}`),
+					)
+					rec := httptest.NewRecorder()
+					router.Mux.ServeHTTP(rec, req)
+					return rec
+				},
+			})
+		})
+	}
+}
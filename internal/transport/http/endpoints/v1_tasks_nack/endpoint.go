@@ -1,14 +1,17 @@
+//go:generate mockgen -source=endpoint.go -destination=endpoint_mock_test.go -package=$GOPACKAGE
+
 package v1_tasks_nack
 
 import (
 	"context"
 
+	"github.com/art-es/queue-service/internal/app/services/task"
 	"github.com/art-es/queue-service/internal/infra/log"
 	transport "github.com/art-es/queue-service/internal/transport/http"
 )
 
 type taskService interface {
-	Nack(ctx context.Context, taskID string, idempotencyKey *string) error
+	Nack(ctx context.Context, req *task.NackRequest) error
 }
 
 func Register(router transport.Router, taskService taskService, logger log.Logger) {
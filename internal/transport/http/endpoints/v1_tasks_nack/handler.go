@@ -1,14 +1,30 @@
 package v1_tasks_nack
 
 import (
+	"encoding/json"
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/google/uuid"
 
+	"github.com/art-es/queue-service/internal/app/domain"
+	"github.com/art-es/queue-service/internal/app/services/task"
 	"github.com/art-es/queue-service/internal/infra/log"
+	"github.com/art-es/queue-service/internal/infra/ops"
 	transport "github.com/art-es/queue-service/internal/transport/http"
 )
 
+const (
+	messageLeaseLost     = "Task lease is lost"
+	maxRetryAfterSeconds = 60 * 60 * 24 * 7
+)
+
+type requestBody struct {
+	LeaseToken string `json:"lease_token"`
+	RetryAfter *int   `json:"retry_after"`
+}
+
 type handler struct {
 	taskService taskService
 	logger      log.Logger
@@ -24,6 +40,31 @@ func newHandler(taskService taskService, logger log.Logger) *handler {
 }
 
 func (h *handler) Handle(ctx transport.Context) {
+	req := parseRequest(ctx)
+	if req == nil {
+		return
+	}
+
+	if err := h.taskService.Nack(ctx, req); err != nil {
+		if errors.Is(err, domain.ErrLeaseLost) {
+			transport.WriteConflict(ctx, messageLeaseLost)
+			return
+		}
+
+		h.logger.Log(log.LevelError).
+			With("message", "task service error").
+			With("error", err.Error()).
+			With("task_id", req.TaskID).
+			Write()
+
+		transport.WriteInternalError(ctx)
+		return
+	}
+
+	transport.WriteEmpty(ctx, http.StatusNoContent)
+}
+
+func parseRequest(ctx transport.Context) *task.NackRequest {
 	taskID := ctx.Request().PathValue("taskId")
 
 	if len(taskID) == 0 {
@@ -31,7 +72,7 @@ func (h *handler) Handle(ctx transport.Context) {
 			Name:   "taskId",
 			Reason: transport.ReasonEmpty,
 		})
-		return
+		return nil
 	}
 
 	if err := uuid.Validate(taskID); err != nil {
@@ -40,19 +81,57 @@ func (h *handler) Handle(ctx transport.Context) {
 			Reason:  transport.ReasonInvalid,
 			Message: err.Error(),
 		})
-		return
+		return nil
 	}
 
-	if err := h.taskService.Nack(ctx, taskID, transport.GetIdempotencyKey(ctx)); err != nil {
-		h.logger.Log(log.LevelError).
-			With("message", "task service error").
-			With("error", err.Error()).
-			With("task_id", taskID).
-			Write()
+	var rb requestBody
+	if err := json.NewDecoder(ctx.Request().Body).Decode(&rb); err != nil {
+		transport.WriteInvalidRequestBody(ctx)
+		return nil
+	}
 
-		transport.WriteInternalError(ctx)
-		return
+	if rb.LeaseToken == "" {
+		transport.WriteBadRequestFields(ctx, transport.CommonResponseBodyField{
+			Name:   "lease_token",
+			Reason: transport.ReasonEmpty,
+		})
+		return nil
 	}
 
-	transport.WriteEmpty(ctx, http.StatusNoContent)
+	if err := uuid.Validate(rb.LeaseToken); err != nil {
+		transport.WriteBadRequestFields(ctx, transport.CommonResponseBodyField{
+			Name:    "lease_token",
+			Reason:  transport.ReasonInvalid,
+			Message: err.Error(),
+		})
+		return nil
+	}
+
+	req := &task.NackRequest{
+		TaskID:         taskID,
+		LeaseToken:     rb.LeaseToken,
+		IdempotencyKey: transport.GetIdempotencyKey(ctx),
+	}
+
+	if rb.RetryAfter != nil {
+		if *rb.RetryAfter < 0 {
+			transport.WriteBadRequestFields(ctx, transport.CommonResponseBodyField{
+				Name:   "retry_after",
+				Reason: transport.ReasonTooSmall,
+			})
+			return nil
+		}
+
+		if *rb.RetryAfter > maxRetryAfterSeconds {
+			transport.WriteBadRequestFields(ctx, transport.CommonResponseBodyField{
+				Name:   "retry_after",
+				Reason: transport.ReasonTooLarge,
+			})
+			return nil
+		}
+
+		req.RetryAfter = ops.Pointer(time.Duration(*rb.RetryAfter) * time.Second)
+	}
+
+	return req
 }
@@ -0,0 +1,105 @@
+package v1_tasks_nack
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/art-es/queue-service/internal/app/domain"
+	"github.com/art-es/queue-service/internal/app/services/task"
+	"github.com/art-es/queue-service/internal/infra/log/logimpl"
+	"github.com/art-es/queue-service/internal/infra/metrics/metricsimpl"
+	"github.com/art-es/queue-service/internal/transport/http/adapter"
+	"github.com/stretchr/testify/assert"
+	gomock "go.uber.org/mock/gomock"
+)
+
+func TestHandler_Handle(t *testing.T) {
+	const (
+		taskID     = "0b0a6a3e-2f5c-4b8e-9d7a-3c1e5f7a9b2d"
+		leaseToken = "5d3c1b7e-8a2f-4e6d-b9c0-1f2e3d4c5b6a"
+	)
+
+	expReq := &task.NackRequest{
+		TaskID:     taskID,
+		LeaseToken: leaseToken,
+	}
+
+	type testDeps struct {
+		mockTaskService *MocktaskService
+		nack            func() *httptest.ResponseRecorder
+	}
+
+	for _, tc := range []struct {
+		name string
+		run  func(t *testing.T, d testDeps)
+	}{
+		{
+			name: "nack task",
+			run: func(t *testing.T, d testDeps) {
+				d.mockTaskService.EXPECT().
+					Nack(gomock.Any(), gomock.Eq(expReq)).
+					Return(nil)
+
+				rec := d.nack()
+				assert.Equal(t, http.StatusNoContent, rec.Code)
+			},
+		},
+		{
+			name: "lock expired",
+			run: func(t *testing.T, d testDeps) {
+				d.mockTaskService.EXPECT().
+					Nack(gomock.Any(), gomock.Eq(expReq)).
+					Return(domain.ErrLeaseLost)
+
+				rec := d.nack()
+				assert.Equal(t, http.StatusConflict, rec.Code)
+				assert.JSONEq(t, `{"message":"Task lease is lost"}`, rec.Body.String())
+			},
+		},
+		{
+			name: "nack task twice",
+			run: func(t *testing.T, d testDeps) {
+				gomock.InOrder(
+					d.mockTaskService.EXPECT().
+						Nack(gomock.Any(), gomock.Eq(expReq)).
+						Return(nil),
+					d.mockTaskService.EXPECT().
+						Nack(gomock.Any(), gomock.Eq(expReq)).
+						Return(domain.ErrLeaseLost),
+				)
+
+				assert.Equal(t, http.StatusNoContent, d.nack().Code)
+				assert.Equal(t, http.StatusConflict, d.nack().Code)
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mc := gomock.NewController(t)
+			defer mc.Finish()
+
+			mockTaskService := NewMocktaskService(mc)
+			logger, _ := logimpl.NewTestLogger()
+
+			router := adapter.NewMuxRouter(metricsimpl.NewRegistry())
+			Register(router, mockTaskService, logger)
+
+			tc.run(t, testDeps{
+				mockTaskService: mockTaskService,
+				nack: func() *httptest.ResponseRecorder {
+					req := httptest.NewRequestWithContext(
+						context.Background(),
+						http.MethodPost,
+						"/v1/tasks/"+taskID+"/nack",
+						strings.NewReader(`{"lease_token":"`+leaseToken+`"}`),
+					)
+					rec := httptest.NewRecorder()
+					router.Mux.ServeHTTP(rec, req)
+					return rec
+				},
+			})
+		})
+	}
+}
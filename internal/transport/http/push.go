@@ -0,0 +1,110 @@
+package http
+
+import (
+	"time"
+
+	"github.com/art-es/queue-service/internal/infra/ops"
+)
+
+const (
+	maxPushPayloadSize  = 1024 * 1024 // Hard limit, queues limit payloads further with max_payload_size
+	maxPushDelaySeconds = 60 * 60 * 24 * 7
+	minPushPriority     = -1000
+	maxPushPriority     = 1000
+)
+
+// PushFields are the task fields of a push request body, shared by the push endpoints.
+type PushFields struct {
+	Payload      string  `json:"payload"`
+	DelaySeconds *int    `json:"delay_seconds"`
+	NotBefore    *string `json:"not_before"`
+	Priority     int     `json:"priority"`
+}
+
+// PushSchedule is when a pushed task becomes visible, neither is set for a task visible right away.
+type PushSchedule struct {
+	Delay     *time.Duration
+	NotBefore *time.Time
+}
+
+// CheckPushFields validates the push fields and returns the schedule of the task.
+// Invalid fields are all returned with their names prefixed, e.g. with the item of a batch.
+func CheckPushFields(prefix string, f *PushFields) (*PushSchedule, []CommonResponseBodyField) {
+	schedule := &PushSchedule{}
+	var fields []CommonResponseBodyField
+
+	if f.Payload == "" {
+		fields = append(fields, CommonResponseBodyField{
+			Name:   prefix + "payload",
+			Reason: ReasonEmpty,
+		})
+	}
+
+	if len(f.Payload) > maxPushPayloadSize {
+		fields = append(fields, CommonResponseBodyField{
+			Name:   prefix + "payload",
+			Reason: ReasonTooLarge,
+		})
+	}
+
+	if f.Priority < minPushPriority {
+		fields = append(fields, CommonResponseBodyField{
+			Name:   prefix + "priority",
+			Reason: ReasonTooSmall,
+		})
+	}
+
+	if f.Priority > maxPushPriority {
+		fields = append(fields, CommonResponseBodyField{
+			Name:   prefix + "priority",
+			Reason: ReasonTooLarge,
+		})
+	}
+
+	if f.DelaySeconds != nil && f.NotBefore != nil {
+		fields = append(fields, CommonResponseBodyField{
+			Name:    prefix + "not_before",
+			Reason:  ReasonInvalid,
+			Message: "Only one of delay_seconds and not_before can be set",
+		})
+	}
+
+	if f.DelaySeconds != nil {
+		switch {
+		case *f.DelaySeconds < 0:
+			fields = append(fields, CommonResponseBodyField{
+				Name:   prefix + "delay_seconds",
+				Reason: ReasonTooSmall,
+			})
+		case *f.DelaySeconds > maxPushDelaySeconds:
+			fields = append(fields, CommonResponseBodyField{
+				Name:   prefix + "delay_seconds",
+				Reason: ReasonTooLarge,
+			})
+		default:
+			schedule.Delay = ops.Pointer(time.Duration(*f.DelaySeconds) * time.Second)
+		}
+	}
+
+	if f.NotBefore != nil {
+		notBefore, err := time.Parse(time.RFC3339, *f.NotBefore)
+		switch {
+		case err != nil:
+			fields = append(fields, CommonResponseBodyField{
+				Name:    prefix + "not_before",
+				Reason:  ReasonInvalid,
+				Message: err.Error(),
+			})
+		// Bounded as delay_seconds, so the task is not held in the queue for good
+		case time.Until(notBefore) > maxPushDelaySeconds*time.Second:
+			fields = append(fields, CommonResponseBodyField{
+				Name:   prefix + "not_before",
+				Reason: ReasonTooLarge,
+			})
+		default:
+			schedule.NotBefore = &notBefore
+		}
+	}
+
+	return schedule, fields
+}
@@ -0,0 +1,91 @@
+package http
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/art-es/queue-service/internal/infra/ops"
+)
+
+func TestCheckPushFields(t *testing.T) {
+	notBefore := time.Now().Add(time.Hour).Truncate(time.Second).UTC()
+
+	for _, tc := range []struct {
+		name        string
+		fields      PushFields
+		expSchedule *PushSchedule
+		expFields   []CommonResponseBodyField
+	}{
+		{
+			name:        "payload only",
+			fields:      PushFields{Payload: "testPayload"},
+			expSchedule: &PushSchedule{},
+		},
+		{
+			name:        "delay",
+			fields:      PushFields{Payload: "testPayload", DelaySeconds: ops.Pointer(60)},
+			expSchedule: &PushSchedule{Delay: ops.Pointer(time.Minute)},
+		},
+		{
+			name:        "not before",
+			fields:      PushFields{Payload: "testPayload", NotBefore: ops.Pointer(notBefore.Format(time.RFC3339))},
+			expSchedule: &PushSchedule{NotBefore: &notBefore},
+		},
+		{
+			name:        "payload empty",
+			fields:      PushFields{},
+			expSchedule: &PushSchedule{},
+			expFields:   []CommonResponseBodyField{{Name: "item.payload", Reason: ReasonEmpty}},
+		},
+		{
+			name:        "payload too large",
+			fields:      PushFields{Payload: strings.Repeat("a", maxPushPayloadSize+1)},
+			expSchedule: &PushSchedule{},
+			expFields:   []CommonResponseBodyField{{Name: "item.payload", Reason: ReasonTooLarge}},
+		},
+		{
+			name:        "priority out of range",
+			fields:      PushFields{Payload: "testPayload", Priority: minPushPriority - 1},
+			expSchedule: &PushSchedule{},
+			expFields:   []CommonResponseBodyField{{Name: "item.priority", Reason: ReasonTooSmall}},
+		},
+		{
+			name: "delay and not before",
+			fields: PushFields{
+				Payload:      "testPayload",
+				DelaySeconds: ops.Pointer(60),
+				NotBefore:    ops.Pointer(notBefore.Format(time.RFC3339)),
+			},
+			expSchedule: &PushSchedule{Delay: ops.Pointer(time.Minute), NotBefore: &notBefore},
+			expFields: []CommonResponseBodyField{{
+				Name:    "item.not_before",
+				Reason:  ReasonInvalid,
+				Message: "Only one of delay_seconds and not_before can be set",
+			}},
+		},
+		{
+			name:        "delay too large",
+			fields:      PushFields{Payload: "testPayload", DelaySeconds: ops.Pointer(maxPushDelaySeconds + 1)},
+			expSchedule: &PushSchedule{},
+			expFields:   []CommonResponseBodyField{{Name: "item.delay_seconds", Reason: ReasonTooLarge}},
+		},
+		{
+			name: "not before too late",
+			fields: PushFields{
+				Payload:   "testPayload",
+				NotBefore: ops.Pointer(time.Now().Add(8 * 24 * time.Hour).Format(time.RFC3339)),
+			},
+			expSchedule: &PushSchedule{},
+			expFields:   []CommonResponseBodyField{{Name: "item.not_before", Reason: ReasonTooLarge}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			schedule, fields := CheckPushFields("item.", &tc.fields)
+			assert.Equal(t, tc.expSchedule, schedule)
+			assert.Equal(t, tc.expFields, fields)
+		})
+	}
+}
@@ -45,6 +45,30 @@ func WriteBadRequestFields(ctx Context, fields ...CommonResponseBodyField) {
 	})
 }
 
+func WriteNotFound(ctx Context, msg string) {
+	Write(ctx, http.StatusNotFound, &CommonResponseBody{
+		Message: msg,
+	})
+}
+
+func WriteConflict(ctx Context, msg string) {
+	Write(ctx, http.StatusConflict, &CommonResponseBody{
+		Message: msg,
+	})
+}
+
+func WriteUnprocessableEntity(ctx Context, msg string) {
+	Write(ctx, http.StatusUnprocessableEntity, &CommonResponseBody{
+		Message: msg,
+	})
+}
+
+func WriteServiceUnavailable(ctx Context, msg string) {
+	Write(ctx, http.StatusServiceUnavailable, &CommonResponseBody{
+		Message: msg,
+	})
+}
+
 func WriteInternalError(ctx Context) {
 	Write(ctx, http.StatusInternalServerError, &CommonResponseBody{
 		Message: messageInternalError,
@@ -58,6 +82,24 @@ func Write(ctx Context, code int, body any) {
 	_ = json.NewEncoder(w).Encode(body)
 }
 
+// WriteFlush writes like Write, but flushes the response right away and returns an error
+// if it could not be sent, e.g. the client has disconnected.
+func WriteFlush(ctx Context, code int, body any) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	w := ctx.ResponseWriter()
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		return err
+	}
+
+	return http.NewResponseController(w).Flush()
+}
+
 func WriteEmpty(ctx Context, code int) {
 	w := ctx.ResponseWriter()
 	w.Header().Set("Content-Type", "application/json")
@@ -1,10 +1,12 @@
 package consumer
 
 import (
+	"errors"
 	"io"
 	"net"
 
 	"github.com/art-es/queue-service/internal/infra/log"
+	"github.com/art-es/queue-service/internal/infra/metrics"
 )
 
 type consumeService interface {
@@ -13,12 +15,16 @@ type consumeService interface {
 
 type Server struct {
 	consumeService consumeService
+	connections    metrics.Gauge
 	logger         log.Logger
 }
 
-func NewServer(consumeService consumeService, logger log.Logger) *Server {
+func NewServer(consumeService consumeService, metrics metrics.Metrics, logger log.Logger) *Server {
+	logger = logger.With("module", "internal/transport/net/consumer")
+
 	return &Server{
 		consumeService: consumeService,
+		connections:    metrics.Gauge("consumer_connections", "Open consumer connections."),
 		logger:         logger,
 	}
 }
@@ -27,12 +33,24 @@ func (s *Server) Serve(lis net.Listener) {
 	for {
 		conn, err := lis.Accept()
 		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+
 			s.logger.Log(log.LevelError).
 				With("message", "accept conn error").
 				With("error", err.Error()).
 				Write()
+			continue
 		}
 
-		go s.consumeService.Consume(conn)
+		go s.consume(conn)
 	}
 }
+
+func (s *Server) consume(conn net.Conn) {
+	s.connections.Inc()
+	defer s.connections.Dec()
+
+	s.consumeService.Consume(conn)
+}